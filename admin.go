@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+
+	"github.com/urfave/cli"
+)
+
+// 管理员相关命令
+var adminCommand = cli.Command{
+	Name:  "admin",
+	Usage: "manage administrators",
+	Subcommands: []cli.Command{
+		{
+			Name:  "create",
+			Usage: "create a super administrator in the default tenant",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "name", Usage: "login name"},
+				cli.StringFlag{Name: "email", Usage: "email"},
+				cli.StringFlag{Name: "mobile", Usage: "mobile"},
+				cli.StringFlag{Name: "password", Usage: "password, 6-20 letters, digits or underscores"},
+				cli.StringFlag{Name: "nickname", Usage: "nickname"},
+			},
+			Action: createAdmin,
+		},
+	},
+}
+
+// 创建用户并授予超级管理员角色
+func createAdmin(c *cli.Context) error {
+	if c.String("password") == "" {
+		return errors.New("password is required")
+	}
+	config, err := config.Load(c.GlobalString("c"))
+	if err != nil {
+		return err
+	}
+	if err := models.Init(config); err != nil {
+		return err
+	}
+	userID, err := models.CreateUser(consts.DefaultTenant, &st.ImportUserDto{
+		Name:     c.String("name"),
+		Email:    c.String("email"),
+		Mobile:   c.String("mobile"),
+		Password: c.String("password"),
+		Nickname: c.String("nickname"),
+	}, "127.0.0.1")
+	if err != nil {
+		return err
+	}
+	e := authzer.NewAuthzer(nil)
+	if err := authzer.SetupSuperAdmin(e, []string{userID.Str()}, nil); err != nil {
+		return err
+	}
+	if err := models.SaveRoleGrants("", userID, []string{consts.SuperAdminRole}, nil, nil, time.Time{}, 0); err != nil {
+		return err
+	}
+	fmt.Printf("Created super administrator %s\n", userID.Str())
+	return nil
+}
@@ -33,6 +33,7 @@ func main() {
 		},
 	}
 	app.Action = run
+	app.Commands = []cli.Command{adminCommand}
 	err := app.Run(os.Args)
 	if err != nil {
 		logger.Panic("Startup error!!!", err)
@@ -9,7 +9,7 @@ import (
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/go-macaron/binding"
 	"github.com/ihuanglei/authenticator/models"
-	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
@@ -31,12 +31,25 @@ func Router(m *macaron.Macaron) {
 				m.Post("/:roleID/update", UpdateRole)
 				m.Post("/:roleID/delete", DeleteRole)
 				m.Get("/:roleID/resource", GetRoleResources)
+				m.Get("/:roleID/parents", GetRoleParents)
+				m.Post("/:roleID/parents", AddRoleParent)
+				m.Post("/:roleID/parents/:parentID/delete", RemoveRoleParent)
+				m.Get("/:roleID/condition", GetRoleConditions)
+				m.Post("/:roleID/condition", binding.Bind(st.PolicyConditionForm{}), SetRoleCondition)
 			})
-			m.Get("/resource", binding.Bind(st.ResourceQuery{}), GetResources)
-		})
+			m.Group("/resource", func() {
+				m.Get("/", binding.Bind(st.ResourceQuery{}), GetResources)
+				m.Post("/create", binding.Bind(st.ResourceForm{}), CreateResource)
+				m.Post("/:resID/update", binding.Bind(st.ResourceForm{}), UpdateResource)
+				m.Post("/:resID/delete", DeleteResource)
+			})
+		}, DefaultTenantOnly)
 
 		m.Group("/user", func() {
 			m.Get("/", binding.Bind(st.UserQuery{}), GetUsers)
+			m.Post("/create", binding.Bind(st.CreateUserForm{}), CreateUser)
+			m.Post("/import", ImportUsers)
+			m.Get("/export", binding.Bind(st.UserQuery{}), ExportUsers)
 			m.Get("/:userID", GetUser)
 			m.Post("/:userID/forbidden/:forbidden", Forbidden)
 			m.Post("/:userID/password", ChangePassword)
@@ -44,7 +57,70 @@ func Router(m *macaron.Macaron) {
 			m.Post("/:userID/activate", ActivateUser)
 			m.Post("/:userID/role", AddRoleForUser)
 			m.Get("/:userID/role", GetRoleForUser)
+			m.Get("/:userID/role/log", binding.Bind(st.EmptyQuery{}), GetRoleGrantLogs)
 			m.Get("/:userID/login", binding.Bind(st.EmptyQuery{}), GetUserLogins)
+			m.Post("/:userID/export", ExportUser)
+			m.Get("/:userID/address", GetUserAddresses)
+			m.Post("/:userID/attribute", SetUserAttributes)
+			m.Post("/:userID/merge", MergeUser)
+			m.Get("/:userID/merge", GetUserMerges)
+			m.Get("/:userID/legal", GetUserLegals)
+		})
+
+		m.Group("/org", func() {
+			m.Get("/", binding.Bind(st.OrgQuery{}), GetOrgs)
+			m.Get("/:orgID", GetOrg)
+			m.Post("/:orgID/delete", DeleteOrg)
+			m.Post("/:orgID/transfer", binding.Bind(st.OrgMemberForm{}), TransferOrg)
+			m.Get("/:orgID/member", binding.Bind(st.EmptyQuery{}), GetOrgMembers)
+			m.Post("/:orgID/member", binding.Bind(st.OrgMemberForm{}), AddOrgMember)
+			m.Post("/:orgID/member/:userID/role", binding.Bind(st.OrgMemberForm{}), SetOrgMemberRole)
+			m.Post("/:orgID/member/:userID/remove", RemoveOrgMember)
+		})
+
+		m.Group("/attribute", func() {
+			m.Get("/", GetAttributes)
+			m.Post("/create", binding.Bind(st.AttributeForm{}), CreateAttribute)
+			m.Post("/:attrID/update", binding.Bind(st.AttributeForm{}), UpdateAttribute)
+			m.Post("/:attrID/delete", DeleteAttribute)
+		}, DefaultTenantOnly)
+
+		m.Group("/word", func() {
+			m.Get("/", binding.Bind(st.WordQuery{}), GetWords)
+			m.Post("/create", binding.Bind(st.WordForm{}), CreateWords)
+			m.Post("/:wordID/update", binding.Bind(st.WordForm{}), UpdateWord)
+			m.Post("/:wordID/delete", DeleteWord)
+		}, DefaultTenantOnly)
+
+		m.Group("/tenant", func() {
+			m.Get("/", GetTenants)
+			m.Post("/create", binding.Bind(st.TenantForm{}), CreateTenant)
+			m.Get("/:tenantID", GetTenant)
+			m.Post("/:tenantID/update", binding.Bind(st.TenantForm{}), UpdateTenant)
+			m.Post("/:tenantID/delete", DeleteTenant)
+		}, DefaultTenantOnly)
+
+		m.Group("/invite", func() {
+			m.Get("/", binding.Bind(st.InviteQuery{}), GetInvites)
+			m.Post("/create", binding.Bind(st.InviteForm{}), CreateInvites)
+			m.Post("/:inviteID/revoke", RevokeInvite)
+			m.Get("/referral", binding.Bind(st.InviteQuery{}), GetReferrals)
+			m.Get("/referral/stat", binding.Bind(st.InviteQuery{}), GetReferralStats)
+		})
+
+		m.Group("/legal", func() {
+			m.Get("/", binding.Bind(st.LegalQuery{}), GetLegals)
+			m.Post("/create", binding.Bind(st.LegalForm{}), CreateLegal)
+			m.Get("/:legalID", GetLegal)
+			m.Post("/:legalID/update", binding.Bind(st.LegalForm{}), UpdateLegal)
+			m.Post("/:legalID/publish", PublishLegal)
+			m.Post("/:legalID/delete", DeleteLegal)
+		})
+
+		m.Group("/moderation", func() {
+			m.Get("/", binding.Bind(st.ModerationQuery{}), GetModerations)
+			m.Post("/:moderationID/approve", ApproveModeration)
+			m.Post("/:moderationID/reject", binding.Bind(st.ModerationRejectForm{}), RejectModeration)
 		})
 
 		m.Group("/dict", func() {
@@ -59,15 +135,15 @@ func Router(m *macaron.Macaron) {
 	}, Authorize)
 }
 
-// Authorize 登录认证及权限管理
-func Authorize(enforce *casbin.Enforcer, config *config.Config, ctx *context.Context) {
+// Authorize 登录认证及权限管理, 管理员只能管理所属租户的数据
+func Authorize(enforce *casbin.Enforcer, ctx *context.Context) {
 	authorizations := strings.Split(ctx.Req.Header.Get(consts.HeaderAuthorizationAdminKey), " ")
 	if len(authorizations) != 2 || authorizations[0] != "Authenticator" || authorizations[1] == "" {
 		ctx.JSONAuth(errors.ErrNotLogin.Error())
 		return
 	}
 	authCode := authorizations[1]
-	hs256 := jwt.NewHS256([]byte(config.Server.JWTSecret))
+	hs256 := jwt.NewHS256([]byte(ctx.Secret))
 	var p jwt.Payload
 	now := time.Now()
 	iatValidator := jwt.IssuedAtValidator(now)
@@ -88,16 +164,23 @@ func Authorize(enforce *casbin.Enforcer, config *config.Config, ctx *context.Con
 		return
 	}
 	sessionUser.UserID = common.StrToID(sessionUser.UserStrID)
-	_, err = models.GetUserByID(sessionUser.UserID)
+	user, err := models.GetUserByID(sessionUser.UserID)
 	if err != nil {
 		logger.Error(err)
 		ctx.JSONAuth(errors.ErrAuthInvalidData.Error())
+		return
+	}
+	if user.Tenant != ctx.Tenant {
+		ctx.JSONAuth(errors.ErrTenantMismatch.Error())
+		return
 	}
 	ctx.SessionUser = sessionUser
 
 	method := common.ToLower(ctx.Req.Method)
 	path := ctx.Req.URL.Path
-	ok, err := enforce.Enforce(ctx.UserStrID, path, method)
+	// 路径中的用户为访问对象的所有者, 用于只允许访问自己数据的条件
+	env := authzer.Env{IP: ctx.IP, Time: now, Owner: ctx.Params("userID")}
+	ok, err := enforce.Enforce(ctx.UserStrID, path, method, env)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -106,4 +189,38 @@ func Authorize(enforce *casbin.Enforcer, config *config.Config, ctx *context.Con
 		ctx.AccessDenied()
 		return
 	}
+	if err := checkTenantScope(ctx); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+}
+
+// DefaultTenantOnly 角色、资源、自定义属性、保留词和租户为全局配置, 只允许默认租户的管理员操作
+func DefaultTenantOnly(ctx *context.Context) {
+	if ctx.Tenant != consts.DefaultTenant {
+		ctx.AccessDenied()
+	}
+}
+
+// 路径中的用户和组织必须属于当前租户, 其他租户的数据视为不存在
+func checkTenantScope(ctx *context.Context) error {
+	if userID := ctx.ParamsID("userID"); userID > 0 {
+		user, err := models.GetUserByID(userID)
+		if err != nil {
+			return err
+		}
+		if user.Tenant != ctx.Tenant {
+			return errors.ErrUserNotExist
+		}
+	}
+	if orgID := ctx.ParamsID("orgID"); orgID > 0 {
+		org, err := models.GetOrg(orgID)
+		if err != nil {
+			return err
+		}
+		if org.Tenant != ctx.Tenant {
+			return errors.ErrOrgNotFound
+		}
+	}
+	return nil
 }
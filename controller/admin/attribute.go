@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// GetAttributes 自定义属性列表
+// @tags 管理 - 自定义属性
+// @Summary 自定义属性列表
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /admin/attribute [get]
+// @Security AdminKeyAuth
+func GetAttributes(ctx *context.Context) {
+	attributes, err := models.GetAttributes()
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(attributes)), "attributes", attributes)
+}
+
+// CreateAttribute 创建自定义属性
+// @tags 管理 - 自定义属性
+// @Summary 创建自定义属性
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param key formData string true "属性键"
+// @Param name formData string true "名称"
+// @Param type formData string true "类型 string/number/bool/date/enum"
+// @Param rule formData string false "校验规则, string为正则, enum为逗号分隔的可选值"
+// @Param visibility formData string true "可见范围 user/admin/public"
+// @Param claim formData bool false "是否放入令牌, 仅管理员可见的属性不能放入令牌"
+// @Router /admin/attribute/create [post]
+// @Security AdminKeyAuth
+func CreateAttribute(form st.AttributeForm, ctx *context.Context) {
+	attrID, err := models.CreateAttribute(attributeDto(&form))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"attr_id": attrID})
+}
+
+// UpdateAttribute 更新自定义属性
+// @tags 管理 - 自定义属性
+// @Summary 更新自定义属性, 属性键不可修改
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "属性编号"
+// @Param name formData string true "名称"
+// @Param type formData string true "类型 string/number/bool/date/enum"
+// @Param rule formData string false "校验规则, string为正则, enum为逗号分隔的可选值"
+// @Param visibility formData string true "可见范围 user/admin/public"
+// @Param claim formData bool false "是否放入令牌, 仅管理员可见的属性不能放入令牌"
+// @Router /admin/attribute/{id}/update [post]
+// @Security AdminKeyAuth
+func UpdateAttribute(form st.AttributeForm, ctx *context.Context) {
+	attrID := ctx.ParamsID("attrID")
+	if attrID <= 0 {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	if err := models.UpdateAttribute(attrID, attributeDto(&form)); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteAttribute 删除自定义属性
+// @tags 管理 - 自定义属性
+// @Summary 删除自定义属性, 同时删除所有用户的属性值
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "属性编号"
+// @Router /admin/attribute/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteAttribute(ctx *context.Context) {
+	attrID := ctx.ParamsID("attrID")
+	if attrID <= 0 {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	if err := models.DelAttribute(attrID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// SetUserAttributes 设置用户自定义属性
+// @tags 管理 - 用户管理
+// @Summary 设置用户自定义属性, 参数名为属性键, 空值删除
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/attribute [post]
+// @Security AdminKeyAuth
+func SetUserAttributes(ctx *context.Context) {
+	userID := ctx.ParamsID("userID")
+	if err := models.SetUserAttributes(userID, ctx.FormValues(), true); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+func attributeDto(form *st.AttributeForm) *st.AttributeDto {
+	return &st.AttributeDto{
+		Key:        form.Key,
+		Name:       form.Name,
+		Type:       consts.AttrType(form.Type),
+		Rule:       form.Rule,
+		Visibility: consts.Visibility(form.Visibility),
+		Claim:      form.Claim,
+	}
+}
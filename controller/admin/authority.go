@@ -3,6 +3,7 @@ package admin
 import (
 	"github.com/casbin/casbin/v2"
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
 	"github.com/ihuanglei/authenticator/pkg/logger"
@@ -13,9 +14,13 @@ import (
 // GetResources 资源列表
 // @tags 管理 - 权限管理
 // @Summary 资源列表
+// @Description 启动时自动同步已注册的路由, 已不存在的路由 stale 为 true
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
+// @Param domain query string false "域, 为空时为管理后台的资源"
 // @Param name query string false "名称"
+// @Param source query string false "来源 [route|custom]"
+// @Param stale query bool false "只看已不存在的路由"
 // @Router /admin/authority/resource [get]
 // @Security AdminKeyAuth
 func GetResources(query st.ResourceQuery, ctx *context.Context) {
@@ -27,16 +32,78 @@ func GetResources(query st.ResourceQuery, ctx *context.Context) {
 	ctx.JSONList(count, "resources", resources)
 }
 
+// CreateResource 创建自定义资源
+// @tags 管理 - 权限管理
+// @Summary 创建自定义资源
+// @Description 地址支持 * 通配和 :param 路径参数, 指定域时为应用或租户自定义的资源
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param domain formData string false "域, 为空时为管理后台的资源"
+// @Param name formData string true "名称"
+// @Param url formData string true "地址"
+// @Param method formData string true "方法 [get|post|put|patch|delete]"
+// @Router /admin/authority/resource/create [post]
+// @Security AdminKeyAuth
+func CreateResource(form st.ResourceForm, ctx *context.Context) {
+	id, err := models.CreateResource(&st.ResourceDto{Domain: form.Domain, Name: form.Name, URL: form.URL, Method: form.Method})
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"id": id})
+}
+
+// UpdateResource 更新自定义资源
+// @tags 管理 - 权限管理
+// @Summary 更新自定义资源
+// @Description 已分配给角色的资源只能修改名称
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "资源编号"
+// @Param name formData string true "名称"
+// @Param url formData string true "地址"
+// @Param method formData string true "方法 [get|post|put|patch|delete]"
+// @Router /admin/authority/resource/{id}/update [post]
+// @Security AdminKeyAuth
+func UpdateResource(form st.ResourceForm, ctx *context.Context) {
+	resourceDto := &st.ResourceDto{Name: form.Name, URL: form.URL, Method: form.Method}
+	if err := models.UpdateResource(ctx.ParamsInt64("resID"), resourceDto); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteResource 删除资源
+// @tags 管理 - 权限管理
+// @Summary 删除资源
+// @Description 只能删除自定义资源和已不存在的路由资源, 已分配给角色的不能删除
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "资源编号"
+// @Router /admin/authority/resource/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteResource(ctx *context.Context) {
+	if err := models.DelResource(ctx.ParamsInt64("resID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
 // CreateRole 创建角色
 // @tags 管理 - 权限管理
 // @Summary 创建角色
+// @Description 角色的资源必须属于同一个域, 创建后不能修改域
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
+// @Param domain formData string false "域, 为空时为管理后台的角色"
 // @Param name formData string false "名称"
 // @Param res_id query []string false "资源id"
 // @Router /admin/authority/role/create [post]
 // @Security AdminKeyAuth
-func CreateRole(e *casbin.Enforcer, ctx *context.Context) {
+func CreateRole(e *casbin.Enforcer, de *authzer.DomainEnforcer, ctx *context.Context) {
+	domain := ctx.QueryTrim("domain")
 	name := ctx.QueryTrim("name")
 	ids := ctx.QueryStrings("res_id")
 	if len(ids) == 0 {
@@ -52,17 +119,13 @@ func CreateRole(e *casbin.Enforcer, ctx *context.Context) {
 		ctx.BadRequestByError(err)
 		return
 	}
-	roleID, err := models.CreateRole(name, ress)
+	roleID, err := models.CreateRole(domain, name, ress)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	p := [][]string{}
-	for _, res := range ress {
-		rule := []string{roleID.Str(), res.URL, res.Method}
-		p = append(p, rule)
-	}
-	_, err = e.AddPolicies(p)
+	enforcer, _ := domainEnforcer(e, de, domain)
+	_, err = enforcer.AddPolicies(rolePolicies(roleID, domain, ress))
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -80,8 +143,13 @@ func CreateRole(e *casbin.Enforcer, ctx *context.Context) {
 // @Param res_id query []string false "资源id"
 // @Router /admin/authority/role/{id}/update [post]
 // @Security AdminKeyAuth
-func UpdateRole(e *casbin.Enforcer, ctx *context.Context) {
+func UpdateRole(e *casbin.Enforcer, de *authzer.DomainEnforcer, conds *authzer.Conditions, ctx *context.Context) {
 	roleID := ctx.ParamsID("roleID")
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	name := ctx.QueryTrim("name")
 	ids := ctx.QueryStrings("res_id")
 	if len(ids) == 0 {
@@ -102,21 +170,21 @@ func UpdateRole(e *casbin.Enforcer, ctx *context.Context) {
 		ctx.BadRequestByError(err)
 		return
 	}
-	p := [][]string{}
-	for _, res := range ress {
-		rule := []string{roleID.Str(), res.URL, res.Method}
-		p = append(p, rule)
-	}
-	_, err = e.RemoveFilteredPolicy(0, roleID.Str())
+	enforcer, _ := domainEnforcer(e, de, role.Domain)
+	_, err = enforcer.RemoveFilteredPolicy(0, roleID.Str())
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	_, err = e.AddPolicies(p)
+	_, err = enforcer.AddPolicies(rolePolicies(roleID, role.Domain, ress))
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
+	// 角色已没有的资源上的条件已移除
+	if err := conds.Reload(); err != nil {
+		logger.Error(err)
+	}
 	ctx.JSONEmpty()
 }
 
@@ -128,15 +196,28 @@ func UpdateRole(e *casbin.Enforcer, ctx *context.Context) {
 // @Param id path string true "角色编号"
 // @Router /admin/authority/role/{id}/delete [post]
 // @Security AdminKeyAuth
-func DeleteRole(e *casbin.Enforcer, ctx *context.Context) {
+func DeleteRole(e *casbin.Enforcer, de *authzer.DomainEnforcer, conds *authzer.Conditions, ctx *context.Context) {
 	roleID := ctx.ParamsID("roleID")
-	err := models.DeleteRole(roleID)
+	role, err := models.GetRoleByID(roleID)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	_, err = e.RemoveFilteredPolicy(0, roleID.Str())
+	err = models.DeleteRole(roleID)
 	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	// 同时移除角色的上级和下级角色的继承关系
+	isRole := func(sub string) bool {
+		has, _ := models.HasRoleByID(common.StrToID(sub))
+		return has
+	}
+	enforcer, domain := domainEnforcer(e, de, role.Domain)
+	if err := authzer.RemoveRole(enforcer, roleID.Str(), isRole, domain...); err != nil {
+		logger.Errorln(err)
+	}
+	if err := conds.Reload(); err != nil {
 		logger.Errorln(err)
 	}
 	ctx.JSONEmpty()
@@ -147,10 +228,11 @@ func DeleteRole(e *casbin.Enforcer, ctx *context.Context) {
 // @Summary 角色列表
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
+// @Param domain query string false "域, 为空时为管理后台的角色"
 // @Param name query string false "名称"
 // @Router /admin/authority/role [get]
 // @Security AdminKeyAuth
-func GetRoles(query st.RoleQuery, e *casbin.Enforcer, ctx *context.Context) {
+func GetRoles(query st.RoleQuery, ctx *context.Context) {
 	count, roles, err := models.GetRoles(query)
 	if err != nil {
 		ctx.BadRequestByError(err)
@@ -162,17 +244,263 @@ func GetRoles(query st.RoleQuery, e *casbin.Enforcer, ctx *context.Context) {
 // GetRoleResources 角色资源
 // @tags 管理 - 权限管理
 // @Summary 角色资源
+// @Description resources 为直接分配的资源, inherited 为继承自上级角色的资源, effective 为合并去重后实际生效的资源
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Param id path string true "角色编号"
 // @Router /admin/authority/role/{id}/resource [get]
 // @Security AdminKeyAuth
-func GetRoleResources(ctx *context.Context) {
+func GetRoleResources(e *casbin.Enforcer, de *authzer.DomainEnforcer, ctx *context.Context) {
 	roleID := ctx.ParamsID("roleID")
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	count, resources, err := models.GetRoleResourceByID(roleID)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	ctx.JSONList(count, "resources", resources)
+	enforcer, domain := domainEnforcer(e, de, role.Domain)
+	ancestors, err := roleAncestors(enforcer, roleID, domain...)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	inherited, err := models.GetInheritedResources(ancestors)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	effective := make([]*st.RoleResourceDto, 0, len(resources)+len(inherited))
+	seen := map[string]bool{}
+	for _, res := range resources {
+		if !seen[res.Method+" "+res.URL] {
+			seen[res.Method+" "+res.URL] = true
+			effective = append(effective, res)
+		}
+	}
+	for _, res := range inherited {
+		if !seen[res.Method+" "+res.URL] {
+			seen[res.Method+" "+res.URL] = true
+			effective = append(effective, &st.RoleResourceDto{Name: res.Name, URL: res.URL, Method: res.Method})
+		}
+	}
+	ctx.JSON(map[string]interface{}{
+		"count":     count,
+		"resources": resources,
+		"inherited": inherited,
+		"effective": effective,
+	})
+}
+
+// GetRoleParents 上级角色
+// @tags 管理 - 权限管理
+// @Summary 上级角色
+// @Description parents 为直接继承的角色, ancestors 为全部上级角色
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "角色编号"
+// @Router /admin/authority/role/{id}/parents [get]
+// @Security AdminKeyAuth
+func GetRoleParents(e *casbin.Enforcer, de *authzer.DomainEnforcer, ctx *context.Context) {
+	roleID := ctx.ParamsID("roleID")
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	enforcer, domain := domainEnforcer(e, de, role.Domain)
+	parentIDs, err := authzer.GetRoleParents(enforcer, roleID.Str(), domain...)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	parents, err := models.GetRolesByIDs(toIDs(parentIDs))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ancestors, err := roleAncestors(enforcer, roleID, domain...)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"parents": parents, "ancestors": ancestors})
+}
+
+// AddRoleParent 继承上级角色
+// @tags 管理 - 权限管理
+// @Summary 继承上级角色
+// @Description 角色拥有上级角色的全部资源, 只能继承同一个域中的角色, 不能继承自身或下级角色, 包含用户在内最多10层
+// @Description 只有超级管理员可以继承或取消继承超级管理员及继承它的角色
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "角色编号"
+// @Param parent_id formData string true "上级角色编号"
+// @Router /admin/authority/role/{id}/parents [post]
+// @Security AdminKeyAuth
+func AddRoleParent(e *casbin.Enforcer, de *authzer.DomainEnforcer, ctx *context.Context) {
+	roleID := ctx.ParamsID("roleID")
+	parentID := common.StrToID(ctx.QueryTrim("parent_id"))
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	parent, err := models.GetRoleByID(parentID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if parent.Domain != role.Domain {
+		ctx.BadRequestByError(errors.ErrRoleDomain)
+		return
+	}
+	if err := authzer.CheckSuperAdminRoles(e, ctx.UserStrID, parentID.Str()); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	enforcer, domain := domainEnforcer(e, de, role.Domain)
+	if err := authzer.AddRoleParent(enforcer, roleID.Str(), parentID.Str(), domain...); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// RemoveRoleParent 取消继承上级角色
+// @tags 管理 - 权限管理
+// @Summary 取消继承上级角色
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "角色编号"
+// @Param parentID path string true "上级角色编号"
+// @Router /admin/authority/role/{id}/parents/{parentID}/delete [post]
+// @Security AdminKeyAuth
+func RemoveRoleParent(e *casbin.Enforcer, de *authzer.DomainEnforcer, ctx *context.Context) {
+	roleID := ctx.ParamsID("roleID")
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	parentID := ctx.ParamsID("parentID")
+	if err := authzer.CheckSuperAdminRoles(e, ctx.UserStrID, parentID.Str()); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	enforcer, domain := domainEnforcer(e, de, role.Domain)
+	if err := authzer.RemoveRoleParent(enforcer, roleID.Str(), parentID.Str(), domain...); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetRoleConditions 角色资源的条件
+// @tags 管理 - 权限管理
+// @Summary 角色资源的条件
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "角色编号"
+// @Router /admin/authority/role/{id}/condition [get]
+// @Security AdminKeyAuth
+func GetRoleConditions(ctx *context.Context) {
+	roleID := ctx.ParamsID("roleID")
+	if _, err := models.GetRoleByID(roleID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	conds, err := models.GetRoleConditions(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(conds)), "conditions", conds)
+}
+
+// SetRoleCondition 设置角色资源的条件
+// @tags 管理 - 权限管理
+// @Summary 设置角色资源的条件
+// @Description 条件全部满足时角色的该资源才生效, 条件都为空时移除, 只影响该角色, 其他角色的相同资源不受影响
+// @Description 所有者条件在管理后台比较路径中的用户编号, 在权限检查接口比较 owner_id
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "角色编号"
+// @Param url formData string true "资源地址, 必须是角色的资源"
+// @Param method formData string true "方法"
+// @Param hours formData string false "每天允许的时间段, 例如 09:00-18:00, 结束早于开始时跨越零点"
+// @Param weekdays formData string false "允许的星期, 逗号分隔, 0为周日, 例如 1,2,3,4,5"
+// @Param ip_ranges formData string false "允许的IP或网段, 逗号分隔, 例如 10.0.0.0/8,192.168.1.1"
+// @Param owner formData bool false "只允许访问自己的数据"
+// @Router /admin/authority/role/{id}/condition [post]
+// @Security AdminKeyAuth
+func SetRoleCondition(conds *authzer.Conditions, form st.PolicyConditionForm, ctx *context.Context) {
+	roleID := ctx.ParamsID("roleID")
+	role, err := models.GetRoleByID(roleID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	condDto := &st.PolicyConditionDto{
+		RoleID:   roleID,
+		Domain:   role.Domain,
+		URL:      common.Trim(form.URL),
+		Method:   common.ToLower(common.Trim(form.Method)),
+		Hours:    common.Trim(form.Hours),
+		Weekdays: common.Trim(form.Weekdays),
+		IPRanges: common.Trim(form.IPRanges),
+		Owner:    form.Owner,
+	}
+	cond, err := authzer.ParseCondition(condDto.Hours, condDto.Weekdays, condDto.IPRanges, condDto.Owner)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if err := models.SavePolicyCondition(condDto); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	conds.Set(role.Domain, roleID.Str(), condDto.URL, condDto.Method, cond)
+	ctx.JSONEmpty()
+}
+
+// 全部有效的上级角色
+func roleAncestors(e *casbin.Enforcer, roleID common.ID, domain ...string) ([]*st.RoleDto, error) {
+	ancestorIDs, err := authzer.GetRoleAncestors(e, roleID.Str(), domain...)
+	if err != nil {
+		return nil, err
+	}
+	return models.GetRolesByIDs(toIDs(ancestorIDs))
+}
+
+// 域对应的Enforcer, 管理后台的域为空, 其他域的操作需要带上domain
+func domainEnforcer(e *casbin.Enforcer, de *authzer.DomainEnforcer, domain string) (*casbin.Enforcer, []string) {
+	if domain == "" {
+		return e, nil
+	}
+	return de.Enforcer, []string{domain}
+}
+
+// 角色资源对应的策略
+func rolePolicies(roleID common.ID, domain string, ress []*st.ResourceDto) [][]string {
+	p := [][]string{}
+	for _, res := range ress {
+		rule := []string{roleID.Str(), res.URL, res.Method}
+		if domain != "" {
+			rule = []string{roleID.Str(), domain, res.URL, res.Method}
+		}
+		p = append(p, rule)
+	}
+	return p
+}
+
+func toIDs(ids []string) []common.ID {
+	ret := make([]common.ID, len(ids))
+	for i, id := range ids {
+		ret[i] = common.StrToID(id)
+	}
+	return ret
 }
@@ -26,7 +26,7 @@ func CreateDict(form st.DictForm, ctx *context.Context) {
 		ctx.Error(err)
 		return
 	}
-	if err := models.CreateDict(dictDto); err != nil {
+	if err := models.CreateDict(ctx.Tenant, dictDto); err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
@@ -57,14 +57,24 @@ func UpdateDict(form st.DictForm, ctx *context.Context) {
 		ctx.Error(err)
 		return
 	}
-	if err := models.UpdateDict(dictID, dictDto); err != nil {
+	if err := models.UpdateDict(ctx.Tenant, dictID, dictDto); err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
 	ctx.JSONEmpty()
 }
 
-// UpdateOneDict 唯一
+// UpdateOneDict 修改唯一字典
+// @tags 管理 - 字典管理
+// @Summary 按类型和业务类型修改唯一字典
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param name query string false "名称"
+// @Param cate query string false "类型"
+// @Param value query string false "值"
+// @Param tp query string false "业务类型"
+// @Router /admin/dict/one/update [post]
+// @Security AdminKeyAuth
 func UpdateOneDict(form st.DictForm, ctx *context.Context) {
 	dictDto := new(st.DictDto)
 	err := convert.Map(&form, dictDto)
@@ -72,18 +82,26 @@ func UpdateOneDict(form st.DictForm, ctx *context.Context) {
 		ctx.Error(err)
 		return
 	}
-	if err := models.UpdateOneDict(dictDto); err != nil {
+	if err := models.UpdateOneDict(ctx.Tenant, dictDto); err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
 	ctx.JSONEmpty()
 }
 
-// GetOneDict .
+// GetOneDict 获取唯一字典
+// @tags 管理 - 字典管理
+// @Summary 按类型和业务类型获取唯一字典
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=st.DictDto}
+// @Param cate query string true "类型"
+// @Param tp query string true "业务类型"
+// @Router /admin/dict/one [get]
+// @Security AdminKeyAuth
 func GetOneDict(ctx *context.Context) {
 	cate := ctx.QueryTrim("cate")
 	tp := ctx.QueryTrim("tp")
-	dictDto, err := models.GetOneDict(cate, tp)
+	dictDto, err := models.GetOneDict(ctx.Tenant, cate, tp)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -92,13 +110,20 @@ func GetOneDict(ctx *context.Context) {
 }
 
 // DelDict 删除字典
+// @tags 管理 - 字典管理
+// @Summary 删除字典
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "字典编号"
+// @Router /admin/dict/{id}/del [post]
+// @Security AdminKeyAuth
 func DelDict(ctx *context.Context) {
 	dictID := ctx.ParamsID("dictID")
 	if dictID <= 0 {
 		ctx.BadRequestByError(errors.ErrArgument)
 		return
 	}
-	if err := models.DelDict(dictID); err != nil {
+	if err := models.DelDict(ctx.Tenant, dictID); err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
@@ -106,9 +131,16 @@ func DelDict(ctx *context.Context) {
 }
 
 // GetDictByCate 根据类型查询字典
+// @tags 管理 - 字典管理
+// @Summary 根据类型查询字典
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param cate query string true "类型"
+// @Router /admin/dict [get]
+// @Security AdminKeyAuth
 func GetDictByCate(ctx *context.Context) {
 	cate := ctx.QueryTrim("cate")
-	dicts, err := models.GetDictByCate(cate)
+	dicts, err := models.GetDictByCate(ctx.Tenant, cate)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
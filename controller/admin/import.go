@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// 导入文件大小上限
+const importMaxSize = 10 << 20
+
+// CreateUser 创建用户
+// @tags 管理 - 用户管理
+// @Summary 创建用户, 用户名、邮箱、手机号至少填写一项, 无密码时随机生成
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param name formData string false "用户名"
+// @Param email formData string false "邮箱"
+// @Param mobile formData string false "手机号"
+// @Param password formData string false "密码"
+// @Param nickname formData string false "昵称"
+// @Router /admin/user/create [post]
+// @Security AdminKeyAuth
+func CreateUser(form st.CreateUserForm, ctx *context.Context) {
+	userDto := &st.ImportUserDto{
+		Name:     form.Name,
+		Email:    form.Email,
+		Mobile:   form.Mobile,
+		Password: form.Password,
+		Nickname: form.Nickname,
+	}
+	userID, err := models.CreateUser(ctx.Tenant, userDto, ctx.IP)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"user_id": userID})
+}
+
+// ImportUsers 批量导入用户
+// @tags 管理 - 用户管理
+// @Summary 批量导入用户
+// @Description 支持csv(首行为表头)和json数组, 字段 name,email,mobile,password,password_hash,salt,nickname
+// @Description password_hash 为 md5(password + salt), 返回每行导入结果
+// @Accept multipart/form-data
+// @Success 200 {object} context.JSONResult
+// @Param file formData file false "导入文件, 为空时读取请求体"
+// @Param format query string false "文件格式, 为空时按文件扩展名或Content-Type判断" Enums(csv, json)
+// @Router /admin/user/import [post]
+// @Security AdminKeyAuth
+func ImportUsers(ctx *context.Context) {
+	format := ctx.QueryTrim("format")
+	var reader io.Reader = ctx.Req.Body().ReadCloser()
+	if file, header, err := ctx.Req.FormFile("file"); err == nil {
+		defer file.Close()
+		reader = file
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+		}
+	}
+	if format == "" {
+		format = ctx.Req.Header.Get("Content-Type")
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(reader, importMaxSize+1))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if len(data) > importMaxSize {
+		ctx.BadRequestByError(errors.ErrImportLimit)
+		return
+	}
+	userDtos, err := parseImportUsers(data, format)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	results, err := models.ImportUsers(ctx.Tenant, userDtos, ctx.IP)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	success := 0
+	for _, result := range results {
+		if result.Success {
+			success++
+		}
+	}
+	ctx.JSON(map[string]interface{}{
+		"total":   len(results),
+		"success": success,
+		"failed":  len(results) - success,
+		"results": results,
+	})
+}
+
+func parseImportUsers(data []byte, format string) ([]*st.ImportUserDto, error) {
+	format = common.ToLower(format)
+	switch {
+	case strings.Contains(format, "json"):
+		var userDtos []*st.ImportUserDto
+		if err := json.Unmarshal(data, &userDtos); err != nil {
+			return nil, errors.ErrImportFormat
+		}
+		return userDtos, nil
+	case strings.Contains(format, "csv"):
+		return parseImportCSV(data)
+	}
+	return nil, errors.ErrImportFormat
+}
+
+// 首行为表头, 列顺序不限, 未知列忽略
+func parseImportCSV(data []byte) ([]*st.ImportUserDto, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return nil, errors.ErrImportFormat
+	}
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[common.ToLower(common.Trim(column))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	var userDtos []*st.ImportUserDto
+	for _, record := range records[1:] {
+		userDtos = append(userDtos, &st.ImportUserDto{
+			Name:         field(record, "name"),
+			Email:        field(record, "email"),
+			Mobile:       field(record, "mobile"),
+			Password:     field(record, "password"),
+			PasswordHash: field(record, "password_hash"),
+			Salt:         field(record, "salt"),
+			Nickname:     field(record, "nickname"),
+		})
+	}
+	return userDtos, nil
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"github.com/casbin/casbin/v2"
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// GetInvites 邀请码列表
+// @tags 管理 - 邀请码
+// @Summary 邀请码列表
+// @Description 只返回未撤销的邀请码
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param inviter_id query string false "邀请人编号"
+// @Router /admin/invite [get]
+// @Security AdminKeyAuth
+func GetInvites(query st.InviteQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	count, invites, err := models.GetInvites(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "invites", invites)
+}
+
+// CreateInvites 生成邀请码
+// @tags 管理 - 邀请码
+// @Summary 生成邀请码
+// @Description 一次最多生成100个, 使用邀请码注册的用户自动获得指定角色, 只有超级管理员可以指定继承超级管理员的角色
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param count formData int false "生成数量, 默认1个"
+// @Param max_uses formData int false "每个邀请码可使用次数, 0为不限"
+// @Param expire_days formData int false "有效天数, 0为永不过期"
+// @Param role_id formData string false "注册后授予的角色编号"
+// @Param inviter_id formData string false "邀请人编号"
+// @Param remark formData string false "备注"
+// @Router /admin/invite/create [post]
+// @Security AdminKeyAuth
+func CreateInvites(form st.InviteForm, e *casbin.Enforcer, ctx *context.Context) {
+	inviteDto := &st.InviteDto{
+		MaxUses:   form.MaxUses,
+		RoleID:    common.StrToID(form.RoleID),
+		InviterID: common.StrToID(form.InviterID),
+		Remark:    common.Trim(form.Remark),
+	}
+	if inviteDto.RoleID > 0 {
+		if err := authzer.CheckSuperAdminRoles(e, ctx.UserStrID, inviteDto.RoleID.Str()); err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+	}
+	invites, err := models.CreateInvites(ctx.Tenant, form.Count, inviteDto, form.ExpireDays)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(invites)), "invites", invites)
+}
+
+// RevokeInvite 撤销邀请码
+// @tags 管理 - 邀请码
+// @Summary 撤销邀请码
+// @Description 撤销后不能再用于注册, 已有的邀请记录保留
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "邀请码编号"
+// @Router /admin/invite/{id}/revoke [post]
+// @Security AdminKeyAuth
+func RevokeInvite(ctx *context.Context) {
+	if err := models.RevokeInvite(ctx.Tenant, ctx.ParamsID("inviteID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetReferrals 邀请注册记录
+// @tags 管理 - 邀请码
+// @Summary 邀请注册记录
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param inviter_id query string false "邀请人编号"
+// @Param invite_id query string false "邀请码编号"
+// @Router /admin/invite/referral [get]
+// @Security AdminKeyAuth
+func GetReferrals(query st.InviteQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	count, referrals, err := models.GetReferrals(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "referrals", referrals)
+}
+
+// GetReferralStats 邀请人排行
+// @tags 管理 - 邀请码
+// @Summary 邀请人排行
+// @Description 按邀请注册人数倒序
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param invite_id query string false "邀请码编号"
+// @Router /admin/invite/referral/stat [get]
+// @Security AdminKeyAuth
+func GetReferralStats(query st.InviteQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	stats, err := models.GetReferralStats(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(stats)), "stats", stats)
+}
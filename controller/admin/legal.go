@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// GetLegals 协议列表
+// @tags 管理 - 用户协议
+// @Summary 协议列表
+// @Description 包含未发布的草稿, 列表不返回内容
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param tp query string false "类型 [terms|privacy]"
+// @Router /admin/legal [get]
+// @Security AdminKeyAuth
+func GetLegals(query st.LegalQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	count, legals, err := models.GetLegals(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "legals", legals)
+}
+
+// GetLegal 协议详情
+// @tags 管理 - 用户协议
+// @Summary 协议详情
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=st.LegalDto}
+// @Param id path string true "协议编号"
+// @Router /admin/legal/{id} [get]
+// @Security AdminKeyAuth
+func GetLegal(ctx *context.Context) {
+	legal, err := models.GetLegal(ctx.Tenant, ctx.ParamsID("legalID"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(legal)
+}
+
+// CreateLegal 创建协议
+// @tags 管理 - 用户协议
+// @Summary 创建协议
+// @Description 创建后为草稿, 发布后生效
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param tp formData string true "类型 [terms|privacy]"
+// @Param version formData string true "版本号"
+// @Param title formData string true "标题"
+// @Param content formData string true "内容"
+// @Router /admin/legal/create [post]
+// @Security AdminKeyAuth
+func CreateLegal(form st.LegalForm, ctx *context.Context) {
+	legalID, err := models.CreateLegal(ctx.Tenant, legalDto(&form))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"legal_id": legalID})
+}
+
+// UpdateLegal 更新协议
+// @tags 管理 - 用户协议
+// @Summary 更新协议
+// @Description 只能修改未发布的草稿
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "协议编号"
+// @Param version formData string true "版本号"
+// @Param title formData string true "标题"
+// @Param content formData string true "内容"
+// @Router /admin/legal/{id}/update [post]
+// @Security AdminKeyAuth
+func UpdateLegal(form st.LegalForm, ctx *context.Context) {
+	if err := models.UpdateLegal(ctx.Tenant, ctx.ParamsID("legalID"), legalDto(&form)); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// PublishLegal 发布协议
+// @tags 管理 - 用户协议
+// @Summary 发布协议
+// @Description 发布后替换同类型的旧版本, 用户下次登录时需要同意
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "协议编号"
+// @Router /admin/legal/{id}/publish [post]
+// @Security AdminKeyAuth
+func PublishLegal(ctx *context.Context) {
+	if err := models.PublishLegal(ctx.Tenant, ctx.ParamsID("legalID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteLegal 删除协议
+// @tags 管理 - 用户协议
+// @Summary 删除协议
+// @Description 只能删除未发布的草稿
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "协议编号"
+// @Router /admin/legal/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteLegal(ctx *context.Context) {
+	if err := models.DelLegal(ctx.Tenant, ctx.ParamsID("legalID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetUserLegals 用户同意协议记录
+// @tags 管理 - 用户管理
+// @Summary 用户同意协议记录
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/legal [get]
+// @Security AdminKeyAuth
+func GetUserLegals(ctx *context.Context) {
+	accepts, err := models.GetLegalAccepts(ctx.ParamsID("userID"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(accepts)), "accepts", accepts)
+}
+
+func legalDto(form *st.LegalForm) *st.LegalDto {
+	return &st.LegalDto{TP: form.TP, Version: form.Version, Title: form.Title, Content: form.Content}
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/message"
+)
+
+const (
+	// 邮件审核通过模板
+	tmplEmailModerationApproved = "email_moderation_approved"
+	// 邮件审核拒绝模板
+	tmplEmailModerationRejected = "email_moderation_rejected"
+	// 短信审核通过模板
+	tmplMobileModerationApproved = "mobile_moderation_approved"
+	// 短信审核拒绝模板
+	tmplMobileModerationRejected = "mobile_moderation_rejected"
+)
+
+// 审核字段的显示名称
+var _ModerationFields = map[string]string{
+	consts.ModerationFieldNickname: "昵称",
+	consts.ModerationFieldAvatar:   "头像",
+}
+
+// 审核结果通知, 优先发送邮件, 没有邮箱时发送短信, 未配置模板时不通知
+// 模板中可使用 {field} 字段名称, {value} 修改后的内容, {reason} 拒绝原因
+func sendModerationMessage(tenant string, moderation *st.ModerationDto) {
+	defer message.Recover()
+	user, err := models.GetUserByID(moderation.UserID)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	replacer := strings.NewReplacer(
+		"{field}", _ModerationFields[moderation.Field],
+		"{value}", moderation.Value,
+		"{reason}", moderation.Reason,
+	)
+	approved := moderation.Status == consts.ModerationApproved
+	if user.Email != "" && user.Email != user.UserID.Str() {
+		tmpl := tmplEmailModerationRejected
+		if approved {
+			tmpl = tmplEmailModerationApproved
+		}
+		ev, err := message.BuildMailMessage(tenant, tmpl)
+		if err != nil {
+			logger.Error(err)
+			return
+		}
+		ev.Subject = replacer.Replace(ev.Subject)
+		ev.Body = replacer.Replace(ev.Body)
+		ev.To = []string{user.Email}
+		message.SendMessage(ev)
+		return
+	}
+	if user.Mobile != "" && user.Mobile != user.UserID.Str() {
+		tmpl := tmplMobileModerationRejected
+		if approved {
+			tmpl = tmplMobileModerationApproved
+		}
+		ev, err := message.BuildSMSMessage(tenant, tmpl)
+		if err != nil {
+			logger.Error(err)
+			return
+		}
+		body := replacer.Replace(ev.Body)
+		replacer = strings.NewReplacer("{to}", user.Mobile, "{body}", body)
+		for k, v := range ev.Params {
+			ev.Params[k] = replacer.Replace(v)
+		}
+		for k, v := range ev.Querys {
+			ev.Querys[k] = replacer.Replace(v)
+		}
+		ev.To = user.Mobile
+		ev.Body = body
+		message.SendMessage(ev)
+	}
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/avatar"
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/storage"
+	"github.com/simplexwork/common"
+)
+
+// GetModerations 审核列表
+// @tags 管理 - 昵称和头像审核
+// @Summary 审核列表
+// @Description 开启审核后用户修改的昵称和头像进入审核队列, 审核通过前其他人看到的仍是原来的内容
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param status query string false "状态 [pending|approved|rejected|canceled]"
+// @Param field query string false "字段 [nickname|avatar]"
+// @Param user_id query string false "用户编号"
+// @Router /admin/moderation [get]
+// @Security AdminKeyAuth
+func GetModerations(query st.ModerationQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	count, moderations, err := models.GetModerations(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "moderations", moderations)
+}
+
+// ApproveModeration 审核通过
+// @tags 管理 - 昵称和头像审核
+// @Summary 审核通过
+// @Description 修改生效并通知用户
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "审核编号"
+// @Router /admin/moderation/{id}/approve [post]
+// @Security AdminKeyAuth
+func ApproveModeration(config *config.Config, store storage.Storage, ctx *context.Context) {
+	moderation, err := models.ApproveModeration(ctx.Tenant, ctx.ParamsID("moderationID"), ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if moderation.Field == consts.ModerationFieldAvatar {
+		removeAvatar(config, store, moderation.UserID, moderation.OldValue)
+	}
+	go sendModerationMessage(ctx.Tenant, moderation)
+	ctx.JSONEmpty()
+}
+
+// RejectModeration 审核拒绝
+// @tags 管理 - 昵称和头像审核
+// @Summary 审核拒绝
+// @Description 保留原来的内容并通知用户
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "审核编号"
+// @Param reason formData string false "拒绝原因"
+// @Router /admin/moderation/{id}/reject [post]
+// @Security AdminKeyAuth
+func RejectModeration(form st.ModerationRejectForm, config *config.Config, store storage.Storage, ctx *context.Context) {
+	moderation, err := models.RejectModeration(ctx.Tenant, ctx.ParamsID("moderationID"), ctx.UserID, form.Reason)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if moderation.Field == consts.ModerationFieldAvatar {
+		removeAvatar(config, store, moderation.UserID, moderation.Value)
+	}
+	go sendModerationMessage(ctx.Tenant, moderation)
+	ctx.JSONEmpty()
+}
+
+// 删除审核后不再使用的上传头像, 失败不影响审核
+func removeAvatar(config *config.Config, store storage.Storage, userID common.ID, url string) {
+	if err := avatar.Remove(store, userID, url, config.Avatar.Sizes); err != nil {
+		logger.Error(err)
+	}
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// GetOrgs 组织列表
+// @tags 管理 - 组织管理
+// @Summary 组织列表
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param name query string false "名称"
+// @Router /admin/org [get]
+// @Security AdminKeyAuth
+func GetOrgs(query st.OrgQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
+	count, orgs, err := models.GetOrgs(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "orgs", orgs)
+}
+
+// GetOrg 组织信息
+// @tags 管理 - 组织管理
+// @Summary 组织信息
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /admin/org/{id} [get]
+// @Security AdminKeyAuth
+func GetOrg(ctx *context.Context) {
+	org, err := models.GetOrg(ctx.ParamsID("orgID"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(org)
+}
+
+// DeleteOrg 删除组织
+// @tags 管理 - 组织管理
+// @Summary 删除组织
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /admin/org/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteOrg(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	if err := models.DelOrg(orgID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// TransferOrg 转让组织
+// @tags 管理 - 组织管理
+// @Summary 转让组织, 原所有者成为管理员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param user_id formData string true "新所有者, 必须是组织成员"
+// @Router /admin/org/{id}/transfer [post]
+// @Security AdminKeyAuth
+func TransferOrg(form st.OrgMemberForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := common.StrToID(form.UserID)
+	if err := models.TransferOrg(orgID, userID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetOrgMembers 组织成员
+// @tags 管理 - 组织管理
+// @Summary 组织成员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /admin/org/{id}/member [get]
+// @Security AdminKeyAuth
+func GetOrgMembers(query st.EmptyQuery, ctx *context.Context) {
+	count, members, err := models.GetOrgMembers(ctx.ParamsID("orgID"), query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "members", members)
+}
+
+// AddOrgMember 添加组织成员
+// @tags 管理 - 组织管理
+// @Summary 添加组织成员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param user_id formData string true "用户编号"
+// @Param role formData string true "角色" Enums(admin, member)
+// @Router /admin/org/{id}/member [post]
+// @Security AdminKeyAuth
+func AddOrgMember(form st.OrgMemberForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := common.StrToID(form.UserID)
+	role := consts.OrgRole(form.Role)
+	if err := models.AddOrgMember(orgID, userID, role); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// SetOrgMemberRole 设置成员角色
+// @tags 管理 - 组织管理
+// @Summary 设置成员角色
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param userID path string true "用户编号"
+// @Param role formData string true "角色" Enums(admin, member)
+// @Router /admin/org/{id}/member/{userID}/role [post]
+// @Security AdminKeyAuth
+func SetOrgMemberRole(form st.OrgMemberForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := ctx.ParamsID("userID")
+	role := consts.OrgRole(form.Role)
+	if err := models.SetOrgMemberRole(orgID, userID, role); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// RemoveOrgMember 移除组织成员
+// @tags 管理 - 组织管理
+// @Summary 移除组织成员, 所有者需先转让组织
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param userID path string true "用户编号"
+// @Router /admin/org/{id}/member/{userID}/remove [post]
+// @Security AdminKeyAuth
+func RemoveOrgMember(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := ctx.ParamsID("userID")
+	if err := models.RemoveOrgMember(orgID, userID, consts.OrgOwner); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
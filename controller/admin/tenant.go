@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// GetTenants 租户列表
+// @tags 管理 - 租户管理
+// @Summary 租户列表
+// @Description 只允许默认租户的管理员操作
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /admin/tenant [get]
+// @Security AdminKeyAuth
+func GetTenants(ctx *context.Context) {
+	tenants, err := models.GetTenants()
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(tenants)), "tenants", tenants)
+}
+
+// GetTenant 租户详情
+// @tags 管理 - 租户管理
+// @Summary 租户详情
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=st.TenantDto}
+// @Param id path string true "租户编号"
+// @Router /admin/tenant/{id} [get]
+// @Security AdminKeyAuth
+func GetTenant(ctx *context.Context) {
+	tenant, err := models.GetTenant(ctx.ParamsID("tenantID"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(tenant)
+}
+
+// CreateTenant 创建租户
+// @tags 管理 - 租户管理
+// @Summary 创建租户
+// @Description 租户通过请求头、路径前缀 /t/{code} 或绑定的域名识别, 创建后不可修改租户代码
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param code formData string true "租户代码"
+// @Param name formData string true "名称"
+// @Param hosts formData string false "绑定的域名, 逗号分隔"
+// @Param secret formData string false "令牌签名密钥, 为空时使用全局密钥"
+// @Router /admin/tenant/create [post]
+// @Security AdminKeyAuth
+func CreateTenant(form st.TenantForm, ctx *context.Context) {
+	tenantID, err := models.CreateTenant(tenantDto(&form))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"tenant_id": tenantID})
+}
+
+// UpdateTenant 更新租户
+// @tags 管理 - 租户管理
+// @Summary 更新租户
+// @Description 修改密钥后该租户已签发的令牌全部失效
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "租户编号"
+// @Param name formData string true "名称"
+// @Param hosts formData string false "绑定的域名, 逗号分隔"
+// @Param secret formData string false "令牌签名密钥, 为空时不修改"
+// @Router /admin/tenant/{id}/update [post]
+// @Security AdminKeyAuth
+func UpdateTenant(form st.TenantForm, ctx *context.Context) {
+	if err := models.UpdateTenant(ctx.ParamsID("tenantID"), tenantDto(&form)); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteTenant 删除租户
+// @tags 管理 - 租户管理
+// @Summary 删除租户
+// @Description 删除后该租户的请求无法识别, 用户数据保留
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "租户编号"
+// @Router /admin/tenant/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteTenant(ctx *context.Context) {
+	if err := models.DelTenant(ctx.ParamsID("tenantID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+func tenantDto(form *st.TenantForm) *st.TenantDto {
+	tenantDto := &st.TenantDto{Code: form.Code, Name: form.Name, Secret: form.Secret}
+	if form.Hosts != "" {
+		tenantDto.Hosts = strings.Split(form.Hosts, ",")
+	}
+	return tenantDto
+}
@@ -1,11 +1,16 @@
 package admin
 
 import (
+	"time"
+
 	"github.com/casbin/casbin/v2"
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/export"
+	"github.com/ihuanglei/authenticator/pkg/logger"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/simplexwork/common"
 )
@@ -28,6 +33,42 @@ func GetUserLogins(query st.EmptyQuery, ctx *context.Context) {
 	ctx.JSONList(count, "logins", userLogins)
 }
 
+// ExportUser 导出用户数据
+// @tags 管理 - 用户管理
+// @Summary 导出用户数据(异步生成, 返回下载地址)
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=export.Task} "导出任务"
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/export [post]
+// @Security AdminKeyAuth
+func ExportUser(exporter *export.Exporter, ctx *context.Context) {
+	userID := ctx.ParamsID("userID")
+	task, err := exporter.Start(userID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(task)
+}
+
+// GetUserAddresses 管理员获取用户地址
+// @tags 管理 - 用户管理
+// @Summary 管理员获取用户地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/address [get]
+// @Security AdminKeyAuth
+func GetUserAddresses(ctx *context.Context) {
+	userID := ctx.ParamsID("userID")
+	count, addresses, err := models.GetAddresses(userID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "addresses", addresses)
+}
+
 // GetUsers 管理员获取用户列表
 // @tags 管理 - 用户管理
 // @Summary 管理员获取用户列表
@@ -37,6 +78,7 @@ func GetUserLogins(query st.EmptyQuery, ctx *context.Context) {
 // @Router /admin/user [get]
 // @Security AdminKeyAuth
 func GetUsers(query st.UserQuery, ctx *context.Context) {
+	query.Tenant = ctx.Tenant
 	count, users, err := models.GetUsers(query)
 	if err != nil {
 		ctx.BadRequestByError(err)
@@ -64,7 +106,12 @@ func GetUser(ctx *context.Context) {
 		ctx.BadRequestByError(err)
 		return
 	}
-	ctx.JSON(userInfo)
+	attributes, err := models.GetUserAttributes(userID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(&st.UserDetailDto{UserInfoDto: userInfo, Attributes: attributes})
 }
 
 // ResetLoginError 重置错误登录
@@ -150,42 +197,214 @@ func ActivateUser(ctx *context.Context) {
 // AddRoleForUser 添加角色
 // @tags 管理 - 用户管理
 // @Summary 添加用户角色
+// @Description 替换用户在域中的全部角色, 角色必须属于该域, 管理后台可使用内置的 superadmin 超级管理员角色, 只有超级管理员可以授予或取消它及继承它的角色
+// @Description 未填写有效期时已有的角色保持原有效期, 填写时新授予和已有的角色都使用该有效期, 过期后自动移除
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Param id path string true "用户编号"
+// @Param domain query string false "域, 为空时为管理后台的角色"
 // @Param role_id query []string false "角色编号"
+// @Param expire_hours query int false "有效小时数, 0为永不过期, 填写时同时修改已有角色的有效期"
 // @Router /admin/user/{id}/role [post]
 // @Security AdminKeyAuth
-func AddRoleForUser(e *casbin.Enforcer, ctx *context.Context) {
+func AddRoleForUser(e *casbin.Enforcer, de *authzer.DomainEnforcer, grants *authzer.Grants, ctx *context.Context) {
 	userID := ctx.ParamsID("userID")
+	domain := ctx.QueryTrim("domain")
 	roleIDs := ctx.QueryStrings("role_id")
-	_, err := e.DeleteRolesForUser(userID.Str())
+	expireHours := ctx.QueryInt("expire_hours")
+	if expireHours < 0 {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	var ids []string
+	for _, id := range roleIDs {
+		if domain != "" || id != consts.SuperAdminRole {
+			ids = append(ids, id)
+		}
+	}
+	roles, err := models.GetRolesByIDs(toIDs(ids))
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	_, err = e.AddRolesForUser(userID.Str(), roleIDs)
+	if len(roles) != len(ids) {
+		ctx.BadRequestByError(errors.ErrRoleNotFound)
+		return
+	}
+	for _, role := range roles {
+		if role.Domain != domain {
+			ctx.BadRequestByError(errors.ErrRoleDomain)
+			return
+		}
+	}
+	enforcer, dom := domainEnforcer(e, de, domain)
+	current, err := enforcer.GetRolesForUser(userID.Str(), dom...)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
+	// 只变更新增和移除的角色, 保留的角色不影响原有效期
+	held := map[string]bool{}
+	for _, roleID := range current {
+		held[roleID] = true
+	}
+	// 填写了有效期时同时修改已有角色的有效期
+	renew := ctx.Query("expire_hours") != ""
+	keep := map[string]bool{}
+	var added, removed, renewed []string
+	for _, roleID := range roleIDs {
+		if !keep[roleID] {
+			if !held[roleID] {
+				added = append(added, roleID)
+			} else if renew {
+				renewed = append(renewed, roleID)
+			}
+		}
+		keep[roleID] = true
+	}
+	for _, roleID := range current {
+		if !keep[roleID] {
+			removed = append(removed, roleID)
+		}
+	}
+	if domain == "" {
+		if err := authzer.CheckSuperAdminRoles(e, ctx.UserStrID, append(append([]string{}, added...), removed...)...); err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+	}
+	for _, roleID := range removed {
+		if _, err := enforcer.DeleteRoleForUser(userID.Str(), roleID, dom...); err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+	}
+	var expire time.Time
+	if expireHours > 0 {
+		expire = time.Now().Add(time.Duration(expireHours) * time.Hour)
+	}
+	if len(added) > 0 {
+		if _, err := enforcer.AddRolesForUser(userID.Str(), added, dom...); err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+	}
+	// 没有授予记录的角色无法过期移除, 记录失败时恢复变更前的角色
+	if err := models.SaveRoleGrants(domain, userID, added, removed, renewed, expire, ctx.UserID); err != nil {
+		for _, roleID := range added {
+			if _, err := enforcer.DeleteRoleForUser(userID.Str(), roleID, dom...); err != nil {
+				logger.Error(err)
+			}
+		}
+		for _, roleID := range removed {
+			if _, err := enforcer.AddRoleForUser(userID.Str(), roleID, dom...); err != nil {
+				logger.Error(err)
+			}
+		}
+		ctx.Error(err)
+		return
+	}
+	for _, roleID := range removed {
+		grants.Set(userID.Str(), roleID, domain, time.Time{})
+	}
+	for _, roleID := range append(added, renewed...) {
+		grants.Set(userID.Str(), roleID, domain, expire)
+	}
 	ctx.JSONEmpty()
 }
 
 // GetRoleForUser 获取用户角色
 // @tags 管理 - 用户管理
 // @Summary 获取用户角色
+// @Description 包含授予人、授予时间和过期时间, 早于授予记录的角色只有角色编号
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Param id path string true "用户编号"
+// @Param domain query string false "域, 为空时为管理后台的角色"
 // @Router /admin/user/{id}/role [get]
 // @Security AdminKeyAuth
-func GetRoleForUser(e *casbin.Enforcer, ctx *context.Context) {
+func GetRoleForUser(e *casbin.Enforcer, de *authzer.DomainEnforcer, grants *authzer.Grants, ctx *context.Context) {
 	userID := ctx.ParamsID("userID")
-	roles, err := e.GetRolesForUser(userID.Str())
+	domain := ctx.QueryTrim("domain")
+	enforcer, dom := domainEnforcer(e, de, domain)
+	roleIDs, err := enforcer.GetRolesForUser(userID.Str(), dom...)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	records, err := models.GetRoleGrants(domain, userID)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
+	roles := make([]*st.RoleGrantDto, len(roleIDs))
+	for i, roleID := range roleIDs {
+		role, ok := records[roleID]
+		if !ok {
+			role = &st.RoleGrantDto{Domain: domain, UserID: userID, RoleID: roleID}
+		}
+		role.Expired = grants.Expired(userID.Str(), roleID, domain)
+		roles[i] = role
+	}
 	ctx.JSONList(int64(len(roles)), "roles", roles)
 }
+
+// GetRoleGrantLogs 用户角色变更记录
+// @tags 管理 - 用户管理
+// @Summary 用户角色变更记录
+// @Description action 为 grant 授予, revoke 取消, renew 修改有效期, expire 过期移除, 包含全部域
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/role/log [get]
+// @Security AdminKeyAuth
+func GetRoleGrantLogs(query st.EmptyQuery, ctx *context.Context) {
+	count, logs, err := models.GetRoleGrantLogs(ctx.ParamsID("userID"), query.Page, query.Limit)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "logs", logs)
+}
+
+// MergeUser 合并账号
+// @tags 管理 - 用户管理
+// @Summary 将source_id账号合并到当前账号, 被合并的账号将被删除
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=st.UserMergeDto} "合并记录"
+// @Param id path string true "用户编号"
+// @Param source_id formData string true "被合并的用户编号"
+// @Router /admin/user/{id}/merge [post]
+// @Security AdminKeyAuth
+func MergeUser(e *casbin.Enforcer, de *authzer.DomainEnforcer, grants *authzer.Grants, ctx *context.Context) {
+	userID := ctx.ParamsID("userID")
+	sourceID := ctx.QueryID("source_id")
+	merge, err := models.MergeUser(sourceID, userID, ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	// 账号已合并, 角色转移失败时由定时任务重试
+	if err := grants.FinishMerges(e, de); err != nil {
+		logger.Error(err)
+	}
+	ctx.JSON(merge)
+}
+
+// GetUserMerges 账号合并记录
+// @tags 管理 - 用户管理
+// @Summary 账号合并记录
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "用户编号"
+// @Router /admin/user/{id}/merge [get]
+// @Security AdminKeyAuth
+func GetUserMerges(ctx *context.Context) {
+	userID := ctx.ParamsID("userID")
+	merges, err := models.GetUserMerges(userID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(merges)), "merges", merges)
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// 导出csv的列
+var exportColumns = []string{
+	"user_id", "name", "email", "mobile", "mode", "status", "forbidden", "activate",
+	"nickname", "avatar", "gender", "qq", "weixin", "province", "city", "county",
+	"reg_ip", "last_login_ip", "last_login_time", "create_time", "delete_time", "roles",
+}
+
+// ExportUsers 管理员导出用户
+// @tags 管理 - 用户管理
+// @Summary 管理员导出用户, 搜索条件同用户列表
+// @Description 以流的方式输出, csv 的多个角色以 | 分隔, ndjson 每行一个用户
+// @Accept x-www-form-urlencoded
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "格式, 默认csv" Enums(csv, ndjson)
+// @Param keyword query string false "用户名、邮箱、手机号"
+// @Param gender query []string false "性别"
+// @Param forbidden query []string false "禁用"
+// @Param activate query []string false "激活"
+// @Router /admin/user/export [get]
+// @Security AdminKeyAuth
+func ExportUsers(query st.UserQuery, e *casbin.Enforcer, ctx *context.Context) {
+	format := ctx.QueryTrim("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	query.Tenant = ctx.Tenant
+	roleNames, err := models.GetRoleNames()
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().Format("20060102150405"), format)
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if format == "csv" {
+		ctx.Resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		ctx.Resp.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	}
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	var write func(*st.UserExportRowDto) error
+	flush := func() {}
+	if format == "csv" {
+		// excel 识别utf-8
+		ctx.Resp.Write([]byte("\xef\xbb\xbf"))
+		w := csv.NewWriter(ctx.Resp)
+		w.Write(exportColumns)
+		write = func(row *st.UserExportRowDto) error {
+			return w.Write(exportRecord(row))
+		}
+		flush = w.Flush
+	} else {
+		encoder := json.NewEncoder(ctx.Resp)
+		write = func(row *st.UserExportRowDto) error {
+			return encoder.Encode(row)
+		}
+	}
+
+	count := 0
+	err = models.EachUser(query, func(row *st.UserExportRowDto) error {
+		hidePlaceholder(row)
+		roles, err := e.GetRolesForUser(row.UserID.Str())
+		if err != nil {
+			return err
+		}
+		row.Roles = make([]string, 0, len(roles))
+		for _, role := range roles {
+			if name, ok := roleNames[role]; ok {
+				row.Roles = append(row.Roles, name)
+			}
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+		if count++; count%100 == 0 {
+			flush()
+			ctx.Resp.Flush()
+		}
+		return nil
+	})
+	flush()
+	ctx.Resp.Flush()
+	// 响应头已输出, 只能记录错误
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// 未设置的用户名、邮箱、手机号以用户编号占位
+func hidePlaceholder(row *st.UserExportRowDto) {
+	userID := row.UserID.Str()
+	if row.Name == userID {
+		row.Name = ""
+	}
+	if row.Email == userID {
+		row.Email = ""
+	}
+	if row.Mobile == userID {
+		row.Mobile = ""
+	}
+}
+
+func exportRecord(row *st.UserExportRowDto) []string {
+	return []string{
+		row.UserID.Str(), row.Name, row.Email, row.Mobile, row.Mode.Str(),
+		row.Status.Str(), row.Forbidden.Str(), row.Activate.Str(),
+		row.Nickname, row.Avatar, row.Gender.Str(), row.QQ, row.WeiXin,
+		row.Province, row.City, row.County,
+		row.RegIP, row.LastLoginIP, exportTime(row.LastLoginTime),
+		exportTime(row.CreateTime), exportTime(row.DeleteTime),
+		strings.Join(row.Roles, "|"),
+	}
+}
+
+func exportTime(t common.DateTime) string {
+	if time.Time(t).Year() <= 1 {
+		return ""
+	}
+	return time.Time(t).Format("2006-01-02 15:04:05")
+}
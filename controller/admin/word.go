@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// GetWords 保留词和敏感词列表
+// @tags 管理 - 保留词和敏感词
+// @Summary 保留词和敏感词列表
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param word query string false "词"
+// @Param tp query string false "类型 [reserved|sensitive]"
+// @Param field query string false "适用字段 [name|nickname|address]"
+// @Router /admin/word [get]
+// @Security AdminKeyAuth
+func GetWords(query st.WordQuery, ctx *context.Context) {
+	count, words, err := models.GetWords(query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "words", words)
+}
+
+// CreateWords 添加保留词或敏感词
+// @tags 管理 - 保留词和敏感词
+// @Summary 添加保留词或敏感词
+// @Description 保留词与用户名、昵称或收货人完全相同时禁止使用, 敏感词包含即禁止, 匹配时忽略大小写、空白和标点
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param word formData string true "词, 换行分隔多个, 单次最多500个"
+// @Param tp formData string true "类型 [reserved|sensitive]"
+// @Param fields formData string true "适用字段, 逗号分隔 [name|nickname|address]"
+// @Router /admin/word/create [post]
+// @Security AdminKeyAuth
+func CreateWords(form st.WordForm, ctx *context.Context) {
+	count, err := models.CreateWords(strings.Split(form.Word, "\n"), form.TP, strings.Split(form.Fields, ","))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"count": count})
+}
+
+// UpdateWord 更新保留词或敏感词
+// @tags 管理 - 保留词和敏感词
+// @Summary 更新保留词或敏感词
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "词编号"
+// @Param word formData string true "词"
+// @Param tp formData string true "类型 [reserved|sensitive]"
+// @Param fields formData string true "适用字段, 逗号分隔 [name|nickname|address]"
+// @Router /admin/word/{id}/update [post]
+// @Security AdminKeyAuth
+func UpdateWord(form st.WordForm, ctx *context.Context) {
+	wordDto := &st.WordDto{Word: form.Word, TP: form.TP, Fields: strings.Split(form.Fields, ",")}
+	if err := models.UpdateWord(ctx.ParamsID("wordID"), wordDto); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteWord 删除保留词或敏感词
+// @tags 管理 - 保留词和敏感词
+// @Summary 删除保留词或敏感词
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "词编号"
+// @Router /admin/word/{id}/delete [post]
+// @Security AdminKeyAuth
+func DeleteWord(ctx *context.Context) {
+	if err := models.DelWord(ctx.ParamsID("wordID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
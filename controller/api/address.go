@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// GetAddresses 地址列表
+// @tags 前端 - 用户地址
+// @Summary 地址列表
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/profile/address [get]
+// @Security ApiKeyAuth
+func GetAddresses(ctx *context.Context) {
+	count, addresses, err := models.GetAddresses(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "addresses", addresses)
+}
+
+// CreateAddress 新增地址
+// @tags 前端 - 用户地址
+// @Summary 新增地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=string} "地址编号"
+// @Param name formData string true "收货人"
+// @Param mobile formData string true "手机号"
+// @Param province formData string false "省"
+// @Param city formData string false "市"
+// @Param county formData string false "区"
+// @Param address formData string true "详细地址"
+// @Param zip formData string false "邮编"
+// @Param def formData bool false "是否默认"
+// @Router /api/profile/address/create [post]
+// @Security ApiKeyAuth
+func CreateAddress(form st.AddressForm, ctx *context.Context) {
+	addressID, err := models.CreateAddress(ctx.UserID, addressForm2Dto(&form))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(addressID)
+}
+
+// UpdateAddress 更新地址
+// @tags 前端 - 用户地址
+// @Summary 更新地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "地址编号"
+// @Param name formData string true "收货人"
+// @Param mobile formData string true "手机号"
+// @Param province formData string false "省"
+// @Param city formData string false "市"
+// @Param county formData string false "区"
+// @Param address formData string true "详细地址"
+// @Param zip formData string false "邮编"
+// @Param def formData bool false "是否默认"
+// @Router /api/profile/address/{id}/update [post]
+// @Security ApiKeyAuth
+func UpdateAddress(form st.AddressForm, ctx *context.Context) {
+	addressID := ctx.ParamsID("addressID")
+	if err := models.UpdateAddress(ctx.UserID, addressID, addressForm2Dto(&form)); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// SetDefaultAddress 设为默认地址
+// @tags 前端 - 用户地址
+// @Summary 设为默认地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "地址编号"
+// @Router /api/profile/address/{id}/default [post]
+// @Security ApiKeyAuth
+func SetDefaultAddress(ctx *context.Context) {
+	addressID := ctx.ParamsID("addressID")
+	if err := models.SetDefaultAddress(ctx.UserID, addressID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DelAddress 删除地址
+// @tags 前端 - 用户地址
+// @Summary 删除地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "地址编号"
+// @Router /api/profile/address/{id}/delete [post]
+// @Security ApiKeyAuth
+func DelAddress(ctx *context.Context) {
+	addressID := ctx.ParamsID("addressID")
+	if err := models.DelAddress(ctx.UserID, addressID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+func addressForm2Dto(form *st.AddressForm) *st.AddressDto {
+	addressDto := &st.AddressDto{
+		Name:     form.Name,
+		Mobile:   form.Mobile,
+		Province: form.Province,
+		City:     form.City,
+		County:   form.County,
+		Address:  form.Address,
+		Zip:      form.Zip,
+	}
+	if form.Def {
+		addressDto.Def = "1"
+	}
+	return addressDto
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/go-macaron/binding"
-	"github.com/ihuanglei/authenticator/pkg/config"
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
@@ -40,6 +39,7 @@ func Router(m *macaron.Macaron) {
 
 			m.Group("", func() {
 				m.Post("/password", SendCodeWithPassword)
+				m.Post("/delete", SendCodeWithDelete)
 				m.Group("/bind", func() {
 					m.Post("/mobile", binding.Bind(st.MobileForm{}), SendCodeWithBindMobile)
 					m.Post("/email", binding.Bind(st.EmailForm{}), SendCodeWithBindEmail)
@@ -65,10 +65,37 @@ func Router(m *macaron.Macaron) {
 			})
 		})
 
+		m.Get("/export/:token", DownloadExport)
+
+		m.Get("/legal", GetLegals)
+
 		m.Group("/profile", func() {
 			m.Get("/", Info)
+			m.Patch("/", binding.Bind(st.UpdateProfileForm{}), UpdateProfile)
+			m.Post("/delete", binding.Bind(st.DeleteUserForm{}), DeleteUser)
+			m.Post("/delete/cancel", CancelDeleteUser)
+			m.Post("/export", ExportUser)
+			m.Post("/attribute", SetAttributes)
+			m.Post("/merge", binding.Bind(st.MergeUserForm{}), MergeUser)
+			m.Get("/legal", GetPendingLegals)
+			m.Post("/legal/accept", binding.Bind(st.LegalAcceptForm{}), AcceptLegals)
+			m.Group("/address", func() {
+				m.Get("/", GetAddresses)
+				m.Post("/create", binding.Bind(st.AddressForm{}), CreateAddress)
+				m.Post("/:addressID/update", binding.Bind(st.AddressForm{}), UpdateAddress)
+				m.Post("/:addressID/default", SetDefaultAddress)
+				m.Post("/:addressID/delete", DelAddress)
+			})
+			m.Group("/third", func() {
+				m.Get("/", GetThirds)
+				m.Get("/:id", binding.Bind(st.LoginWithThirdForm{}), BindURLForThird)
+				m.Post("/:id", binding.Bind(st.BindThirdForm{}), BindThird)
+				m.Post("/weixinmp/:id", binding.Bind(st.BindWeiXinMPForm{}), BindWeiXinMP)
+				m.Post("/:id/unbind", UnbindThird)
+			})
 			m.Group("/update", func() {
 				m.Post("/avatar", UpdateAvatar)
+				m.Post("/avatar/upload", UploadAvatar)
 				m.Post("/nickname", UpdateNickname)
 				m.Post("/gender", UpdateGender)
 
@@ -84,18 +111,51 @@ func Router(m *macaron.Macaron) {
 			})
 
 		}, Authorize)
+
+		m.Group("/org", func() {
+			m.Get("/", GetOrgs)
+			m.Post("/create", binding.Bind(st.OrgForm{}), CreateOrg)
+			m.Post("/invite/accept", AcceptOrgInvite)
+			m.Group("/:orgID", func() {
+				m.Get("", GetOrg)
+				m.Post("/update", binding.Bind(st.OrgForm{}), UpdateOrg)
+				m.Post("/delete", DeleteOrg)
+				m.Post("/transfer", binding.Bind(st.OrgMemberForm{}), TransferOrg)
+				m.Post("/leave", LeaveOrg)
+				m.Get("/member", binding.Bind(st.EmptyQuery{}), GetOrgMembers)
+				m.Post("/member/:userID/role", binding.Bind(st.OrgMemberForm{}), SetOrgMemberRole)
+				m.Post("/member/:userID/remove", RemoveOrgMember)
+				m.Get("/invite", GetOrgInvites)
+				m.Post("/invite", binding.Bind(st.OrgInviteForm{}), CreateOrgInvite)
+				m.Post("/invite/:inviteID/revoke", RevokeOrgInvite)
+			}, OrgAuthorize)
+		}, Authorize)
 	})
+
+	m.Group("/v1/authz", func() {
+		m.Post("/check", binding.Bind(st.AuthzCheckForm{}), CheckPermission)
+		m.Post("/check/batch", binding.Bind(st.AuthzBatchForm{}), CheckPermissions)
+	}, ServiceAuthorize)
 }
 
-// Authorize 登录认证
-func Authorize(config *config.Config, ctx *context.Context) {
+// Authorize 登录认证, 令牌必须属于当前租户
+func Authorize(ctx *context.Context) {
 	authorizations := strings.Split(ctx.Req.Header.Get(consts.HeaderAuthorizationKey), " ")
 	if len(authorizations) != 2 || authorizations[0] != "Authenticator" || authorizations[1] == "" {
 		ctx.JSONAuth(errors.ErrNotLogin.Error())
 		return
 	}
-	authCode := authorizations[1]
-	hs256 := jwt.NewHS256([]byte(config.Server.JWTSecret))
+	sessionUser, err := parseToken(ctx.Secret, ctx.Tenant, authorizations[1])
+	if err != nil {
+		ctx.JSONAuth(err.Error())
+		return
+	}
+	ctx.SessionUser = sessionUser
+}
+
+// 解析登录令牌, 未包含租户的令牌属于默认租户
+func parseToken(secret, tenant, authCode string) (*context.SessionUser, error) {
+	hs256 := jwt.NewHS256([]byte(secret))
 	var p jwt.Payload
 	now := time.Now()
 	iatValidator := jwt.IssuedAtValidator(now)
@@ -105,16 +165,20 @@ func Authorize(config *config.Config, ctx *context.Context) {
 	_, err := jwt.Verify([]byte(authCode), hs256, &p, verifyOption)
 	if err != nil {
 		logger.Debug(err)
-		ctx.JSONAuth(errors.ErrAuthExpired.Error())
-		return
+		return nil, errors.ErrAuthExpired
 	}
 	sessionUser := new(context.SessionUser)
 	err = json.Unmarshal([]byte(p.Subject), sessionUser)
 	if err != nil {
 		logger.Debug(err)
-		ctx.JSONAuth(errors.ErrAuthInvalidData.Error())
-		return
+		return nil, errors.ErrAuthInvalidData
+	}
+	if sessionUser.Tenant == "" {
+		sessionUser.Tenant = consts.DefaultTenant
+	}
+	if sessionUser.Tenant != tenant {
+		return nil, errors.ErrTenantMismatch
 	}
 	sessionUser.UserID = common.StrToID(sessionUser.UserStrID)
-	ctx.SessionUser = sessionUser
+	return sessionUser, nil
 }
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// 批量检查的最大数量
+const maxAuthzBatch = 100
+
+// ServiceAuthorize 服务凭证认证, 使用配置中的 authz.clients
+func ServiceAuthorize(config *config.Config, ctx *context.Context) {
+	id, secret, ok := ctx.Req.BasicAuth()
+	if ok && id != "" && secret != "" {
+		for _, client := range config.Authz.Clients {
+			if client.ID == id && subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) == 1 {
+				return
+			}
+		}
+	}
+	ctx.JSONAuth(errors.ErrServiceAuth.Error())
+}
+
+// CheckPermission 权限检查
+// @tags 服务 - 权限检查
+// @Summary 权限检查
+// @Description 与管理后台使用相同的权限规则, 用户编号和令牌填写一个, 用户必须属于当前租户
+// @Description 指定域时检查用户在应用或租户中的权限, 策略设置了条件时使用 ip 和 owner_id 检查
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param domain formData string false "域, 为空时检查管理后台的权限"
+// @Param user_id formData string false "用户编号"
+// @Param token formData string false "登录令牌"
+// @Param obj formData string true "资源, 例如 /v1/admin/user"
+// @Param act formData string true "方法, 例如 get"
+// @Param ip formData string false "用户的客户端IP"
+// @Param owner_id formData string false "访问对象所有者的用户编号"
+// @Router /authz/check [post]
+// @Security ServiceAuth
+func CheckPermission(checker *authzer.Checker, form st.AuthzCheckForm, ctx *context.Context) {
+	sub, err := authzSubject(ctx, form.UserID, form.Token)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	env := authzer.Env{IP: common.Trim(form.IP), Owner: common.Trim(form.OwnerID)}
+	allow, err := checker.Check(sub, common.Trim(form.Domain), form.Obj, common.ToLower(form.Act), env)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"allow": allow})
+}
+
+// CheckPermissions 批量权限检查
+// @tags 服务 - 权限检查
+// @Summary 批量权限检查
+// @Description obj和act按顺序一一对应, 最多100项, 结果按相同顺序返回
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param domain formData string false "域, 为空时检查管理后台的权限"
+// @Param user_id formData string false "用户编号"
+// @Param token formData string false "登录令牌"
+// @Param obj formData []string true "资源"
+// @Param act formData []string true "方法"
+// @Param ip formData string false "用户的客户端IP"
+// @Param owner_id formData string false "访问对象所有者的用户编号"
+// @Router /authz/check/batch [post]
+// @Security ServiceAuth
+func CheckPermissions(checker *authzer.Checker, form st.AuthzBatchForm, ctx *context.Context) {
+	if len(form.Obj) == 0 || len(form.Obj) > maxAuthzBatch || len(form.Obj) != len(form.Act) {
+		ctx.BadRequestByError(errors.ErrAuthzBatch)
+		return
+	}
+	sub, err := authzSubject(ctx, form.UserID, form.Token)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	domain := common.Trim(form.Domain)
+	env := authzer.Env{IP: common.Trim(form.IP), Owner: common.Trim(form.OwnerID)}
+	results := make([]*st.AuthzResultDto, 0, len(form.Obj))
+	for i, obj := range form.Obj {
+		act := common.ToLower(form.Act[i])
+		allow, err := checker.Check(sub, domain, obj, act, env)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		results = append(results, &st.AuthzResultDto{Obj: obj, Act: act, Allow: allow})
+	}
+	ctx.JSON(map[string]interface{}{"results": results})
+}
+
+// 检查对象, 优先使用令牌中的用户
+func authzSubject(ctx *context.Context, userID, token string) (string, error) {
+	if token = strings.TrimPrefix(common.Trim(token), "Authenticator "); token != "" {
+		sessionUser, err := parseToken(ctx.Secret, ctx.Tenant, token)
+		if err != nil {
+			return "", err
+		}
+		userID = sessionUser.UserStrID
+	}
+	if userID = common.Trim(userID); userID == "" {
+		return "", errors.ErrAuthzSubject
+	}
+	user, err := models.GetUserByID(common.StrToID(userID))
+	if err != nil {
+		logger.Debug(err)
+		return "", errors.ErrAuthzSubject
+	}
+	if user.Tenant != ctx.Tenant {
+		return "", errors.ErrAuthzSubject
+	}
+	return user.UserID.Str(), nil
+}
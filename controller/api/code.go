@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
@@ -19,6 +20,8 @@ const (
 	codeKeyWithBindMobile = "__code_bind_mobile_%v"
 	// 登录用户绑定邮箱
 	codeKeyWithBindEmail = "__code_bind_email_%v"
+	// 登录用户注销账号
+	codeKeyWithDelete = "__code_delete_%v"
 
 	// 忘记密码验证过你吗
 	codeKeyByForgotPwdWithEmail = "__code_forgot_email_%v"
@@ -29,20 +32,28 @@ const (
 	codeKeyWithReg = "__code_reg_%v"
 )
 
-func saveCodeAndSendWithMobile(t, mobile string, ex int, cache cache.Cache) error {
-	key := fmt.Sprintf(t, mobile)
+// 验证码缓存key, 非默认租户加上租户代码, 同一手机号或邮箱在不同租户互不影响
+func codeKey(t, tenant, to string) string {
+	if tenant != "" && tenant != consts.DefaultTenant {
+		to = tenant + ":" + to
+	}
+	return fmt.Sprintf(t, to)
+}
+
+func saveCodeAndSendWithMobile(t, tenant, mobile string, ex int, cache cache.Cache) error {
+	key := codeKey(t, tenant, mobile)
 	exp := time.Minute * time.Duration(ex)
 	code := common.RandomNumber(6)
 	err := cache.Set(key, code, exp)
 	if err != nil {
 		return err
 	}
-	go sendMessageWithMobile(mobile, code)
+	go sendMessageWithMobile(tenant, mobile, code)
 	return nil
 }
 
-func saveCodeAndSendWithEmail(t, email string, ex int, cache cache.Cache) error {
-	key := fmt.Sprintf(t, email)
+func saveCodeAndSendWithEmail(t, tenant, email string, ex int, cache cache.Cache) error {
+	key := codeKey(t, tenant, email)
 	exp := time.Minute * time.Duration(ex)
 	code := common.RandomNumber(6)
 	err := cache.Set(key, code, exp)
@@ -51,9 +62,9 @@ func saveCodeAndSendWithEmail(t, email string, ex int, cache cache.Cache) error
 	}
 	switch t {
 	case codeKeyWithBindEmail:
-		go sendBindMessageWithEmail(email, code)
+		go sendBindMessageWithEmail(tenant, email, code)
 	case codeKeyByForgotPwdWithEmail:
-		go sendForgotMessageWithEmail(email, code)
+		go sendForgotMessageWithEmail(tenant, email, code)
 	}
 	return nil
 }
@@ -66,7 +77,7 @@ func saveCodeAndSendWithEmail(t, email string, ex int, cache cache.Cache) error
 // @Param mobile formData string false "手机号"
 // @Router /api/code/reg [post]
 func SendCodeWithReg(form st.MobileForm, ctx *context.Context, cache cache.Cache) {
-	has, err := models.HasUserByMobile(form.Mobile)
+	has, err := models.HasUserByMobile(ctx.Tenant, form.Mobile)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -75,7 +86,7 @@ func SendCodeWithReg(form st.MobileForm, ctx *context.Context, cache cache.Cache
 		ctx.BadRequestByError(errors.ErrUserExist)
 		return
 	}
-	if err := saveCodeAndSendWithMobile(codeKeyWithReg, form.Mobile, 5, cache); err != nil {
+	if err := saveCodeAndSendWithMobile(codeKeyWithReg, ctx.Tenant, form.Mobile, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -90,11 +101,11 @@ func SendCodeWithReg(form st.MobileForm, ctx *context.Context, cache cache.Cache
 // @Param mobile formData string false "手机号"
 // @Router /api/code/login [post]
 func SendCodeWithLogin(form st.MobileForm, ctx *context.Context, cache cache.Cache) {
-	if err := models.CheckUserByMobile(form.Mobile); err != nil {
+	if err := models.CheckUserByMobile(ctx.Tenant, form.Mobile); err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	if err := saveCodeAndSendWithMobile(codeKeyWithLogin, form.Mobile, 5, cache); err != nil {
+	if err := saveCodeAndSendWithMobile(codeKeyWithLogin, ctx.Tenant, form.Mobile, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -118,7 +129,31 @@ func SendCodeWithPassword(ctx *context.Context, cache cache.Cache) {
 		ctx.BadRequestByError(errors.ErrUserMobileNotBind)
 		return
 	}
-	if err := saveCodeAndSendWithMobile(codeKeyWithChangePassword, user.Mobile, 5, cache); err != nil {
+	if err := saveCodeAndSendWithMobile(codeKeyWithChangePassword, ctx.Tenant, user.Mobile, 5, cache); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// SendCodeWithDelete 注销账号验证码(用户已登录)
+// @tags 前端 - 手机验证码
+// @Summary 注销账号验证码(用户已登录)
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult "验证码"
+// @Router /api/code/delete [post]
+// @Security ApiKeyAuth
+func SendCodeWithDelete(ctx *context.Context, cache cache.Cache) {
+	user, err := models.GetUserByID(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if user.Mobile == user.UserID.Str() {
+		ctx.BadRequestByError(errors.ErrUserMobileNotBind)
+		return
+	}
+	if err := saveCodeAndSendWithMobile(codeKeyWithDelete, ctx.Tenant, user.Mobile, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -134,7 +169,7 @@ func SendCodeWithPassword(ctx *context.Context, cache cache.Cache) {
 // @Router /api/code/bind/mobile [post]
 // @Security ApiKeyAuth
 func SendCodeWithBindMobile(form st.MobileForm, ctx *context.Context, cache cache.Cache) {
-	has, err := models.HasUserByMobile(form.Mobile)
+	has, err := models.HasUserByMobile(ctx.Tenant, form.Mobile)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -143,7 +178,7 @@ func SendCodeWithBindMobile(form st.MobileForm, ctx *context.Context, cache cach
 		ctx.BadRequestByError(errors.ErrUserMobileExist)
 		return
 	}
-	if err := saveCodeAndSendWithMobile(codeKeyWithBindMobile, form.Mobile, 5, cache); err != nil {
+	if err := saveCodeAndSendWithMobile(codeKeyWithBindMobile, ctx.Tenant, form.Mobile, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -159,7 +194,7 @@ func SendCodeWithBindMobile(form st.MobileForm, ctx *context.Context, cache cach
 // @Router /api/code/bind/email [post]
 // @Security ApiKeyAuth
 func SendCodeWithBindEmail(form st.EmailForm, ctx *context.Context, cache cache.Cache) {
-	has, err := models.HasUserByEmail(form.Email)
+	has, err := models.HasUserByEmail(ctx.Tenant, form.Email)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -168,7 +203,7 @@ func SendCodeWithBindEmail(form st.EmailForm, ctx *context.Context, cache cache.
 		ctx.BadRequestByError(errors.ErrUserEmailExist)
 		return
 	}
-	if err := saveCodeAndSendWithEmail(codeKeyWithBindEmail, form.Email, 5, cache); err != nil {
+	if err := saveCodeAndSendWithEmail(codeKeyWithBindEmail, ctx.Tenant, form.Email, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -184,7 +219,7 @@ func SendCodeWithBindEmail(form st.EmailForm, ctx *context.Context, cache cache.
 // @Router /api/code/forgot/email [post]
 // @Security ApiKeyAuth
 func SendCodeByForgotPasswordWithEmail(form st.EmailForm, ctx *context.Context, cache cache.Cache) {
-	has, err := models.HasUserByEmail(form.Email)
+	has, err := models.HasUserByEmail(ctx.Tenant, form.Email)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -193,7 +228,7 @@ func SendCodeByForgotPasswordWithEmail(form st.EmailForm, ctx *context.Context,
 		ctx.BadRequestByError(errors.ErrUserNotExist)
 		return
 	}
-	if err := saveCodeAndSendWithEmail(codeKeyByForgotPwdWithEmail, form.Email, 5, cache); err != nil {
+	if err := saveCodeAndSendWithEmail(codeKeyByForgotPwdWithEmail, ctx.Tenant, form.Email, 5, cache); err != nil {
 		ctx.Error(err)
 		return
 	}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/export"
+)
+
+// ExportUser 导出个人数据
+// @tags 前端 - 用户信息
+// @Summary 导出个人数据(异步生成, 返回下载地址)
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=export.Task} "导出任务"
+// @Router /api/profile/export [post]
+// @Security ApiKeyAuth
+func ExportUser(exporter *export.Exporter, ctx *context.Context) {
+	task, err := exporter.Start(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(task)
+}
+
+// DownloadExport 下载导出数据
+// @tags 前端 - 用户信息
+// @Summary 下载导出数据(下载地址有时效)
+// @Accept x-www-form-urlencoded
+// @Produce application/zip
+// @Success 200 {file} file "zip文件"
+// @Param token path string true "导出任务令牌"
+// @Router /api/export/{token} [get]
+func DownloadExport(exporter *export.Exporter, ctx *context.Context) {
+	task, err := exporter.Get(ctx.Params("token"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	switch task.Status {
+	case export.Pending:
+		ctx.BadRequestByError(errors.ErrExportPending)
+	case export.Done:
+		ctx.ServeFile(exporter.File(task), fmt.Sprintf("export_%v.zip", task.UserID))
+	default:
+		ctx.BadRequestByError(errors.ErrExportNotFound)
+	}
+}
@@ -1,8 +1,6 @@
 package api
 
 import (
-	"fmt"
-
 	"github.com/ihuanglei/authenticator/models"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
@@ -20,13 +18,13 @@ import (
 // @Param code formData string false "邮件验证码"
 // @Router /api/forgot/reset/email [post]
 func ResetPasswordByCodeWithEmail(form st.ResetPasswordWithEmailCodeForm, ctx *context.Context, cache cache.Cache) {
-	key := fmt.Sprintf(codeKeyByForgotPwdWithEmail, form.Email)
+	key := codeKey(codeKeyByForgotPwdWithEmail, ctx.Tenant, form.Email)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
 		return
 	}
-	user, err := models.GetUserByEmail(form.Email)
+	user, err := models.GetUserByEmail(ctx.Tenant, form.Email)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// GetLegals 当前生效的协议
+// @tags 前端 - 用户协议
+// @Summary 当前生效的协议
+// @Description 注册即表示同意当前生效的服务条款和隐私政策
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/legal [get]
+func GetLegals(ctx *context.Context) {
+	legals, err := models.GetCurrentLegals(ctx.Tenant)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(legals)), "legals", legals)
+}
+
+// GetPendingLegals 需要同意的协议
+// @tags 前端 - 用户协议
+// @Summary 需要同意的协议
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/profile/legal [get]
+// @Security ApiKeyAuth
+func GetPendingLegals(ctx *context.Context) {
+	legals, err := models.GetPendingLegals(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(legals)), "legals", legals)
+}
+
+// AcceptLegals 同意协议
+// @tags 前端 - 用户协议
+// @Summary 同意协议
+// @Description 只能同意当前生效的协议, 已同意的忽略
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param legal_ids formData string true "协议编号, 逗号分隔"
+// @Router /api/profile/legal/accept [post]
+// @Security ApiKeyAuth
+func AcceptLegals(form st.LegalAcceptForm, ctx *context.Context) {
+	if err := models.AcceptLegals(ctx.UserID, parseLegalIDs(form.LegalIDs), ctx.IP); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// 逗号分隔的协议编号
+func parseLegalIDs(ids string) []common.ID {
+	var legalIDs []common.ID
+	for _, id := range strings.Split(ids, ",") {
+		if id = common.Trim(id); id != "" {
+			legalIDs = append(legalIDs, common.StrToID(id))
+		}
+	}
+	return legalIDs
+}
@@ -31,38 +31,40 @@ const (
 // Login 手机号\邮箱\用户名和密码登录
 // @tags 前端 - 用户登录
 // @Summary 手机号\邮箱\用户名和密码登录
+// @Description 协议更新后未同意时返回状态码11204, data中包含令牌和需要同意的协议
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param login_name formData string false "[手机号|邮箱|用户名]"
 // @Param password formData string false "密码"
 // @Router /api/login [post]
 func Login(form st.LoginForm, ctx *context.Context) {
-	token, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
+	token, legals, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
 		return models.Login(loginDto)
 	})
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	ctx.JSON(string(token))
+	jsonToken(ctx, token, legals)
 }
 
 // LoginByMobile 手机号和验证码登录
 // @tags 前端 - 用户登录
 // @Summary 手机号和验证码登录
+// @Description 协议更新后未同意时返回状态码11204, data中包含令牌和需要同意的协议
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param mobile formData string false "手机号"
 // @Param code formData string false "验证码"
 // @Router /api/login/mobile [post]
 func LoginByMobile(form st.LoginWithMobileAndCodeForm, config *config.Config, cache cache.Cache, ctx *context.Context) {
-	key := fmt.Sprintf(codeKeyWithLogin, form.Mobile)
+	key := codeKey(codeKeyWithLogin, ctx.Tenant, form.Mobile)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
 		return
 	}
-	token, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
+	token, legals, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
 		return models.LoginByMobile(loginDto)
 	})
 	if err != nil {
@@ -70,12 +72,13 @@ func LoginByMobile(form st.LoginWithMobileAndCodeForm, config *config.Config, ca
 		return
 	}
 	cache.Del(key)
-	ctx.JSON(string(token))
+	jsonToken(ctx, token, legals)
 }
 
 // LoginByThirdCode 第三方使用code登录
 // @tags 前端 - 用户登录
 // @Summary 第三方QQ，微信，微博使用code登录
+// @Description 协议更新后未同意时返回状态码11204, data中包含令牌和需要同意的协议
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param id path string true "第三方编号"
@@ -84,14 +87,14 @@ func LoginByMobile(form st.LoginWithMobileAndCodeForm, config *config.Config, ca
 // @Router /api/login/th/{id} [post]
 func LoginByThirdCode(form st.LoginWithThirdCodeForm, cache cache.Cache, ctx *context.Context) {
 	id := ctx.Params("id")
-	thirdUser, err := getThirdUser(id, form.Code)
+	thirdUser, err := getThirdUser(ctx.Tenant, id, form.Code)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
 	form.OpenID = thirdUser.OpenID
 	form.Type = thirdUser.TP
-	token, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
+	token, legals, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
 		return models.LoginByOpenID(loginDto)
 	})
 	// 三方没有注册过返回三方用户信息
@@ -107,12 +110,13 @@ func LoginByThirdCode(form st.LoginWithThirdCodeForm, cache cache.Cache, ctx *co
 		}
 		return
 	}
-	ctx.JSON(string(token))
+	jsonToken(ctx, token, legals)
 }
 
 // LoginByWeiXinMPCode 微信小程序code获取session
 // @tags 前端 - 用户登录
 // @Summary 微信小程序登录
+// @Description 协议更新后未同意时返回状态码11204, data中包含令牌和需要同意的协议
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param id path string true "第三方编号"
@@ -142,7 +146,7 @@ func LoginByWeiXinMPCode(form st.LoginWithWeiXinMPCodeForm, cache cache.Cache, c
 	}
 
 	id := ctx.Params("id")
-	mp, err := getThird(id)
+	mp, err := getThird(ctx.Tenant, id)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -170,7 +174,7 @@ func LoginByWeiXinMPCode(form st.LoginWithWeiXinMPCodeForm, cache cache.Cache, c
 
 	form.OpenID = openID
 	form.Type = mp.GetType()
-	token, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
+	token, legals, err := login(&form, ctx, func(loginDto *st.LoginDto) (*st.UserDto, error) {
 		return models.LoginByOpenID(loginDto)
 	})
 	if err != nil {
@@ -194,7 +198,7 @@ func LoginByWeiXinMPCode(form st.LoginWithWeiXinMPCodeForm, cache cache.Cache, c
 		}
 		return
 	}
-	ctx.JSON(string(token))
+	jsonToken(ctx, token, legals)
 }
 
 // RedirectURLForThird 第三方登录
@@ -207,7 +211,7 @@ func LoginByWeiXinMPCode(form st.LoginWithWeiXinMPCodeForm, cache cache.Cache, c
 // @Router /api/login/th/{id} [get]
 func RedirectURLForThird(form st.LoginWithThirdForm, ctx *context.Context) {
 	id := ctx.Params("id")
-	url, err := getAuthorizeURL(id, form.State)
+	url, err := getAuthorizeURL(ctx.Tenant, id, form.State)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -215,16 +219,16 @@ func RedirectURLForThird(form st.LoginWithThirdForm, ctx *context.Context) {
 	ctx.JSON(url)
 }
 
-func getAuthorizeURL(id, state string) (string, error) {
-	third, err := getThird(id)
+func getAuthorizeURL(tenant, id, state string) (string, error) {
+	third, err := getThird(tenant, id)
 	if err != nil {
 		return "", err
 	}
 	return third.GetAuthorizeURL(state), nil
 }
 
-func getThirdUser(id, code string) (*third.User, error) {
-	third, err := getThird(id)
+func getThirdUser(tenant, id, code string) (*third.User, error) {
+	third, err := getThird(tenant, id)
 	if err != nil {
 		return nil, err
 	}
@@ -235,8 +239,9 @@ func getThirdUser(id, code string) (*third.User, error) {
 	return thirdUser, nil
 }
 
-func getThird(id string) (third.Third, error) {
-	dictDto, err := models.GetDictByID(common.StrToID(id))
+// 第三方配置, 可使用租户自己的或默认租户的配置
+func getThird(tenant, id string) (third.Third, error) {
+	dictDto, err := models.GetDictByID(tenant, common.StrToID(id))
 	if err != nil {
 		return nil, err
 	}
@@ -285,24 +290,39 @@ func getThird(id string) (third.Third, error) {
 	return nil, errors.ErrDictNotFound
 }
 
-func login(form interface{}, ctx *context.Context, handle func(loginDto *st.LoginDto) (*st.UserDto, error)) (string, error) {
+// 登录并签发令牌, 同时返回用户尚未同意的协议
+func login(form interface{}, ctx *context.Context, handle func(loginDto *st.LoginDto) (*st.UserDto, error)) (string, []*st.LegalDto, error) {
 	loginDto := new(st.LoginDto)
 	if err := convert.Map(form, loginDto); err != nil {
-		return "", err
+		return "", nil, err
 	}
 	loginDto.IP = ctx.IP
+	loginDto.Tenant = ctx.Tenant
 	userDto, err := handle(loginDto)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	token, err := getUserAndCreateJWTToken(userDto.UserID, ctx.Secret, ctx.Expire)
+	token, err := getUserAndCreateJWTToken(userDto.UserID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	legals, err := models.GetPendingLegals(userDto.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("Authenticator %v", string(token)), legals, nil
+}
+
+// 返回令牌, 有未同意的协议时返回需同意状态, 令牌仍然有效, 客户端展示协议后调用同意接口
+func jsonToken(ctx *context.Context, token string, legals []*st.LegalDto) {
+	if len(legals) > 0 {
+		ctx.JSONByCode(errors.ErrLegalMustAccept.Code(), map[string]interface{}{"token": token, "legals": legals})
+		return
 	}
-	return fmt.Sprintf("Authenticator %v", string(token)), nil
+	ctx.JSON(token)
 }
 
-func getUserAndCreateJWTToken(userID common.ID, secret string, expire int64) ([]byte, error) {
+func getUserAndCreateJWTToken(userID common.ID, tenant, secret string, expire int64) ([]byte, error) {
 	userInfoDto, err := models.GetUserInfoByID(userID)
 	if err != nil {
 		return nil, err
@@ -315,6 +335,21 @@ func getUserAndCreateJWTToken(userID common.ID, secret string, expire int64) ([]
 	subjectMap["user_id"] = userInfoDto.UserID
 	subjectMap["avatar"] = userInfoDto.Avatar
 	subjectMap["nickname"] = userInfoDto.Nickname
+	subjectMap["tenant"] = tenant
+	claims, err := models.GetUserClaims(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) > 0 {
+		subjectMap["attrs"] = claims
+	}
+	orgs, err := models.GetOrgRolesForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) > 0 {
+		subjectMap["orgs"] = orgs
+	}
 	b, err := common.ToJSON(subjectMap)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// MergeUser 合并账号
+// @tags 前端 - 用户信息
+// @Summary 将另一个账号合并到当前账号
+// @Description 需要先登录被合并的账号获取令牌以证明拥有该账号, 合并后被合并的账号将被删除
+// @Description 用户名、邮箱、手机号及同类型的第三方绑定冲突时保留当前账号的
+// @Description 两个账号的地址合计超过上限时不能合并, 需要先删除部分地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=st.UserMergeDto} "合并记录"
+// @Param token formData string true "被合并账号的登录令牌"
+// @Router /api/profile/merge [post]
+// @Security ApiKeyAuth
+func MergeUser(form st.MergeUserForm, e *casbin.Enforcer, de *authzer.DomainEnforcer, grants *authzer.Grants, ctx *context.Context) {
+	source, err := parseToken(ctx.Secret, ctx.Tenant, strings.TrimPrefix(form.Token, "Authenticator "))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	merge, err := models.MergeUser(source.UserID, ctx.UserID, ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	// 账号已合并, 角色转移失败时由定时任务重试
+	if err := grants.FinishMerges(e, de); err != nil {
+		logger.Error(err)
+	}
+	ctx.JSON(merge)
+}
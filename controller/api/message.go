@@ -2,26 +2,14 @@ package api
 
 import (
 	"fmt"
-	"runtime"
 	"strings"
 
-	"github.com/ihuanglei/authenticator/models"
 	"github.com/ihuanglei/authenticator/pkg/logger"
 	"github.com/ihuanglei/authenticator/pkg/message"
 	"github.com/simplexwork/common"
 )
 
 const (
-	// 通知类邮箱配置
-	cateEmail = "email"
-	tpEmail   = "notice"
-
-	// 验证码短信配置
-	cateSMS = "sms"
-	tpSMS   = "identify"
-
-	// 模板类型
-	cateTmpl = "tmpl"
 	// 邮件注册激活码模板
 	tmplEmailReg = "email_activate"
 	// 邮件绑定验证码模板
@@ -33,9 +21,9 @@ const (
 )
 
 // 邮箱注册激活
-func sendActivateMessageWithEmail(userID common.ID, activateCode, email string) {
-	defer messageRecover()
-	ev, err := buildMailMessage(tmplEmailReg)
+func sendActivateMessageWithEmail(tenant string, userID common.ID, activateCode, email string) {
+	defer message.Recover()
+	ev, err := message.BuildMailMessage(tenant, tmplEmailReg)
 	if err != nil {
 		logger.Error(err)
 		return
@@ -47,9 +35,9 @@ func sendActivateMessageWithEmail(userID common.ID, activateCode, email string)
 }
 
 // 邮箱绑定\更新
-func sendBindMessageWithEmail(email, code string) {
-	defer messageRecover()
-	ev, err := buildMailMessage(tmplEmailBind)
+func sendBindMessageWithEmail(tenant, email, code string) {
+	defer message.Recover()
+	ev, err := message.BuildMailMessage(tenant, tmplEmailBind)
 	if err != nil {
 		logger.Error(err)
 		return
@@ -60,9 +48,9 @@ func sendBindMessageWithEmail(email, code string) {
 }
 
 // 邮箱忘记密码
-func sendForgotMessageWithEmail(email, code string) {
-	defer messageRecover()
-	ev, err := buildMailMessage(tmplEmailForgot)
+func sendForgotMessageWithEmail(tenant, email, code string) {
+	defer message.Recover()
+	ev, err := message.BuildMailMessage(tenant, tmplEmailForgot)
 	if err != nil {
 		logger.Error(err)
 		return
@@ -72,38 +60,18 @@ func sendForgotMessageWithEmail(email, code string) {
 	message.SendMessage(ev)
 }
 
-func buildMailMessage(tmpl string) (*message.MailMessage, error) {
-	// FIXME: 是否要缓存当前邮件数据?
-	dictDto, err := models.GetOneDict(cateEmail, tpEmail)
-	if err != nil {
-		return nil, err
-	}
-	var ev *message.MailMessage
-	if err := common.FromJSON([]byte(dictDto.Value), &ev); err != nil {
-		return nil, err
-	}
-	dictDto1, err := models.GetOneDict(cateTmpl, tmpl)
-	if err != nil {
-		return nil, err
-	}
-	if err := common.FromJSON([]byte(dictDto1.Value), &ev); err != nil {
-		return nil, err
-	}
-	return ev, nil
-}
-
 /***** ******/
 
-// 短信验证码，统一格式
-func sendMessageWithMobile(mobile, code string) {
+// 短信验证码，统一格式, 使用租户的短信配置
+func sendMessageWithMobile(tenant, mobile, code string) {
 	replaceVarFunc := func(s, mobile, code, body string) string {
 		s = strings.ReplaceAll(s, "{to}", mobile)
 		s = strings.ReplaceAll(s, "{code}", code)
 		s = strings.ReplaceAll(s, "{body}", body)
 		return s
 	}
-	defer messageRecover()
-	ev, err := buildSMSMessage(tmplMobileReg)
+	defer message.Recover()
+	ev, err := message.BuildSMSMessage(tenant, tmplMobileReg)
 	if err != nil {
 		logger.Error(err)
 		return
@@ -119,31 +87,3 @@ func sendMessageWithMobile(mobile, code string) {
 	ev.Body = body
 	message.SendMessage(ev)
 }
-
-func buildSMSMessage(tmpl string) (*message.SMSMessage, error) {
-	// FIXME: 是否要缓存当前短信数据?
-	dictDto, err := models.GetOneDict(cateSMS, tpSMS)
-	if err != nil {
-		return nil, err
-	}
-	var ev *message.SMSMessage
-	if err := common.FromJSON([]byte(dictDto.Value), &ev); err != nil {
-		return nil, err
-	}
-	dictDto1, err := models.GetOneDict(cateTmpl, tmpl)
-	if err != nil {
-		return nil, err
-	}
-	ev.Body = dictDto1.Value
-	return ev, nil
-}
-
-/***** ******/
-
-func messageRecover() {
-	if err := recover(); err != nil {
-		var buf [1024]byte
-		n := runtime.Stack(buf[:], false)
-		logger.Error(string(buf[:n]))
-	}
-}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/authzer"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// OrgAuthorize 组织内权限, 按用户在组织成员表中的角色检查, 非成员没有权限
+func OrgAuthorize(e *authzer.OrgEnforcer, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	role, err := models.GetOrgRole(orgID, ctx.UserID)
+	if err == errors.ErrOrgNotFound || err == errors.ErrOrgNotMember {
+		ctx.AccessDenied()
+		return
+	} else if err != nil {
+		ctx.Error(err)
+		return
+	}
+	method := common.ToLower(ctx.Req.Method)
+	ok, err := e.Enforce(role, orgID.Str(), ctx.Req.URL.Path, method)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.AccessDenied()
+		return
+	}
+}
+
+// GetOrgs 我的组织
+// @tags 前端 - 组织
+// @Summary 我加入的组织
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/org [get]
+// @Security ApiKeyAuth
+func GetOrgs(ctx *context.Context) {
+	orgs, err := models.GetOrgsForUser(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(orgs)), "orgs", orgs)
+}
+
+// CreateOrg 创建组织
+// @tags 前端 - 组织
+// @Summary 创建组织, 创建者为所有者
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param name formData string true "名称"
+// @Param description formData string false "描述"
+// @Router /api/org/create [post]
+// @Security ApiKeyAuth
+func CreateOrg(form st.OrgForm, ctx *context.Context) {
+	orgID, err := models.CreateOrg(ctx.UserID, &st.OrgDto{Name: form.Name, Description: form.Description})
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"org_id": orgID})
+}
+
+// GetOrg 组织信息
+// @tags 前端 - 组织
+// @Summary 组织信息
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /api/org/{id} [get]
+// @Security ApiKeyAuth
+func GetOrg(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	org, err := models.GetOrg(orgID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if org.Role, err = models.GetOrgRole(orgID, ctx.UserID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(org)
+}
+
+// UpdateOrg 更新组织
+// @tags 前端 - 组织
+// @Summary 更新组织, 需要管理员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param name formData string true "名称"
+// @Param description formData string false "描述"
+// @Router /api/org/{id}/update [post]
+// @Security ApiKeyAuth
+func UpdateOrg(form st.OrgForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	if err := models.UpdateOrg(orgID, &st.OrgDto{Name: form.Name, Description: form.Description}); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteOrg 删除组织
+// @tags 前端 - 组织
+// @Summary 删除组织, 需要所有者
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /api/org/{id}/delete [post]
+// @Security ApiKeyAuth
+func DeleteOrg(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	if err := models.DelOrg(orgID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// TransferOrg 转让组织
+// @tags 前端 - 组织
+// @Summary 转让组织, 需要所有者, 转让后原所有者成为管理员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param user_id formData string true "新所有者, 必须是组织成员"
+// @Router /api/org/{id}/transfer [post]
+// @Security ApiKeyAuth
+func TransferOrg(form st.OrgMemberForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := common.StrToID(form.UserID)
+	if err := models.TransferOrg(orgID, userID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetOrgMembers 组织成员
+// @tags 前端 - 组织
+// @Summary 组织成员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /api/org/{id}/member [get]
+// @Security ApiKeyAuth
+func GetOrgMembers(query st.EmptyQuery, ctx *context.Context) {
+	count, members, err := models.GetOrgMembers(ctx.ParamsID("orgID"), query)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(count, "members", members)
+}
+
+// SetOrgMemberRole 设置成员角色
+// @tags 前端 - 组织
+// @Summary 设置成员角色, 需要所有者
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param userID path string true "用户编号"
+// @Param role formData string true "角色" Enums(admin, member)
+// @Router /api/org/{id}/member/{userID}/role [post]
+// @Security ApiKeyAuth
+func SetOrgMemberRole(form st.OrgMemberForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := ctx.ParamsID("userID")
+	role := consts.OrgRole(form.Role)
+	if err := models.SetOrgMemberRole(orgID, userID, role); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// RemoveOrgMember 移除成员
+// @tags 前端 - 组织
+// @Summary 移除成员, 需要管理员, 只能移除角色低于自己的成员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param userID path string true "用户编号"
+// @Router /api/org/{id}/member/{userID}/remove [post]
+// @Security ApiKeyAuth
+func RemoveOrgMember(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	userID := ctx.ParamsID("userID")
+	operator, err := models.GetOrgRole(orgID, ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if err := models.RemoveOrgMember(orgID, userID, operator); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// LeaveOrg 退出组织
+// @tags 前端 - 组织
+// @Summary 退出组织, 所有者需先转让组织
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /api/org/{id}/leave [post]
+// @Security ApiKeyAuth
+func LeaveOrg(ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	if err := models.LeaveOrg(orgID, ctx.UserID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// GetOrgInvites 组织邀请
+// @tags 前端 - 组织
+// @Summary 未使用的组织邀请, 需要管理员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Router /api/org/{id}/invite [get]
+// @Security ApiKeyAuth
+func GetOrgInvites(ctx *context.Context) {
+	invites, err := models.GetOrgInvites(ctx.ParamsID("orgID"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(invites)), "invites", invites)
+}
+
+// CreateOrgInvite 创建组织邀请
+// @tags 前端 - 组织
+// @Summary 创建组织邀请, 需要管理员, 返回邀请码
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param role formData string true "加入后的角色" Enums(admin, member)
+// @Router /api/org/{id}/invite [post]
+// @Security ApiKeyAuth
+func CreateOrgInvite(form st.OrgInviteForm, ctx *context.Context) {
+	orgID := ctx.ParamsID("orgID")
+	role := consts.OrgRole(form.Role)
+	operator, err := models.GetOrgRole(orgID, ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	// 不能邀请与自己同级别的角色, 所有者除外
+	if operator != consts.OrgOwner && role.Level() >= operator.Level() {
+		ctx.BadRequestByError(errors.ErrOrgMemberRole)
+		return
+	}
+	invite, err := models.CreateOrgInvite(orgID, ctx.UserID, role)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(invite)
+}
+
+// RevokeOrgInvite 撤销组织邀请
+// @tags 前端 - 组织
+// @Summary 撤销组织邀请, 需要管理员
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "组织编号"
+// @Param inviteID path string true "邀请编号"
+// @Router /api/org/{id}/invite/{inviteID}/revoke [post]
+// @Security ApiKeyAuth
+func RevokeOrgInvite(ctx *context.Context) {
+	if err := models.RevokeOrgInvite(ctx.ParamsID("orgID"), ctx.ParamsID("inviteID")); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// AcceptOrgInvite 接受组织邀请
+// @tags 前端 - 组织
+// @Summary 接受组织邀请
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param code formData string true "邀请码"
+// @Router /api/org/invite/accept [post]
+// @Security ApiKeyAuth
+func AcceptOrgInvite(ctx *context.Context) {
+	org, err := models.AcceptOrgInvite(ctx.UserID, ctx.QueryTrim("code"))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(org)
+}
@@ -2,38 +2,31 @@ package api
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/avatar"
+	"github.com/ihuanglei/authenticator/pkg/config"
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/storage"
 	"github.com/ihuanglei/authenticator/pkg/third"
 	"github.com/ihuanglei/authenticator/pkg/third/weixin"
 	"github.com/simplexwork/cache"
 	"github.com/simplexwork/common"
 )
 
-// UpdateAddress 更新地址
-// func UpdateAddress(ctx *context.Context) {
-// 	province := ctx.QueryTrim("province")
-// 	city := ctx.QueryTrim("city")
-// 	county := ctx.QueryTrim("county")
-// 	address := ctx.QueryTrim("address")
-// 	err := models.UpdateAddress(ctx.UserID, province, city, county, address)
-// 	if e, ok := err.(models.Err); ok {
-// 		ctx.BadRequestByCode(e.Code(), e.Error())
-// 		return
-// 	} else if err != nil {
-// 		ctx.Error(err)
-// 		return
-// 	}
-// 	ctx.JSONEmpty()
-// }
-
 // Info 用户信息
 // @tags 前端 - 用户信息
 // @Summary 用户信息
+// @Description 昵称和头像有待审核的修改时, pending 返回修改后的内容
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Router /api/profile [get]
@@ -73,29 +66,307 @@ func Info(ctx *context.Context) {
 	ret["county"] = userInfo.County
 	ret["weixin"] = userInfo.WeiXin
 	ret["qq"] = userInfo.QQ
+	if time.Time(user.DeleteTime).Year() > 1 {
+		ret["delete_time"] = user.DeleteTime
+	}
+	attributes, err := models.GetUserAttributes(ctx.UserID, consts.VisibilityUser, consts.VisibilityPublic)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ret["attributes"] = attributes
+	// 待审核的昵称和头像只返回给本人
+	pending, err := models.GetPendingModerations(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if len(pending) > 0 {
+		ret["pending"] = pending
+	}
+	ctx.JSON(ret)
+}
+
+// SetAttributes 设置自定义属性
+// @tags 前端 - 用户信息
+// @Summary 设置自定义属性, 参数名为属性键, 空值删除, 仅管理员可见的属性不可设置
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/profile/attribute [post]
+// @Security ApiKeyAuth
+func SetAttributes(ctx *context.Context) {
+	if err := models.SetUserAttributes(ctx.UserID, ctx.FormValues(), false); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// DeleteUser 注销账号
+// @tags 前端 - 用户信息
+// @Summary 注销账号(宽限期内登录即取消)
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult "注销时间"
+// @Param password formData string false "密码"
+// @Param code formData string false "手机验证码(/api/code/delete)"
+// @Router /api/profile/delete [post]
+// @Security ApiKeyAuth
+func DeleteUser(form st.DeleteUserForm, config *config.Config, cache cache.Cache, ctx *context.Context) {
+	user, err := models.GetUserByID(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	key := ""
+	if !common.IsEmpty(form.Password) {
+		if err := models.CheckPasswordForUser(ctx.UserID, form.Password); err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+	} else if !common.IsEmpty(form.Code) {
+		if user.Mobile == user.UserID.Str() {
+			ctx.BadRequestByError(errors.ErrUserMobileNotBind)
+			return
+		}
+		key = codeKey(codeKeyWithDelete, ctx.Tenant, user.Mobile)
+		tmpCode, err := cache.GetString(key)
+		if err != nil || tmpCode != form.Code {
+			ctx.BadRequestByError(errors.ErrCode)
+			return
+		}
+	} else {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	grace := config.Server.DeleteGrace
+	if grace <= 0 {
+		grace = consts.DeleteGrace
+	}
+	deleteTime, err := models.DeleteUser(ctx.UserID, time.Hour*24*time.Duration(grace))
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if key != "" {
+		cache.Del(key)
+	}
+	ctx.JSON(map[string]interface{}{"delete_time": deleteTime})
+}
+
+// CancelDeleteUser 取消注销账号
+// @tags 前端 - 用户信息
+// @Summary 取消注销账号
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/profile/delete/cancel [post]
+// @Security ApiKeyAuth
+func CancelDeleteUser(ctx *context.Context) {
+	if err := models.CancelDeleteForUser(ctx.UserID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// UpdateProfile 修改用户信息
+// @tags 前端 - 用户信息
+// @Summary 修改用户信息(只更新提交的字段, 昵称或头像变化时返回新令牌)
+// @Description 开启审核时昵称和头像进入审核队列, pending 返回待审核的字段
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param nickname formData string false "昵称"
+// @Param avatar formData string false "头像地址"
+// @Param gender formData string false "性别" Enums(male, female, unknown)
+// @Param qq formData string false "QQ"
+// @Param weixin formData string false "微信"
+// @Param province formData string false "省"
+// @Param city formData string false "市"
+// @Param county formData string false "区"
+// @Router /api/profile [patch]
+// @Security ApiKeyAuth
+func UpdateProfile(form st.UpdateProfileForm, ctx *context.Context) {
+	var fields []string
+	for _, field := range []string{"nickname", "avatar", "gender", "qq", "weixin", "province", "city", "county"} {
+		if _, ok := ctx.Req.Form[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	gender := consts.NewGender(form.Gender)
+	if _, ok := ctx.Req.Form["gender"]; ok && gender.Str() != form.Gender {
+		ctx.BadRequestByError(errors.ErrGender)
+		return
+	}
+	userInfoDto := &st.UserInfoDto{
+		Nickname: form.Nickname,
+		Avatar:   form.Avatar,
+		Gender:   gender,
+		QQ:       form.QQ,
+		WeiXin:   form.WeiXin,
+		Province: form.Province,
+		City:     form.City,
+		County:   form.County,
+	}
+	columns, pending, err := models.UpdateMy(ctx.UserID, userInfoDto, fields...)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ret := map[string]interface{}{"fields": columns}
+	if len(pending) > 0 {
+		ret["pending"] = pending
+	}
+	for _, column := range columns {
+		// 令牌中包含昵称和头像, 需要重新签发
+		if column == "nickname" || column == "avatar" {
+			token, err := getUserAndCreateJWTToken(ctx.UserID, ctx.Tenant, ctx.Secret, ctx.Expire)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			ret["token"] = fmt.Sprintf("Authenticator %v", string(token))
+			break
+		}
+	}
 	ctx.JSON(ret)
 }
 
 // UpdateAvatar 修改头像
 // @tags 前端 - 用户信息
 // @Summary 修改头像
+// @Description 开启审核时进入审核队列, 返回 pending 为 true
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Param avatar formData string false "头像地址"
 // @Router /api/profile/update/avatar [post]
 // @Security ApiKeyAuth
-func UpdateAvatar(ctx *context.Context) {
-	avatar := ctx.QueryTrim("avatar")
-	if err := models.UpdateAvatarForUser(ctx.UserID, avatar); err != nil {
+func UpdateAvatar(config *config.Config, store storage.Storage, ctx *context.Context) {
+	pending, err := updateAvatar(config, store, ctx.UserID, ctx.QueryTrim("avatar"))
+	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
+	if pending {
+		ctx.JSON(map[string]interface{}{"pending": true})
+		return
+	}
 	ctx.JSONEmpty()
 }
 
+// UploadAvatar 上传头像
+// @tags 前端 - 用户信息
+// @Summary 上传头像
+// @Description 支持jpg、png、gif格式, 居中裁剪为正方形并生成多个尺寸, 第一个尺寸的地址保存为头像, 开启审核时进入审核队列
+// @Accept multipart/form-data
+// @Success 200 {object} context.JSONResult
+// @Param avatar formData file true "头像文件"
+// @Router /api/profile/update/avatar/upload [post]
+// @Security ApiKeyAuth
+func UploadAvatar(config *config.Config, store storage.Storage, ctx *context.Context) {
+	maxSize := config.Avatar.MaxSize
+	if maxSize <= 0 {
+		maxSize = avatar.DefaultMaxSize
+	}
+	maxSize *= 1024
+	// 表单其他内容预留64K
+	if ctx.Req.ContentLength > maxSize+64<<10 {
+		ctx.BadRequestByError(errors.ErrAvatarSize)
+		return
+	}
+	ctx.Req.Request.Body = http.MaxBytesReader(ctx.Resp, ctx.Req.Request.Body, maxSize+64<<10)
+	file, _, err := ctx.Req.FormFile("avatar")
+	if err != nil {
+		ctx.BadRequestByError(errors.ErrArgument)
+		return
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		ctx.BadRequestByError(errors.ErrAvatarSize)
+		return
+	}
+	if int64(len(data)) > maxSize {
+		ctx.BadRequestByError(errors.ErrAvatarSize)
+		return
+	}
+	sizes := config.Avatar.Sizes
+	if len(sizes) == 0 {
+		sizes = avatar.DefaultSizes
+	}
+	images, err := avatar.Process(data, sizes)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if len(images) == 0 {
+		ctx.BadRequestByError(errors.ErrAvatarImage)
+		return
+	}
+	name := avatar.Name(ctx.UserID, time.Now())
+	urls := make(map[string]string, len(images))
+	for _, image := range images {
+		url, err := store.Put(avatar.Key(name, image.Size), image.Data, avatar.ContentType)
+		if err != nil {
+			// 删除已保存的其他尺寸
+			for _, image := range images {
+				if err := store.Delete(avatar.Key(name, image.Size)); err != nil {
+					logger.Error(err)
+				}
+			}
+			ctx.Error(err)
+			return
+		}
+		urls[strconv.Itoa(image.Size)] = url
+	}
+	url := urls[strconv.Itoa(images[0].Size)]
+	pending, err := updateAvatar(config, store, ctx.UserID, url)
+	if err != nil {
+		if err := avatar.Remove(store, ctx.UserID, url, sizes); err != nil {
+			logger.Error(err)
+		}
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(map[string]interface{}{"avatar": url, "sizes": urls, "pending": pending})
+}
+
+// 修改头像, 成功后删除被替换的头像和被撤回的待审核头像, 删除失败不影响修改
+func updateAvatar(config *config.Config, store storage.Storage, userID common.ID, url string) (bool, error) {
+	info, err := models.GetUserInfoByID(userID)
+	if err != nil {
+		return false, err
+	}
+	pendings, err := models.GetPendingModerations(userID)
+	if err != nil {
+		return false, err
+	}
+	pending, err := models.UpdateAvatarForUser(userID, url)
+	if err != nil {
+		return false, err
+	}
+	// 进入审核队列时当前头像仍在使用
+	superseded := []string{pendings[consts.ModerationFieldAvatar]}
+	if !pending {
+		superseded = append(superseded, info.Avatar)
+	}
+	for _, old := range superseded {
+		if old == "" || old == url {
+			continue
+		}
+		if err := avatar.Remove(store, userID, old, config.Avatar.Sizes); err != nil {
+			logger.Error(err)
+		}
+	}
+	return pending, nil
+}
+
 // UpdateNickname 修改昵称
 // @tags 前端 - 用户信息
 // @Summary 修改昵称
+// @Description 开启审核时进入审核队列, 返回 pending 为 true
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult
 // @Param nickname formData string false "昵称"
@@ -103,10 +374,15 @@ func UpdateAvatar(ctx *context.Context) {
 // @Security ApiKeyAuth
 func UpdateNickname(ctx *context.Context) {
 	nickname := ctx.QueryTrim("nickname")
-	if err := models.UpdateNicknameForUser(ctx.UserID, nickname); err != nil {
+	pending, err := models.UpdateNicknameForUser(ctx.UserID, nickname)
+	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
+	if pending {
+		ctx.JSON(map[string]interface{}{"pending": true})
+		return
+	}
 	ctx.JSONEmpty()
 }
 
@@ -164,7 +440,7 @@ func UpdatePasswordWithCode(form st.UpdatePasswordWithCodeForm, ctx *context.Con
 		ctx.BadRequestByError(err)
 		return
 	}
-	key := fmt.Sprintf(codeKeyWithChangePassword, user.Mobile)
+	key := codeKey(codeKeyWithChangePassword, ctx.Tenant, user.Mobile)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
@@ -189,13 +465,13 @@ func UpdatePasswordWithCode(form st.UpdatePasswordWithCodeForm, ctx *context.Con
 // @Router /api/profile/update/bind/mobile [post]
 // @Security ApiKeyAuth
 func UpdateMobileWithCode(form st.UpdateMobileWithCodeForm, ctx *context.Context, cache cache.Cache) {
-	key := fmt.Sprintf(codeKeyWithBindMobile, form.Mobile)
+	key := codeKey(codeKeyWithBindMobile, ctx.Tenant, form.Mobile)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
 		return
 	}
-	has, err := models.HasUserByMobile(form.Mobile)
+	has, err := models.HasUserByMobile(ctx.Tenant, form.Mobile)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -237,7 +513,7 @@ func UpdateMobileWithWeiXinMP(form st.WeiXinMPForm, ctx *context.Context, cache
 		return
 	}
 	id := ctx.Params("id")
-	mp, err := getThird(id)
+	mp, err := getThird(ctx.Tenant, id)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -270,13 +546,13 @@ func UpdateMobileWithWeiXinMP(form st.WeiXinMPForm, ctx *context.Context, cache
 // @Router /api/profile/update/bind/email [post]
 // @Security ApiKeyAuth
 func UpdateEmailWidthCode(form st.UpdateEmailWithCodeForm, ctx *context.Context, cache cache.Cache) {
-	key := fmt.Sprintf(codeKeyWithBindEmail, form.Email)
+	key := codeKey(codeKeyWithBindEmail, ctx.Tenant, form.Email)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
 		return
 	}
-	has, err := models.HasUserByEmail(form.Email)
+	has, err := models.HasUserByEmail(ctx.Tenant, form.Email)
 	if err != nil {
 		ctx.Error(err)
 		return
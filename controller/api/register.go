@@ -3,11 +3,16 @@ package api
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/casbin/casbin/v2"
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
 	"github.com/ihuanglei/authenticator/pkg/convert"
 	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/ihuanglei/authenticator/pkg/third"
 	"github.com/ihuanglei/authenticator/pkg/third/weixin"
@@ -15,14 +20,24 @@ import (
 	"github.com/simplexwork/common"
 )
 
+// 微信小程序注册方式, 用于注册开关
+const modeWeiXinMP = "weixinmp"
+
 // RegisterWithThirdCode 第三方QQ，微信，微博使用code注册
 // @tags 前端 - 用户注册
 // @Summary 第三方QQ，微信，微博使用code注册
 // @Accept x-www-form-urlencoded
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param code formData string false "第三方授权后返回的code"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/third [post]
-func RegisterWithThirdCode(form st.RegisterWithThirdForm, cache cache.Cache, ctx *context.Context) {
+func RegisterWithThirdCode(form st.RegisterWithThirdForm, cache cache.Cache, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, consts.Third.Str(), form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	key := fmt.Sprintf(tokenThirdKey, form.Code)
 	data, err := cache.Get(key)
 	if err != nil {
@@ -35,12 +50,16 @@ func RegisterWithThirdCode(form st.RegisterWithThirdForm, cache cache.Cache, ctx
 		return
 	}
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.InviteCode = form.InviteCode
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, err := models.CreateUserWithThird(&registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	token, err := getUserAndCreateJWTToken(userID, ctx.Secret, ctx.Expire)
+	grantInviteRole(e, invite, userID)
+	token, err := getUserAndCreateJWTToken(userID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -58,8 +77,15 @@ func RegisterWithThirdCode(form st.RegisterWithThirdForm, cache cache.Cache, ctx
 // @Param key formData string false "调用微信小程序登录接口(/login/th/weixinmp/{id})返回的内容"
 // @Param encrypted_data formData string false "微信小程序通过wx.getUserInfo获取的encryptedData"
 // @Param iv formData string false "微信小程序通过wx.getUserInfo获取的iv"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/weixinmp/userinfo/{id} [post]
-func RegisterWithWeiXinMP(form st.WeiXinMPForm, cache cache.Cache, ctx *context.Context) {
+func RegisterWithWeiXinMP(form st.WeiXinMPForm, cache cache.Cache, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, modeWeiXinMP, form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	key := fmt.Sprintf(sessionKeyWithWeiXinMP, form.WeiXinMPKey)
 	data, err := cache.Get(key)
 	if err != nil {
@@ -72,7 +98,7 @@ func RegisterWithWeiXinMP(form st.WeiXinMPForm, cache cache.Cache, ctx *context.
 		return
 	}
 	id := ctx.Params("id")
-	mp, err := getThird(id)
+	mp, err := getThird(ctx.Tenant, id)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -96,12 +122,16 @@ func RegisterWithWeiXinMP(form st.WeiXinMPForm, cache cache.Cache, ctx *context.
 	registerDto.TP = thirdUser.TP
 	registerDto.OpenID = thirdUser.OpenID
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.InviteCode = form.InviteCode
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, err := models.CreateUserWithThird(&registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	token, err := getUserAndCreateJWTToken(userID, ctx.Secret, ctx.Expire)
+	grantInviteRole(e, invite, userID)
+	token, err := getUserAndCreateJWTToken(userID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -119,8 +149,15 @@ func RegisterWithWeiXinMP(form st.WeiXinMPForm, cache cache.Cache, ctx *context.
 // @Param key formData string false "调用微信小程序登录接口(/login/th/weixinmp/{id})返回的内容"
 // @Param encrypted_data formData string false "微信小程序通过getPhoneNumber获取的encryptedData"
 // @Param iv formData string false "微信小程序通过getPhoneNumber获取的encryptedData"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/weixinmp/mobile/{id} [post]
-func RegisterWithWeiXinMPPhone(form st.WeiXinMPForm, cache cache.Cache, ctx *context.Context) {
+func RegisterWithWeiXinMPPhone(form st.WeiXinMPForm, cache cache.Cache, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, modeWeiXinMP, form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	key := fmt.Sprintf(sessionKeyWithWeiXinMP, form.WeiXinMPKey)
 	data, err := cache.Get(key)
 	if err != nil {
@@ -133,7 +170,7 @@ func RegisterWithWeiXinMPPhone(form st.WeiXinMPForm, cache cache.Cache, ctx *con
 		return
 	}
 	id := ctx.Params("id")
-	mp, err := getThird(id)
+	mp, err := getThird(ctx.Tenant, id)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -153,12 +190,16 @@ func RegisterWithWeiXinMPPhone(form st.WeiXinMPForm, cache cache.Cache, ctx *con
 	registerDto.OpenID = thirdUser.OpenID
 	registerDto.Mobile = mobile
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.InviteCode = form.InviteCode
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, err := models.CreateUserWithThird(&registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	token, err := getUserAndCreateJWTToken(userID, ctx.Secret, ctx.Expire)
+	grantInviteRole(e, invite, userID)
+	token, err := getUserAndCreateJWTToken(userID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -174,20 +215,30 @@ func RegisterWithWeiXinMPPhone(form st.WeiXinMPForm, cache cache.Cache, ctx *con
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param login_name formData string false "用户名"
 // @Param password formData string false "密码"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/name [post]
-func RegisterWithNameAndPassword(form st.RegisterNameForm, ctx *context.Context) {
+func RegisterWithNameAndPassword(form st.RegisterNameForm, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, consts.Name.Str(), form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	registerDto, err := registerForm2Dto(&form)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, err := models.CreateUserWithName(registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	token, err := getUserAndCreateJWTToken(userID, ctx.Secret, ctx.Expire)
+	grantInviteRole(e, invite, userID)
+	token, err := getUserAndCreateJWTToken(userID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -202,20 +253,30 @@ func RegisterWithNameAndPassword(form st.RegisterNameForm, ctx *context.Context)
 // @Success 200 {object} context.JSONResult{data=string} "令牌"
 // @Param email formData string false "邮箱"
 // @Param password formData string false "密码"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/email [post]
-func RegisterWithEmailAndPassword(form st.RegisterEmailForm, ctx *context.Context) {
+func RegisterWithEmailAndPassword(form st.RegisterEmailForm, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, consts.Email.Str(), form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
 	registerDto, err := registerForm2Dto(&form)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, activateCode, err := models.CreateUserWithEmail(registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	go sendActivateMessageWithEmail(userID, activateCode, registerDto.Email)
+	grantInviteRole(e, invite, userID)
+	go sendActivateMessageWithEmail(ctx.Tenant, userID, activateCode, registerDto.Email)
 	ctx.JSONEmpty()
 }
 
@@ -227,9 +288,16 @@ func RegisterWithEmailAndPassword(form st.RegisterEmailForm, ctx *context.Contex
 // @Param mobile formData string false "手机号"
 // @Param password formData string false "密码"
 // @Param code formData string false "验证码"
+// @Param invite_code formData string false "邀请码, 开启邀请注册时必填"
+// @Param legal_ids formData string false "注册页展示的协议编号, 逗号分隔, 租户发布了协议时必须包含全部当前生效的协议"
 // @Router /api/reg/mobile [post]
-func RegisterWithMobileAndPassword(form st.RegisterMobileForm, cache cache.Cache, ctx *context.Context) {
-	key := fmt.Sprintf(codeKeyWithReg, form.Mobile)
+func RegisterWithMobileAndPassword(form st.RegisterMobileForm, cache cache.Cache, config *config.Config, e *casbin.Enforcer, ctx *context.Context) {
+	invite, err := checkRegister(config, ctx.Tenant, consts.Mobile.Str(), form.InviteCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	key := codeKey(codeKeyWithReg, ctx.Tenant, form.Mobile)
 	tmpCode, err := cache.GetString(key)
 	if err != nil || tmpCode != form.Code {
 		ctx.BadRequestByError(errors.ErrCode)
@@ -241,12 +309,15 @@ func RegisterWithMobileAndPassword(form st.RegisterMobileForm, cache cache.Cache
 		return
 	}
 	registerDto.IP = ctx.IP
+	registerDto.Tenant = ctx.Tenant
+	registerDto.LegalIDs = parseLegalIDs(form.LegalIDs)
 	userID, err := models.CreateUserWithMobile(registerDto)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
 	}
-	token, err := getUserAndCreateJWTToken(userID, ctx.Secret, ctx.Expire)
+	grantInviteRole(e, invite, userID)
+	token, err := getUserAndCreateJWTToken(userID, ctx.Tenant, ctx.Secret, ctx.Expire)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -289,7 +360,7 @@ func ActivateUser(form st.ActivateUserForm, ctx *context.Context) {
 // @Param email formData string false "邮箱"
 // @Router /api/reg/activate/resend [post]
 func ReSendActivateCode(form st.EmailForm, ctx *context.Context) {
-	user, err := models.GetUserByEmail(form.Email)
+	user, err := models.GetUserByEmail(ctx.Tenant, form.Email)
 	if err != nil {
 		ctx.BadRequestByError(err)
 		return
@@ -299,7 +370,7 @@ func ReSendActivateCode(form st.EmailForm, ctx *context.Context) {
 		ctx.BadRequestByError(err)
 		return
 	}
-	go sendActivateMessageWithEmail(user.UserID, activateCode, form.Email)
+	go sendActivateMessageWithEmail(ctx.Tenant, user.UserID, activateCode, form.Email)
 	ctx.JSONEmpty()
 }
 
@@ -310,3 +381,32 @@ func registerForm2Dto(form interface{}) (*st.RegisterDto, error) {
 	}
 	return registerDto, nil
 }
+
+// 检查注册开关, 开启邀请注册时必须填写邀请码, 返回可用的邀请码, 未填写时返回nil
+// 邀请码在创建用户时再次校验并记录使用
+func checkRegister(config *config.Config, tenant, mode, code string) (*st.InviteDto, error) {
+	if err := models.CheckRegister(tenant, mode); err != nil {
+		return nil, err
+	}
+	if common.Trim(code) == "" {
+		if config.Register.Invite {
+			return nil, errors.ErrInviteRequired
+		}
+		return nil, nil
+	}
+	return models.CheckInvite(tenant, code)
+}
+
+// 邀请码指定了角色时, 注册成功后授予该角色, 失败不影响注册
+func grantInviteRole(e *casbin.Enforcer, invite *st.InviteDto, userID common.ID) {
+	if invite == nil || invite.RoleID == 0 {
+		return
+	}
+	if _, err := e.AddRoleForUser(userID.Str(), invite.RoleID.Str()); err != nil {
+		logger.Error(err)
+		return
+	}
+	if err := models.SaveRoleGrants("", userID, []string{invite.RoleID.Str()}, nil, nil, time.Time{}, 0); err != nil {
+		logger.Error(err)
+	}
+}
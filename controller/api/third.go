@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/third/weixin"
+)
+
+// GetThirds 已绑定的第三方账号
+// @tags 前端 - 第三方绑定
+// @Summary 已绑定的第三方账号
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Router /api/profile/third [get]
+// @Security ApiKeyAuth
+func GetThirds(ctx *context.Context) {
+	thirds, err := models.GetThirdsForUser(ctx.UserID)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONList(int64(len(thirds)), "thirds", thirds)
+}
+
+// BindURLForThird 绑定第三方地址
+// @tags 前端 - 第三方绑定
+// @Summary 第三方QQ，微信，微博，Github绑定地址
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult{data=string} 第三方请求地址
+// @Param id path string true "第三方编号"
+// @Param state query string false "第三方授权后返回的state"
+// @Router /api/profile/third/{id} [get]
+// @Security ApiKeyAuth
+func BindURLForThird(form st.LoginWithThirdForm, ctx *context.Context) {
+	id := ctx.Params("id")
+	url, err := getAuthorizeURL(ctx.Tenant, id, form.State)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSON(url)
+}
+
+// BindThird 使用code绑定第三方
+// @tags 前端 - 第三方绑定
+// @Summary 第三方QQ，微信，微博，Github使用code绑定
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "第三方编号"
+// @Param code formData string false "第三方授权后返回的code"
+// @Router /api/profile/third/{id} [post]
+// @Security ApiKeyAuth
+func BindThird(form st.BindThirdForm, ctx *context.Context) {
+	id := ctx.Params("id")
+	thirdUser, err := getThirdUser(ctx.Tenant, id, form.Code)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if err := models.BindThirdForUser(ctx.UserID, thirdUser.TP, thirdUser.OpenID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// BindWeiXinMP 绑定微信小程序
+// @tags 前端 - 第三方绑定
+// @Summary 绑定微信小程序
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "第三方编号"
+// @Param code formData string false "微信小程序通过wx.login获取的临时登录凭证"
+// @Router /api/profile/third/weixinmp/{id} [post]
+// @Security ApiKeyAuth
+func BindWeiXinMP(form st.BindWeiXinMPForm, ctx *context.Context) {
+	id := ctx.Params("id")
+	mp, err := getThird(ctx.Tenant, id)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	v, ok := mp.(*weixin.MinPro)
+	if !ok {
+		ctx.BadRequestByError(errors.ErrDictNotFound)
+		return
+	}
+	openID, _, err := v.GetSession(form.WeiXinMPCode)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if err := models.BindThirdForUser(ctx.UserID, mp.GetType(), openID); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
+
+// UnbindThird 解绑第三方
+// @tags 前端 - 第三方绑定
+// @Summary 解绑第三方(不能解绑唯一的登录方式)
+// @Accept x-www-form-urlencoded
+// @Success 200 {object} context.JSONResult
+// @Param id path string true "第三方编号"
+// @Router /api/profile/third/{id}/unbind [post]
+// @Security ApiKeyAuth
+func UnbindThird(ctx *context.Context) {
+	id := ctx.Params("id")
+	third, err := getThird(ctx.Tenant, id)
+	if err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	if err := models.UnbindThirdForUser(ctx.UserID, third.GetType()); err != nil {
+		ctx.BadRequestByError(err)
+		return
+	}
+	ctx.JSONEmpty()
+}
@@ -24,3 +24,5 @@ package controller
 // @securityDefinitions.apikey AdminKeyAuth
 // @in header
 // @name X-AACMS-Authorization
+
+// @securityDefinitions.basic ServiceAuth
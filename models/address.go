@@ -1,55 +1,148 @@
 package models
 
 import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/simplexwork/common"
+	"xorm.io/builder"
 )
 
-// CreateAddress 新增地址
-func CreateAddress(userID common.ID, addressDto st.AddressDto) error {
-	address := new(userAddress)
-	err := convert.Map(addressDto, address)
+const (
+	// 默认地址
+	addressDefault = "1"
+	// 非默认地址
+	addressNotDefault = "-1"
+)
+
+// GetAddresses 获取用户地址列表
+func GetAddresses(userID common.ID) (int64, []*st.AddressDto, error) {
+	cond := builder.Eq{"user_id": userID, "status": consts.Normal}
+	count, addresses, err := getAddresses(cond, 1, consts.AddressLimit)
 	if err != nil {
-		return err
+		return 0, nil, err
+	}
+	var addressDtos = make([]*st.AddressDto, len(addresses))
+	if err := convert.Map(&addresses, &addressDtos); err != nil {
+		return 0, nil, err
+	}
+	return count, addressDtos, nil
+}
+
+// CreateAddress 新增地址, 第一个地址自动设为默认
+func CreateAddress(userID common.ID, addressDto *st.AddressDto) (common.ID, error) {
+	if err := checkAddress(addressDto); err != nil {
+		return 0, err
+	}
+	if _, err := getUserByID(userID); err != nil {
+		return 0, err
+	}
+	count, err := getAddressCount(builder.Eq{"user_id": userID, "status": consts.Normal})
+	if err != nil {
+		return 0, err
+	}
+	if count >= consts.AddressLimit {
+		return 0, errors.ErrAddressLimit
+	}
+	address := new(userAddress)
+	if err := convert.Map(addressDto, address); err != nil {
+		return 0, err
 	}
 	address.UserID = userID
-	return createAddress(address)
+	if count == 0 {
+		address.Def = addressDefault
+	} else if address.Def != addressDefault {
+		address.Def = addressNotDefault
+	}
+	if err := createAddress(address); err != nil {
+		return 0, err
+	}
+	return address.AddressID, nil
 }
 
 // UpdateAddress 更新地址
-func UpdateAddress(userID common.ID, addressID common.ID, addressDto st.AddressDto) error {
-	_, err := getUserByID(userID)
-	if err != nil {
+func UpdateAddress(userID common.ID, addressID common.ID, addressDto *st.AddressDto) error {
+	if err := checkAddress(addressDto); err != nil {
 		return err
 	}
-	oAddress, err := getAddressByID(addressID)
-	if err != nil {
+	if _, err := getAddressForUser(userID, addressID); err != nil {
 		return err
 	}
-	if oAddress.UserID != userID {
-		return nil
-	}
 	address := new(userAddress)
-	err = convert.Map(addressDto, address)
-	if err != nil {
+	if err := convert.Map(addressDto, address); err != nil {
+		return err
+	}
+	if err := updateAddress(addressID, address); err != nil {
+		return err
+	}
+	if address.Def == addressDefault {
+		return setDefaultAddress(userID, addressID)
+	}
+	return nil
+}
+
+// SetDefaultAddress 设为默认地址
+func SetDefaultAddress(userID common.ID, addressID common.ID) error {
+	if _, err := getAddressForUser(userID, addressID); err != nil {
 		return err
 	}
-	return updateAddress(addressID, address)
+	return setDefaultAddress(userID, addressID)
 }
 
-// DelAddress 删除地址
+// DelAddress 删除地址, 删除默认地址时最近的地址成为默认
 func DelAddress(userID common.ID, addressID common.ID) error {
-	_, err := getUserByID(userID)
+	address, err := getAddressForUser(userID, addressID)
 	if err != nil {
 		return err
 	}
-	oAddress, err := getAddressByID(addressID)
+	return delAddress(userID, addressID, address.Def == addressDefault)
+}
+
+func getAddressForUser(userID common.ID, addressID common.ID) (*userAddress, error) {
+	if _, err := getUserByID(userID); err != nil {
+		return nil, err
+	}
+	address, err := getAddressByID(addressID)
 	if err != nil {
+		return nil, err
+	}
+	if address.UserID != userID {
+		return nil, errors.ErrAddressNotFound
+	}
+	return address, nil
+}
+
+func checkAddress(addressDto *st.AddressDto) error {
+	if addressDto == nil {
+		return errors.ErrArgument
+	}
+	addressDto.Name = common.Trim(addressDto.Name)
+	addressDto.Address = common.Trim(addressDto.Address)
+	if common.IsEmpty(addressDto.Name) || utf8.RuneCountInString(addressDto.Name) > 30 {
+		return errors.ErrAddressName
+	}
+	if err := checkWords(consts.WordFieldAddress, addressDto.Name); err != nil {
 		return err
 	}
-	if oAddress.UserID != userID {
-		return nil
+	if !common.IsMobile(addressDto.Mobile) {
+		return errors.ErrMobile
+	}
+	if common.IsEmpty(addressDto.Address) || utf8.RuneCountInString(addressDto.Address) > 255 {
+		return errors.ErrAddressDetail
+	}
+	for _, v := range []string{addressDto.Province, addressDto.City, addressDto.County} {
+		if utf8.RuneCountInString(v) > 30 {
+			return errors.ErrAddressDetail
+		}
+	}
+	if addressDto.Zip != "" {
+		if b, err := regexp.MatchString(`^\d{6}$`, addressDto.Zip); !b || err != nil {
+			return errors.ErrZip
+		}
 	}
-	return delAddress(addressID)
+	return nil
 }
@@ -16,17 +16,23 @@ func getAddresses(cond builder.Cond, page, limit int) (int64, []*userAddress, er
 	}
 	start := (page - 1) * limit
 	var addresses = make([]*userAddress, 0)
-	count, err := _Engine.Desc("create_time").Where(cond).Limit(limit, start).FindAndCount(&addresses)
+	count, err := _Engine.Desc("def", "create_time").Where(cond).Limit(limit, start).FindAndCount(&addresses)
 	if err != nil {
 		return 0, nil, err
 	}
 	return count, addresses, nil
 }
 
+// 地址数量
+func getAddressCount(cond builder.Cond) (int64, error) {
+	address := new(userAddress)
+	return _Engine.Where(cond).Count(address)
+}
+
 // 根据地址获取
 func getAddressByID(addressID common.ID) (*userAddress, error) {
 	address := new(userAddress)
-	has, err := _Engine.Where("address_id = ?", addressID).Get(address)
+	has, err := _Engine.Where("address_id = ? AND status = ?", addressID, consts.Normal).Get(address)
 	if err != nil {
 		return nil, err
 	} else if !has {
@@ -50,6 +56,12 @@ func createAddress(address *userAddress) error {
 	if err := session.Begin(); err != nil {
 		return err
 	}
+	if address.Def == addressDefault {
+		other := &userAddress{Def: addressNotDefault}
+		if _, err := session.Cols("def").Where("user_id = ?", address.UserID).Update(other); err != nil {
+			return err
+		}
+	}
 	_, err = session.Insert(address)
 	if err != nil {
 		return err
@@ -72,17 +84,48 @@ func updateAddress(addressID common.ID, address *userAddress) error {
 	return session.Commit()
 }
 
+// 设置默认地址
+func setDefaultAddress(userID common.ID, addressID common.ID) error {
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	other := &userAddress{Def: addressNotDefault}
+	if _, err := session.Cols("def").Where("user_id = ? AND address_id <> ?", userID, addressID).Update(other); err != nil {
+		return err
+	}
+	address := &userAddress{Def: addressDefault, UpdateTime: common.Now()}
+	if _, err := session.Cols("def", "update_time").Where("address_id = ?", addressID).Update(address); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
 // 删除地址
-func delAddress(addressID common.ID) error {
-	address := &userAddress{Status: consts.Delete, UpdateTime: common.Now()}
+func delAddress(userID common.ID, addressID common.ID, def bool) error {
+	address := &userAddress{Status: consts.Delete, Def: addressNotDefault, UpdateTime: common.Now()}
 	session := _Engine.NewSession()
 	defer session.Close()
 	if err := session.Begin(); err != nil {
 		return err
 	}
-	_, err := session.Cols("status", "update_time").Where("address_id = ?", addressID).Update(address)
+	_, err := session.Cols("status", "def", "update_time").Where("address_id = ?", addressID).Update(address)
 	if err != nil {
 		return err
 	}
+	if def {
+		latest := new(userAddress)
+		has, err := session.Where("user_id = ? AND status = ?", userID, consts.Normal).Desc("create_time").Get(latest)
+		if err != nil {
+			return err
+		}
+		if has {
+			latest.Def = addressDefault
+			if _, err := session.Cols("def").Where("address_id = ?", latest.AddressID).Update(latest); err != nil {
+				return err
+			}
+		}
+	}
 	return session.Commit()
 }
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// CreateAttribute 创建自定义属性
+func CreateAttribute(attributeDto *st.AttributeDto) (common.ID, error) {
+	if err := checkAttribute(attributeDto); err != nil {
+		return 0, err
+	}
+	count, err := getAttributeCount(builder.Eq{"attr_key": attributeDto.Key, "status": consts.Normal})
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, errors.ErrAttributeExist
+	}
+	attribute := new(attribute)
+	if err := convert.Map(attributeDto, attribute); err != nil {
+		return 0, err
+	}
+	if err := createAttribute(attribute); err != nil {
+		return 0, err
+	}
+	return attribute.AttrID, nil
+}
+
+// UpdateAttribute 更新自定义属性, 属性键不允许修改
+func UpdateAttribute(attrID common.ID, attributeDto *st.AttributeDto) error {
+	old, err := getAttributeByID(attrID)
+	if err != nil {
+		return err
+	}
+	attributeDto.Key = old.Key
+	if err := checkAttribute(attributeDto); err != nil {
+		return err
+	}
+	attribute := new(attribute)
+	if err := convert.Map(attributeDto, attribute); err != nil {
+		return err
+	}
+	return updateAttribute(attrID, attribute)
+}
+
+// DelAttribute 删除自定义属性及用户的属性值
+func DelAttribute(attrID common.ID) error {
+	attribute, err := getAttributeByID(attrID)
+	if err != nil {
+		return err
+	}
+	return deleteAttribute(attrID, attribute.Key)
+}
+
+// GetAttributes 自定义属性列表
+func GetAttributes() ([]*st.AttributeDto, error) {
+	attributes, err := getAttributes(builder.Eq{"status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	var attributeDtos = make([]*st.AttributeDto, len(attributes))
+	if err := convert.Map(&attributes, &attributeDtos); err != nil {
+		return nil, err
+	}
+	return attributeDtos, nil
+}
+
+// GetUserAttributes 获取用户自定义属性值, visibilities为空时返回全部
+func GetUserAttributes(userID common.ID, visibilities ...consts.Visibility) (map[string]string, error) {
+	cond := builder.Eq{"status": consts.Normal}
+	if len(visibilities) > 0 {
+		return getUserAttributeValues(userID, cond.And(builder.In("visibility", visibilities)))
+	}
+	return getUserAttributeValues(userID, cond)
+}
+
+// GetUserClaims 获取需要放入令牌的用户自定义属性值, 令牌对用户可见, 不包含仅管理员可见的属性
+func GetUserClaims(userID common.ID) (map[string]string, error) {
+	return getUserAttributeValues(userID, builder.Eq{"status": consts.Normal, "claim": true}.
+		And(builder.Neq{"visibility": consts.VisibilityAdmin}))
+}
+
+// SetUserAttributes 设置用户自定义属性值, 空值表示删除, admin为false时只能设置用户可编辑的属性
+func SetUserAttributes(userID common.ID, values map[string]string, admin bool) error {
+	if _, err := getUserByID(userID); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return errors.ErrArgument
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	attributes, err := getAttributes(builder.Eq{"status": consts.Normal}.And(builder.In("attr_key", keys)))
+	if err != nil {
+		return err
+	}
+	if len(attributes) != len(keys) {
+		return errors.ErrAttributeNotFound
+	}
+	for _, attribute := range attributes {
+		if !admin && attribute.Visibility == consts.VisibilityAdmin {
+			return errors.ErrAttributeReadOnly
+		}
+		value := common.Trim(values[attribute.Key])
+		if value != "" {
+			if err := attribute.check(value); err != nil {
+				return err
+			}
+		}
+		values[attribute.Key] = value
+	}
+	return saveUserAttributes(userID, values)
+}
+
+func getUserAttributeValues(userID common.ID, cond builder.Cond) (map[string]string, error) {
+	attributes, err := getAttributes(cond)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if len(attributes) == 0 {
+		return values, nil
+	}
+	keys := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		keys[i] = attribute.Key
+	}
+	userAttributes, err := getUserAttributes(builder.Eq{"user_id": userID}.And(builder.In("attr_key", keys)))
+	if err != nil {
+		return nil, err
+	}
+	for _, userAttribute := range userAttributes {
+		values[userAttribute.Key] = userAttribute.Value
+	}
+	return values, nil
+}
+
+func checkAttribute(attributeDto *st.AttributeDto) error {
+	if b, err := regexp.MatchString(`^[a-z][a-z0-9_]{1,29}$`, attributeDto.Key); !b || err != nil {
+		return errors.ErrAttributeKey
+	}
+	if common.IsEmpty(attributeDto.Name) || utf8.RuneCountInString(attributeDto.Name) > 30 {
+		return errors.ErrArgument
+	}
+	if !attributeDto.Type.Valid() || !attributeDto.Visibility.Valid() {
+		return errors.ErrAttributeType
+	}
+	if attributeDto.Claim && attributeDto.Visibility == consts.VisibilityAdmin {
+		return errors.ErrAttributeClaim
+	}
+	switch attributeDto.Type {
+	case consts.AttrString:
+		if _, err := regexp.Compile(attributeDto.Rule); err != nil {
+			return errors.ErrAttributeType
+		}
+	case consts.AttrEnum:
+		if common.IsEmpty(attributeDto.Rule) {
+			return errors.ErrAttributeType
+		}
+	}
+	return nil
+}
+
+// 校验属性值
+func (a *attribute) check(value string) error {
+	if utf8.RuneCountInString(value) > 1024 {
+		return errors.ErrAttributeValue
+	}
+	var err error
+	switch a.Type {
+	case consts.AttrString:
+		if a.Rule != "" {
+			var b bool
+			if b, err = regexp.MatchString(a.Rule, value); err == nil && !b {
+				err = errors.ErrAttributeValue
+			}
+		}
+	case consts.AttrNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case consts.AttrBool:
+		_, err = strconv.ParseBool(value)
+	case consts.AttrDate:
+		_, err = time.Parse("2006-01-02", value)
+	case consts.AttrEnum:
+		err = errors.ErrAttributeValue
+		for _, option := range strings.Split(a.Rule, ",") {
+			if common.Trim(option) == value {
+				err = nil
+				break
+			}
+		}
+	}
+	if err != nil {
+		return errors.ErrAttributeValue
+	}
+	return nil
+}
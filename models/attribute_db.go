@@ -0,0 +1,119 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 新增自定义属性
+func createAttribute(attribute *attribute) error {
+	attrID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	attribute.AttrID = attrID
+	attribute.Status = consts.Normal
+	attribute.CreateTime = common.Now()
+	attribute.UpdateTime = attribute.CreateTime
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(attribute); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 更新自定义属性
+func updateAttribute(attrID common.ID, attribute *attribute) error {
+	attribute.UpdateTime = common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("name", "type", "rule", "visibility", "claim", "update_time").Where("attr_id = ?", attrID).Update(attribute); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 删除自定义属性
+func deleteAttribute(attrID common.ID, key string) error {
+	attribute := &attribute{Status: consts.Delete, UpdateTime: common.Now()}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("status", "update_time").Where("attr_id = ?", attrID).Update(attribute); err != nil {
+		return err
+	}
+	if _, err := session.Where("attr_key = ?", key).Delete(new(userAttribute)); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 根据编号获取自定义属性
+func getAttributeByID(attrID common.ID) (*attribute, error) {
+	attribute := new(attribute)
+	has, err := _Engine.Where("attr_id = ? AND status = ?", attrID, consts.Normal).Get(attribute)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.ErrAttributeNotFound
+	}
+	return attribute, nil
+}
+
+// 自定义属性数量
+func getAttributeCount(cond builder.Cond) (int64, error) {
+	attribute := new(attribute)
+	return _Engine.Where(cond).Count(attribute)
+}
+
+// 自定义属性列表
+func getAttributes(cond builder.Cond) ([]*attribute, error) {
+	var attributes = make([]*attribute, 0)
+	if err := _Engine.Asc("create_time").Where(cond).Find(&attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// 用户自定义属性值
+func getUserAttributes(cond builder.Cond) ([]*userAttribute, error) {
+	var userAttributes = make([]*userAttribute, 0)
+	if err := _Engine.Where(cond).Find(&userAttributes); err != nil {
+		return nil, err
+	}
+	return userAttributes, nil
+}
+
+// 保存用户自定义属性值, 空值删除
+func saveUserAttributes(userID common.ID, values map[string]string) error {
+	now := common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	for key, value := range values {
+		if _, err := session.Where("user_id = ? AND attr_key = ?", userID, key).Delete(new(userAttribute)); err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		userAttribute := &userAttribute{UserID: userID, Key: key, Value: value, UpdateTime: now}
+		if _, err := session.Insert(userAttribute); err != nil {
+			return err
+		}
+	}
+	return session.Commit()
+}
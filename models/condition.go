@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// SavePolicyCondition 保存角色资源的条件, 条件都为空时移除, 资源必须已分配给角色
+func SavePolicyCondition(condDto *st.PolicyConditionDto) error {
+	count, _, err := getRoleResources(builder.Eq{"role_id": condDto.RoleID, "url": condDto.URL, "method": condDto.Method})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.ErrCondResource
+	}
+	if condDto.Hours == "" && condDto.Weekdays == "" && condDto.IPRanges == "" && !condDto.Owner {
+		return deletePolicyCondition(condDto.RoleID, condDto.URL, condDto.Method)
+	}
+	cond := &policyCondition{
+		RoleID:   condDto.RoleID,
+		Domain:   condDto.Domain,
+		URL:      condDto.URL,
+		Method:   condDto.Method,
+		Hours:    condDto.Hours,
+		Weekdays: condDto.Weekdays,
+		IPRanges: condDto.IPRanges,
+		Owner:    condDto.Owner,
+	}
+	return savePolicyCondition(cond)
+}
+
+// GetPolicyConditions 全部策略条件
+func GetPolicyConditions() ([]*st.PolicyConditionDto, error) {
+	return toPolicyConditionDtos(getPolicyConditions(builder.NewCond()))
+}
+
+// GetRoleConditions 角色资源的条件
+func GetRoleConditions(roleID common.ID) ([]*st.PolicyConditionDto, error) {
+	return toPolicyConditionDtos(getPolicyConditions(builder.Eq{"role_id": roleID}))
+}
+
+func toPolicyConditionDtos(conds []*policyCondition, err error) ([]*st.PolicyConditionDto, error) {
+	if err != nil {
+		return nil, err
+	}
+	var condDtos = make([]*st.PolicyConditionDto, len(conds))
+	if err := convert.Map(&conds, &condDtos); err != nil {
+		return nil, err
+	}
+	return condDtos, nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 新增或更新策略条件
+func savePolicyCondition(cond *policyCondition) error {
+	now := common.Now()
+	cond.UpdateTime = now
+	affected, err := _Engine.Cols("domain", "hours", "weekdays", "ip_ranges", "owner", "update_time").
+		Where("role_id = ? AND url = ? AND method = ?", cond.RoleID, cond.URL, cond.Method).Update(cond)
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	cond.CreateTime = now
+	_, err = _Engine.Insert(cond)
+	return err
+}
+
+// 删除策略条件
+func deletePolicyCondition(roleID common.ID, url, method string) error {
+	_, err := _Engine.Where("role_id = ? AND url = ? AND method = ?", roleID, url, method).Delete(new(policyCondition))
+	return err
+}
+
+// 移除角色已没有的资源上的条件
+func prunePolicyConditions(session *xorm.Session, roleID common.ID, roleRess []*roleResource) error {
+	var conds = make([]*policyCondition, 0)
+	if err := session.Where("role_id = ?", roleID).Find(&conds); err != nil {
+		return err
+	}
+	kept := make(map[string]bool, len(roleRess))
+	for _, res := range roleRess {
+		kept[res.Method+" "+res.URL] = true
+	}
+	for _, cond := range conds {
+		if kept[cond.Method+" "+cond.URL] {
+			continue
+		}
+		if _, err := session.ID(cond.ID).Delete(new(policyCondition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 获取策略条件
+func getPolicyConditions(cond builder.Cond) ([]*policyCondition, error) {
+	var conds = make([]*policyCondition, 0)
+	if err := _Engine.Where(cond).Find(&conds); err != nil {
+		return nil, err
+	}
+	return conds, nil
+}
@@ -1,25 +1,27 @@
 package models
 
 import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/convert"
 	"github.com/ihuanglei/authenticator/pkg/errors"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/simplexwork/common"
 )
 
-// CreateDict 创建字典
-func CreateDict(dictDto *st.DictDto) error {
+// CreateDict 创建租户字典
+func CreateDict(tenant string, dictDto *st.DictDto) error {
 	dict := new(dict)
 	err := convert.Map(&dictDto, dict)
 	if err != nil {
 		return err
 	}
+	dict.Tenant = tenantOf(tenant)
 	return createDict(dict)
 }
 
-// UpdateDict 更新字典
-func UpdateDict(dictID common.ID, dictDto *st.DictDto) error {
-	if _, err := getDictByID(dictID); err != nil {
+// UpdateDict 更新租户字典
+func UpdateDict(tenant string, dictID common.ID, dictDto *st.DictDto) error {
+	if _, err := getTenantDictByID(tenant, dictID); err != nil {
 		return err
 	}
 	dict := new(dict)
@@ -30,8 +32,8 @@ func UpdateDict(dictID common.ID, dictDto *st.DictDto) error {
 	return updateDict(dictID, dict)
 }
 
-// UpdateOneDict 更新字典
-func UpdateOneDict(dictDto *st.DictDto) error {
+// UpdateOneDict 更新租户字典
+func UpdateOneDict(tenant string, dictDto *st.DictDto) error {
 	if common.IsEmpty(dictDto.TP) || common.IsEmpty(dictDto.Cate) {
 		return errors.ErrArgument
 	}
@@ -40,23 +42,27 @@ func UpdateOneDict(dictDto *st.DictDto) error {
 	if err != nil {
 		return err
 	}
+	dict.Tenant = tenantOf(tenant)
 	return updateOne(dict)
 }
 
-// DelDict 删除字典
-func DelDict(dictID common.ID) error {
-	if _, err := getDictByID(dictID); err != nil {
+// DelDict 删除租户字典
+func DelDict(tenant string, dictID common.ID) error {
+	if _, err := getTenantDictByID(tenant, dictID); err != nil {
 		return err
 	}
 	return delDict(dictID)
 }
 
-// GetOneDict .
-func GetOneDict(cate, tp string) (*st.DictDto, error) {
+// GetOneDict 租户未配置时使用默认租户的配置
+func GetOneDict(tenant, cate, tp string) (*st.DictDto, error) {
 	if common.IsEmpty(cate) || common.IsEmpty(tp) {
 		return nil, errors.ErrArgument
 	}
-	dict, err := getOneDict(cate, tp)
+	dict, err := getOneDict(tenantOf(tenant), cate, tp)
+	if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrDictNotFound.Code() && tenantOf(tenant) != consts.DefaultTenant {
+		dict, err = getOneDict(consts.DefaultTenant, cate, tp)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +74,8 @@ func GetOneDict(cate, tp string) (*st.DictDto, error) {
 }
 
 // GetDictByName .
-func GetDictByName(name ...string) ([]*st.DictDto, error) {
-	dicts, err := getDictByName(name...)
+func GetDictByName(tenant string, name ...string) ([]*st.DictDto, error) {
+	dicts, err := getDictByName(tenantOf(tenant), name...)
 	if err != nil {
 		return nil, err
 	}
@@ -81,8 +87,8 @@ func GetDictByName(name ...string) ([]*st.DictDto, error) {
 }
 
 // GetDictByCate .
-func GetDictByCate(cate string) ([]*st.DictDto, error) {
-	dicts, err := getDictByCate(cate)
+func GetDictByCate(tenant, cate string) ([]*st.DictDto, error) {
+	dicts, err := getDictByCate(tenantOf(tenant), cate)
 	if err != nil {
 		return nil, err
 	}
@@ -94,15 +100,30 @@ func GetDictByCate(cate string) ([]*st.DictDto, error) {
 	return dictDtos, nil
 }
 
-// GetDictByID .
-func GetDictByID(dictID common.ID) (*st.DictDto, error) {
+// GetDictByID 租户自己的或默认租户的字典
+func GetDictByID(tenant string, dictID common.ID) (*st.DictDto, error) {
 	dict, err := getDictByID(dictID)
 	if err != nil {
 		return nil, err
 	}
+	if dict.Tenant != tenantOf(tenant) && dict.Tenant != consts.DefaultTenant {
+		return nil, errors.ErrDictNotFound
+	}
 	dictDto := new(st.DictDto)
 	if err := convert.Map(&dict, dictDto); err != nil {
 		return nil, err
 	}
 	return dictDto, nil
 }
+
+// 租户自己的字典, 用于修改和删除
+func getTenantDictByID(tenant string, dictID common.ID) (*dict, error) {
+	dict, err := getDictByID(dictID)
+	if err != nil {
+		return nil, err
+	}
+	if dict.Tenant != tenantOf(tenant) {
+		return nil, errors.ErrDictNotFound
+	}
+	return dict, nil
+}
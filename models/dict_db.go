@@ -72,14 +72,14 @@ func getDictByID(dictID common.ID) (*dict, error) {
 	return &dict, nil
 }
 
-//  根据名称获取内容
-func getDictByName(name ...string) ([]*dict, error) {
-	return getDict(builder.Eq{"status": consts.Normal}.And(builder.In("name", name)))
+//  根据名称获取租户内容
+func getDictByName(tenant string, name ...string) ([]*dict, error) {
+	return getDict(builder.Eq{"tenant": tenant, "status": consts.Normal}.And(builder.In("name", name)))
 }
 
-//  根据类型获取内容
-func getDictByCate(cate string) ([]*dict, error) {
-	return getDict(builder.Eq{"cate": cate, "status": consts.Normal})
+//  根据类型获取租户内容
+func getDictByCate(tenant, cate string) ([]*dict, error) {
+	return getDict(builder.Eq{"tenant": tenant, "cate": cate, "status": consts.Normal})
 }
 
 // 获取字段
@@ -92,8 +92,8 @@ func getDict(cond builder.Cond) ([]*dict, error) {
 	return dicts, nil
 }
 
-func getOneDict(cate, tp string) (*dict, error) {
-	dicts, err := getDict(builder.Eq{"cate": cate, "tp": tp, "status": consts.Normal})
+func getOneDict(tenant, cate, tp string) (*dict, error) {
+	dicts, err := getDict(builder.Eq{"tenant": tenant, "cate": cate, "tp": tp, "status": consts.Normal})
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +103,9 @@ func getOneDict(cate, tp string) (*dict, error) {
 	return dicts[0], nil
 }
 
-// 根据cate和name,指定唯一数据进行更新或新增
+// 根据租户、cate和name,指定唯一数据进行更新或新增
 func updateOne(dict *dict) error {
-	d, err := getOneDict(dict.Cate, dict.TP)
+	d, err := getOneDict(dict.Tenant, dict.Cate, dict.TP)
 	if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrDictNotFound.Code() {
 		return createDict(dict)
 	} else if err != nil {
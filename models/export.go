@@ -0,0 +1,113 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// GetUserExport 获取用户全部数据, 用于数据导出
+func GetUserExport(userID common.ID) (*st.UserExportDto, error) {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := getUserInfoByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	thirds, err := getUserThirds(builder.Eq{"user_id": userID, "status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	_, addresses, err := getAddresses(builder.Eq{"user_id": userID, "status": consts.Normal}, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	userLogins, err := getAllUserLogins(builder.Eq{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	exportDto := &st.UserExportDto{
+		User:      new(st.UserDto),
+		UserInfo:  new(st.UserInfoDto),
+		RegIP:     userInfo.IP,
+		Thirds:    make([]*st.UserThirdDto, len(thirds)),
+		Addresses: make([]*st.AddressDto, len(addresses)),
+		Logins:    make([]*st.UserLoginDto, len(userLogins)),
+	}
+	if err := convert.Map(user, exportDto.User); err != nil {
+		return nil, err
+	}
+	if err := convert.Map(userInfo, exportDto.UserInfo); err != nil {
+		return nil, err
+	}
+	if err := convert.Map(&thirds, &exportDto.Thirds); err != nil {
+		return nil, err
+	}
+	if err := convert.Map(&addresses, &exportDto.Addresses); err != nil {
+		return nil, err
+	}
+	if err := convert.Map(&userLogins, &exportDto.Logins); err != nil {
+		return nil, err
+	}
+	return exportDto, nil
+}
+
+// EachUser 按搜索条件分批遍历用户, 用于管理员导出, 内存中只保留一批数据
+func EachUser(query st.UserQuery, fn func(*st.UserExportRowDto) error) error {
+	cond := userQueryCond(query)
+	var lastID int64
+	for {
+		users, err := getUsersAfter(cond, lastID, consts.ExportBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		userIDs := make([]common.ID, len(users))
+		for i, user := range users {
+			userIDs[i] = user.UserID
+		}
+		userInfos, err := getUserInfos(userIDs)
+		if err != nil {
+			return err
+		}
+		userLogins, err := getLastUserLogins(userIDs)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			row := &st.UserExportRowDto{UserDto: new(st.UserDto), Mode: user.Mode, LastLoginTime: zeroTime()}
+			if err := convert.Map(user, row.UserDto); err != nil {
+				return err
+			}
+			if userInfo, ok := userInfos[user.UserID]; ok {
+				row.Nickname = userInfo.Nickname
+				row.Avatar = userInfo.Avatar
+				row.Gender = userInfo.Gender
+				row.QQ = userInfo.QQ
+				row.WeiXin = userInfo.WeiXin
+				row.Province = userInfo.Province
+				row.City = userInfo.City
+				row.County = userInfo.County
+				row.RegIP = userInfo.IP
+			}
+			if userLogin, ok := userLogins[user.UserID]; ok {
+				row.LastLoginIP = userLogin.IP
+				row.LastLoginTime = userLogin.CreateTime
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(users) < consts.ExportBatchSize {
+			return nil
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// SaveRoleGrants 记录用户在域中新授予、取消和修改有效期的角色, expire为零值时永不过期
+func SaveRoleGrants(domain string, userID common.ID, added, removed, renewed []string, expire time.Time, operatorID common.ID) error {
+	expireTime := zeroTime()
+	if !expire.IsZero() {
+		expireTime = common.DateTime(expire)
+	}
+	return saveRoleGrants(domain, userID, added, removed, renewed, expireTime, operatorID)
+}
+
+// GetRoleGrants 用户在域中的角色授予记录, 按角色编号索引
+func GetRoleGrants(domain string, userID common.ID) (map[string]*st.RoleGrantDto, error) {
+	grants, err := getRoleGrants(builder.Eq{"domain": domain, "user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	grantDtos, err := toRoleGrantDtos(grants)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*st.RoleGrantDto, len(grantDtos))
+	for _, grant := range grantDtos {
+		ret[grant.RoleID] = grant
+	}
+	return ret, nil
+}
+
+// GetExpiringRoleGrants 设置了有效期的授予记录, 包含已过期但未移除的
+func GetExpiringRoleGrants() ([]*st.RoleGrantDto, error) {
+	grants, err := getRoleGrants(builder.Gt{"expire_time": zeroTime()})
+	if err != nil {
+		return nil, err
+	}
+	return toRoleGrantDtos(grants)
+}
+
+// GetExpiredRoleGrants 已过期的授予记录
+func GetExpiredRoleGrants() ([]*st.RoleGrantDto, error) {
+	grants, err := getRoleGrants(builder.Gt{"expire_time": zeroTime()}.And(builder.Lte{"expire_time": common.Now()}))
+	if err != nil {
+		return nil, err
+	}
+	return toRoleGrantDtos(grants)
+}
+
+// ExpireRoleGrants 移除已过期的授予记录, 调用前先移除权限规则中的用户角色
+func ExpireRoleGrants(grantDtos []*st.RoleGrantDto) error {
+	var grants = make([]*roleGrant, len(grantDtos))
+	if err := convert.Map(&grantDtos, &grants); err != nil {
+		return err
+	}
+	return expireRoleGrants(grants)
+}
+
+// GetRoleGrantLogs 用户角色变更记录
+func GetRoleGrantLogs(userID common.ID, page, limit int) (int64, []*st.RoleGrantLogDto, error) {
+	count, logs, err := getRoleGrantLogs(builder.Eq{"user_id": userID}, page, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	var logDtos = make([]*st.RoleGrantLogDto, len(logs))
+	if err := convert.Map(&logs, &logDtos); err != nil {
+		return 0, nil, err
+	}
+	return count, logDtos, nil
+}
+
+func toRoleGrantDtos(grants []*roleGrant) ([]*st.RoleGrantDto, error) {
+	var grantDtos = make([]*st.RoleGrantDto, len(grants))
+	if err := convert.Map(&grants, &grantDtos); err != nil {
+		return nil, err
+	}
+	return grantDtos, nil
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 保存用户在域中新授予、取消和修改有效期的角色, 同时写入变更记录
+func saveRoleGrants(domain string, userID common.ID, added, removed, renewed []string, expireTime common.DateTime, operatorID common.ID) error {
+	now := common.Now()
+	var grants []*roleGrant
+	var logs []*roleGrantLog
+	for _, roleID := range added {
+		grants = append(grants, &roleGrant{Domain: domain, UserID: userID, RoleID: roleID, GranterID: operatorID, ExpireTime: expireTime, CreateTime: now})
+		logs = append(logs, &roleGrantLog{Domain: domain, UserID: userID, RoleID: roleID, Action: consts.GrantActionGrant, ExpireTime: expireTime, OperatorID: operatorID, CreateTime: now})
+	}
+	for _, roleID := range renewed {
+		grants = append(grants, &roleGrant{Domain: domain, UserID: userID, RoleID: roleID, GranterID: operatorID, ExpireTime: expireTime, CreateTime: now})
+		logs = append(logs, &roleGrantLog{Domain: domain, UserID: userID, RoleID: roleID, Action: consts.GrantActionRenew, ExpireTime: expireTime, OperatorID: operatorID, CreateTime: now})
+	}
+	for _, roleID := range removed {
+		logs = append(logs, &roleGrantLog{Domain: domain, UserID: userID, RoleID: roleID, Action: consts.GrantActionRevoke, ExpireTime: zeroTime(), OperatorID: operatorID, CreateTime: now})
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	// 新授予的角色可能残留之前的记录, 修改有效期的角色替换原记录, 一并删除
+	roleIDs := append(append(append([]string{}, added...), removed...), renewed...)
+	if _, err := session.Where(builder.Eq{"domain": domain, "user_id": userID}.And(builder.In("role_id", roleIDs))).Delete(new(roleGrant)); err != nil {
+		return err
+	}
+	if len(grants) > 0 {
+		if _, err := session.Insert(&grants); err != nil {
+			return err
+		}
+	}
+	if _, err := session.Insert(&logs); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 移除已过期的授予记录, 同时写入变更记录
+func expireRoleGrants(grants []*roleGrant) error {
+	if len(grants) == 0 {
+		return nil
+	}
+	now := common.Now()
+	var logs []*roleGrantLog
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		// 其他实例已移除或重新授予时跳过
+		affected, err := session.Where("domain = ? AND user_id = ? AND role_id = ? AND expire_time = ?", grant.Domain, grant.UserID, grant.RoleID, grant.ExpireTime).
+			Delete(new(roleGrant))
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			logs = append(logs, &roleGrantLog{Domain: grant.Domain, UserID: grant.UserID, RoleID: grant.RoleID, Action: consts.GrantActionExpire, ExpireTime: grant.ExpireTime, CreateTime: now})
+		}
+	}
+	if len(logs) == 0 {
+		return session.Commit()
+	}
+	if _, err := session.Insert(&logs); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 合并账号时转移授予记录, target已有的角色保留target的记录
+func mergeRoleGrantsInSession(session *xorm.Session, sourceID, targetID common.ID) error {
+	var sources []*roleGrant
+	if err := session.Where("user_id = ?", sourceID).Find(&sources); err != nil {
+		return err
+	}
+	for _, grant := range sources {
+		has, err := session.Where("domain = ? AND user_id = ? AND role_id = ?", grant.Domain, targetID, grant.RoleID).Exist(new(roleGrant))
+		if err != nil {
+			return err
+		}
+		if has {
+			_, err = session.ID(grant.ID).Delete(new(roleGrant))
+		} else {
+			_, err = session.Cols("user_id").ID(grant.ID).Update(&roleGrant{UserID: targetID})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 获取授予记录
+func getRoleGrants(cond builder.Cond) ([]*roleGrant, error) {
+	var grants = make([]*roleGrant, 0)
+	if err := _Engine.Where(cond).Find(&grants); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// 获取变更记录
+func getRoleGrantLogs(cond builder.Cond, page, limit int) (int64, []*roleGrantLog, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var logs = make([]*roleGrantLog, 0)
+	count, err := _Engine.Where(cond).Desc("id").Limit(limit, start).FindAndCount(&logs)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, logs, nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"regexp"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// CreateUser 管理员在租户内创建用户
+func CreateUser(tenant string, userDto *st.ImportUserDto, ip string) (common.ID, error) {
+	user, userInfo, err := checkImportUser(tenant, userDto, ip, map[string]bool{})
+	if err != nil {
+		return 0, err
+	}
+	if err := createUser(user, userInfo, nil, nil, nil); err != nil {
+		return 0, err
+	}
+	return user.UserID, nil
+}
+
+// ImportUsers 批量导入用户到租户, 校验失败的行跳过, 其余每批一个事务写入, 返回每行结果
+func ImportUsers(tenant string, userDtos []*st.ImportUserDto, ip string) ([]*st.ImportResultDto, error) {
+	if len(userDtos) == 0 {
+		return nil, errors.ErrArgument
+	}
+	if len(userDtos) > consts.ImportLimit {
+		return nil, errors.ErrImportLimit
+	}
+	results := make([]*st.ImportResultDto, len(userDtos))
+	// 同一文件内重复的用户名、邮箱、手机号
+	seen := map[string]bool{}
+	for start := 0; start < len(userDtos); start += consts.ImportBatchSize {
+		end := start + consts.ImportBatchSize
+		if end > len(userDtos) {
+			end = len(userDtos)
+		}
+		var rows []int
+		var users []*user
+		var userInfos []*userInfo
+		for i := start; i < end; i++ {
+			results[i] = &st.ImportResultDto{Row: i + 1}
+			user, userInfo, err := checkImportUser(tenant, userDtos[i], ip, seen)
+			if err != nil {
+				importFailed(results[i], err)
+				continue
+			}
+			rows = append(rows, i)
+			users = append(users, user)
+			userInfos = append(userInfos, userInfo)
+		}
+		if len(users) == 0 {
+			continue
+		}
+		err := createUsers(users, userInfos)
+		for j, i := range rows {
+			if err != nil {
+				importFailed(results[i], err)
+			} else {
+				results[i].Success = true
+				results[i].UserID = users[j].UserID
+			}
+		}
+	}
+	return results, nil
+}
+
+// 复用注册时的校验规则, 包括保留词和敏感词, 通过后返回待写入的用户
+func checkImportUser(tenant string, userDto *st.ImportUserDto, ip string, seen map[string]bool) (*user, *userInfo, error) {
+	if userDto == nil {
+		return nil, nil, errors.ErrArgument
+	}
+	name := common.Trim(userDto.Name)
+	email := common.Trim(userDto.Email)
+	mobile := common.Trim(userDto.Mobile)
+	if name == "" && email == "" && mobile == "" {
+		return nil, nil, errors.ErrArgument
+	}
+	user := &user{Tenant: tenant, Name: name, Email: email, Mobile: mobile}
+	if mobile != "" {
+		if !common.IsMobile(mobile) {
+			return nil, nil, errors.ErrMobile
+		}
+		if has, err := HasUserByMobile(tenant, mobile); err != nil {
+			return nil, nil, err
+		} else if has || seen["mobile:"+mobile] {
+			return nil, nil, errors.ErrUserMobileExist
+		}
+		user.Mode = consts.Mobile
+	}
+	if email != "" {
+		if !common.IsEmail(email) {
+			return nil, nil, errors.ErrEmail
+		}
+		if has, err := HasUserByEmail(tenant, email); err != nil {
+			return nil, nil, err
+		} else if has || seen["email:"+email] {
+			return nil, nil, errors.ErrUserEmailExist
+		}
+		user.Mode = consts.Email
+	}
+	if name != "" {
+		if err := checkLoginName(name); err != nil {
+			return nil, nil, err
+		}
+		if err := checkWords(consts.WordFieldName, name); err != nil {
+			return nil, nil, err
+		}
+		if has, err := HasUserByName(tenant, name); err != nil {
+			return nil, nil, err
+		} else if has || seen["name:"+name] {
+			return nil, nil, errors.ErrUserNameExist
+		}
+		user.Mode = consts.Name
+	}
+	if userDto.PasswordHash != "" {
+		if b, err := regexp.MatchString(`^[0-9a-f]{32}$`, userDto.PasswordHash); !b || err != nil {
+			return nil, nil, errors.ErrPasswordHash
+		}
+		if userDto.Salt == "" || len(userDto.Salt) > 15 {
+			return nil, nil, errors.ErrPasswordHash
+		}
+		user.Password = userDto.PasswordHash
+		user.Salt = userDto.Salt
+		user.passwordHashed = true
+	} else if userDto.Password != "" {
+		if !common.IsSimplePassword(userDto.Password) {
+			return nil, nil, errors.ErrPassword
+		}
+		user.Password = userDto.Password
+	}
+	userInfo := &userInfo{IP: ip}
+	if nickname := common.Trim(userDto.Nickname); nickname != "" {
+		if err := checkNickname(nickname); err != nil {
+			return nil, nil, err
+		}
+		if err := checkWords(consts.WordFieldNickname, nickname); err != nil {
+			return nil, nil, err
+		}
+		userInfo.Nickname = nickname
+	}
+	if name != "" {
+		seen["name:"+name] = true
+	}
+	if email != "" {
+		seen["email:"+email] = true
+	}
+	if mobile != "" {
+		seen["mobile:"+mobile] = true
+	}
+	return user, userInfo, nil
+}
+
+func importFailed(result *st.ImportResultDto, err error) {
+	result.Success = false
+	if e, ok := err.(errors.Error); ok {
+		result.Code = e.Code()
+		result.Msg = e.Error()
+		return
+	}
+	logger.Error(err)
+	result.Code = errors.ErrUnknown.Code()
+	result.Msg = errors.ErrUnknown.Error()
+}
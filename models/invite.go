@@ -0,0 +1,192 @@
+package models
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// CreateInvites 批量生成租户的注册邀请码, expireDays为0时永不过期
+func CreateInvites(tenant string, count int, inviteDto *st.InviteDto, expireDays int) ([]*st.InviteDto, error) {
+	if count <= 0 {
+		count = 1
+	}
+	if count > consts.InviteBatchLimit || inviteDto.MaxUses < 0 || expireDays < 0 {
+		return nil, errors.ErrInviteLimit
+	}
+	if utf8.RuneCountInString(inviteDto.Remark) > 60 {
+		return nil, errors.ErrArgument
+	}
+	if inviteDto.RoleID > 0 {
+		// 注册后授予的是管理后台的角色
+		if role, err := GetRoleByID(inviteDto.RoleID); err != nil {
+			return nil, err
+		} else if role.Domain != "" {
+			return nil, errors.ErrRoleDomain
+		}
+	}
+	if inviteDto.InviterID > 0 {
+		inviter, err := getUserByID(inviteDto.InviterID)
+		if err != nil {
+			return nil, err
+		}
+		if inviter.Tenant != tenantOf(tenant) {
+			return nil, errors.ErrUserNotExist
+		}
+	}
+	expireTime := zeroTime()
+	if expireDays > 0 {
+		expireTime = common.DateTime(time.Now().AddDate(0, 0, expireDays))
+	}
+	invites := make([]*inviteCode, count)
+	for i := range invites {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		invites[i] = &inviteCode{
+			Tenant:     tenantOf(tenant),
+			Code:       code,
+			InviterID:  inviteDto.InviterID,
+			RoleID:     inviteDto.RoleID,
+			MaxUses:    inviteDto.MaxUses,
+			Remark:     inviteDto.Remark,
+			ExpireTime: expireTime,
+		}
+	}
+	if err := createInviteCodes(invites); err != nil {
+		return nil, err
+	}
+	inviteDtos := make([]*st.InviteDto, len(invites))
+	if err := convert.Map(&invites, &inviteDtos); err != nil {
+		return nil, err
+	}
+	return inviteDtos, nil
+}
+
+// GetInvites 租户的有效邀请码列表
+func GetInvites(query st.InviteQuery) (int64, []*st.InviteDto, error) {
+	cond := builder.Eq{"tenant": tenantOf(query.Tenant), "status": consts.Normal}
+	if query.InviterID != "" {
+		cond["inviter_id"] = common.StrToID(query.InviterID)
+	}
+	count, invites, err := getInviteCodes(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	inviteDtos := make([]*st.InviteDto, len(invites))
+	if err := convert.Map(&invites, &inviteDtos); err != nil {
+		return 0, nil, err
+	}
+	return count, inviteDtos, nil
+}
+
+// RevokeInvite 作废租户的邀请码, 已注册的邀请记录保留
+func RevokeInvite(tenant string, inviteID common.ID) error {
+	invite, err := getInviteCodeByID(inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.Tenant != tenantOf(tenant) {
+		return errors.ErrInviteNotFound
+	}
+	return revokeInviteCode(inviteID)
+}
+
+// CheckInvite 校验租户的邀请码是否可用
+func CheckInvite(tenant, code string) (*st.InviteDto, error) {
+	invite, err := getValidInvite(tenant, code)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, errors.ErrInviteNotFound
+	}
+	inviteDto := new(st.InviteDto)
+	if err := convert.Map(invite, inviteDto); err != nil {
+		return nil, err
+	}
+	return inviteDto, nil
+}
+
+// GetReferrals 邀请注册记录
+func GetReferrals(query st.InviteQuery) (int64, []*st.ReferralDto, error) {
+	count, referrals, err := getReferrals(referralQueryCond(query), query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	referralDtos := make([]*st.ReferralDto, len(referrals))
+	if err := convert.Map(&referrals, &referralDtos); err != nil {
+		return 0, nil, err
+	}
+	return count, referralDtos, nil
+}
+
+// GetReferralStats 按邀请人统计邀请注册人数
+func GetReferralStats(query st.InviteQuery) ([]*st.ReferralStatDto, error) {
+	stats, err := getReferralStats(referralQueryCond(query), query.Page, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	statDtos := make([]*st.ReferralStatDto, len(stats))
+	for i, stat := range stats {
+		statDtos[i] = &st.ReferralStatDto{InviterID: stat.InviterID, Count: stat.Count}
+	}
+	return statDtos, nil
+}
+
+func referralQueryCond(query st.InviteQuery) builder.Cond {
+	cond := builder.Eq{"tenant": tenantOf(query.Tenant)}
+	if query.InviterID != "" {
+		cond["inviter_id"] = common.StrToID(query.InviterID)
+	}
+	if query.InviteID != "" {
+		cond["invite_id"] = common.StrToID(query.InviteID)
+	}
+	return cond
+}
+
+// 邀请码字符, 去掉容易混淆的0、O、1、I
+const inviteCodeChars = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// 批量生成时按时间播种的随机数可能重复, 使用crypto/rand
+func newInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = inviteCodeChars[int(b[i])%len(inviteCodeChars)]
+	}
+	return string(b), nil
+}
+
+// 注册时使用的邀请码, 未填写时返回nil, 已作废、过期、用完或不属于该租户的视为无效
+func getValidInvite(tenant, code string) (*inviteCode, error) {
+	code = strings.ToUpper(common.Trim(code))
+	if code == "" {
+		return nil, nil
+	}
+	invite, err := getInviteCodeByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if invite.Tenant != tenantOf(tenant) {
+		return nil, errors.ErrInviteNotFound
+	}
+	if time.Time(invite.ExpireTime).Year() > 1 && time.Now().After(time.Time(invite.ExpireTime)) {
+		return nil, errors.ErrInviteNotFound
+	}
+	if invite.MaxUses > 0 && invite.Used >= invite.MaxUses {
+		return nil, errors.ErrInviteNotFound
+	}
+	return invite, nil
+}
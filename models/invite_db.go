@@ -0,0 +1,141 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 批量新增邀请码
+func createInviteCodes(invites []*inviteCode) error {
+	now := common.Now()
+	for _, invite := range invites {
+		inviteID, err := _IDWorker.Next()
+		if err != nil {
+			return err
+		}
+		invite.InviteID = inviteID
+		invite.Status = consts.Normal
+		invite.CreateTime = now
+		invite.UpdateTime = now
+	}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(&invites); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 根据编号获取邀请码
+func getInviteCodeByID(inviteID common.ID) (*inviteCode, error) {
+	return getInviteCode(builder.Eq{"invite_id": inviteID, "status": consts.Normal})
+}
+
+// 根据邀请码获取
+func getInviteCodeByCode(code string) (*inviteCode, error) {
+	return getInviteCode(builder.Eq{"code": code, "status": consts.Normal})
+}
+
+func getInviteCode(cond builder.Cond) (*inviteCode, error) {
+	invite := new(inviteCode)
+	has, err := _Engine.Where(cond).Get(invite)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+// 邀请码列表
+func getInviteCodes(cond builder.Cond, page, limit int) (int64, []*inviteCode, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var invites = make([]*inviteCode, 0)
+	count, err := _Engine.Desc("create_time").Where(cond).Limit(limit, start).FindAndCount(&invites)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, invites, nil
+}
+
+// 作废邀请码
+func revokeInviteCode(inviteID common.ID) error {
+	invite := &inviteCode{Status: consts.Delete, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("status", "update_time").Where("invite_id = ?", inviteID).Update(invite)
+	return err
+}
+
+// 使用邀请码并记录邀请关系, 与创建用户在同一事务, 并发使用时以使用次数为准
+func useInviteCode(session *xorm.Session, invite *inviteCode, user *user) error {
+	used := &inviteCode{UpdateTime: user.CreateTime}
+	n, err := session.Incr("used").Cols("update_time").
+		Where("invite_id = ? AND status = ? AND (max_uses = 0 OR used < max_uses)", invite.InviteID, consts.Normal).
+		Update(used)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.ErrInviteNotFound
+	}
+	referral := &referral{
+		Tenant:     user.Tenant,
+		InviteID:   invite.InviteID,
+		InviterID:  invite.InviterID,
+		UserID:     user.UserID,
+		CreateTime: user.CreateTime,
+	}
+	_, err = session.Insert(referral)
+	return err
+}
+
+// 邀请注册记录
+func getReferrals(cond builder.Cond, page, limit int) (int64, []*referral, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var referrals = make([]*referral, 0)
+	count, err := _Engine.Desc("id").Where(cond).Limit(limit, start).FindAndCount(&referrals)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, referrals, nil
+}
+
+// 按邀请人统计邀请注册人数, 人数多的在前
+func getReferralStats(cond builder.Cond, page, limit int) ([]*referralStat, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var stats = make([]*referralStat, 0)
+	err := _Engine.Table(new(referral)).Select("inviter_id, COUNT(*) AS count").Where(cond).
+		GroupBy("inviter_id").OrderBy("count DESC").Limit(limit, start).Find(&stats)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+type referralStat struct {
+	InviterID common.ID `xorm:"inviter_id"`
+	Count     int64     `xorm:"count"`
+}
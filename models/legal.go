@@ -0,0 +1,269 @@
+package models
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 需要用户同意的协议类型
+var _LegalTypes = []string{consts.LegalTerms, consts.LegalPrivacy}
+
+// CreateLegal 创建租户的协议草稿, 发布后用户才需要同意
+func CreateLegal(tenant string, legalDto *st.LegalDto) (common.ID, error) {
+	if !isLegalType(legalDto.TP) {
+		return 0, errors.ErrLegalType
+	}
+	if err := checkLegal(tenant, 0, legalDto); err != nil {
+		return 0, err
+	}
+	legal := &legal{Tenant: tenantOf(tenant), TP: legalDto.TP, Version: legalDto.Version, Title: legalDto.Title, Content: legalDto.Content}
+	if err := createLegal(legal); err != nil {
+		return 0, err
+	}
+	return legal.LegalID, nil
+}
+
+// UpdateLegal 更新租户的协议草稿, 协议类型不允许修改
+func UpdateLegal(tenant string, legalID common.ID, legalDto *st.LegalDto) error {
+	old, err := getDraftLegal(tenant, legalID)
+	if err != nil {
+		return err
+	}
+	legalDto.TP = old.TP
+	if err := checkLegal(tenant, legalID, legalDto); err != nil {
+		return err
+	}
+	return updateLegal(legalID, &legal{Version: legalDto.Version, Title: legalDto.Title, Content: legalDto.Content})
+}
+
+// PublishLegal 发布协议, 发布后所有用户下次登录时需要同意
+func PublishLegal(tenant string, legalID common.ID) error {
+	if _, err := getDraftLegal(tenant, legalID); err != nil {
+		return err
+	}
+	return publishLegal(legalID)
+}
+
+// DelLegal 删除协议草稿
+func DelLegal(tenant string, legalID common.ID) error {
+	if _, err := getDraftLegal(tenant, legalID); err != nil {
+		return err
+	}
+	return deleteLegal(legalID)
+}
+
+// GetLegal 获取协议, 租户自己的或默认租户的
+func GetLegal(tenant string, legalID common.ID) (*st.LegalDto, error) {
+	legal, err := getLegalByID(legalID)
+	if err != nil {
+		return nil, err
+	}
+	if legal.Tenant != tenantOf(tenant) && legal.Tenant != consts.DefaultTenant {
+		return nil, errors.ErrLegalNotFound
+	}
+	return legal.dto(), nil
+}
+
+// GetLegals 租户的协议列表, 包含草稿
+func GetLegals(query st.LegalQuery) (int64, []*st.LegalDto, error) {
+	cond := builder.Eq{"tenant": tenantOf(query.Tenant), "status": consts.Normal}
+	if query.TP != "" {
+		cond["tp"] = query.TP
+	}
+	count, legals, err := getLegals(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	legalDtos := make([]*st.LegalDto, len(legals))
+	for i, legal := range legals {
+		legalDtos[i] = legal.dto()
+	}
+	return count, legalDtos, nil
+}
+
+// GetCurrentLegals 租户当前生效的协议, 租户未发布的类型使用默认租户的协议
+func GetCurrentLegals(tenant string) ([]*st.LegalDto, error) {
+	legals, err := getCurrentLegals(tenant)
+	if err != nil {
+		return nil, err
+	}
+	legalDtos := make([]*st.LegalDto, len(legals))
+	for i, legal := range legals {
+		legalDtos[i] = legal.dto()
+	}
+	return legalDtos, nil
+}
+
+// GetPendingLegals 用户尚未同意的当前生效协议
+func GetPendingLegals(userID common.ID) ([]*st.LegalDto, error) {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	legals, err := getCurrentLegals(user.Tenant)
+	if err != nil || len(legals) == 0 {
+		return nil, err
+	}
+	accepts, err := getLegalAccepts(userID)
+	if err != nil {
+		return nil, err
+	}
+	accepted := make(map[common.ID]bool, len(accepts))
+	for _, accept := range accepts {
+		accepted[accept.LegalID] = true
+	}
+	legalDtos := make([]*st.LegalDto, 0, len(legals))
+	for _, legal := range legals {
+		if !accepted[legal.LegalID] {
+			legalDtos = append(legalDtos, legal.dto())
+		}
+	}
+	return legalDtos, nil
+}
+
+// AcceptLegals 用户同意协议, 只能同意当前生效的协议
+func AcceptLegals(userID common.ID, legalIDs []common.ID, ip string) error {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	current, err := getCurrentLegals(user.Tenant)
+	if err != nil {
+		return err
+	}
+	legals, err := pickCurrentLegals(current, legalIDs)
+	if err != nil {
+		return err
+	}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := acceptLegals(session, userID, legals, ip); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 从当前生效的协议中选出用户同意的, 重复的忽略
+func pickCurrentLegals(current []*legal, legalIDs []common.ID) ([]*legal, error) {
+	byID := make(map[common.ID]*legal, len(current))
+	for _, legal := range current {
+		byID[legal.LegalID] = legal
+	}
+	legals := make([]*legal, 0, len(legalIDs))
+	for _, legalID := range legalIDs {
+		legal, ok := byID[legalID]
+		if !ok {
+			return nil, errors.ErrLegalNotFound
+		}
+		delete(byID, legalID)
+		legals = append(legals, legal)
+	}
+	return legals, nil
+}
+
+// GetLegalAccepts 用户同意协议记录
+func GetLegalAccepts(userID common.ID) ([]*st.LegalAcceptDto, error) {
+	accepts, err := getLegalAccepts(userID)
+	if err != nil {
+		return nil, err
+	}
+	acceptDtos := make([]*st.LegalAcceptDto, len(accepts))
+	if err := convert.Map(&accepts, &acceptDtos); err != nil {
+		return nil, err
+	}
+	return acceptDtos, nil
+}
+
+// 租户当前生效的协议, 每种类型取最新发布的版本
+func getCurrentLegals(tenant string) ([]*legal, error) {
+	tenant = tenantOf(tenant)
+	legals := make([]*legal, 0, len(_LegalTypes))
+	for _, tp := range _LegalTypes {
+		legal, err := getPublishedLegal(tenant, tp)
+		if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrLegalNotFound.Code() && tenant != consts.DefaultTenant {
+			legal, err = getPublishedLegal(consts.DefaultTenant, tp)
+		}
+		if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrLegalNotFound.Code() {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		legals = append(legals, legal)
+	}
+	return legals, nil
+}
+
+// 租户自己的未发布协议
+func getDraftLegal(tenant string, legalID common.ID) (*legal, error) {
+	legal, err := getLegalByID(legalID)
+	if err != nil {
+		return nil, err
+	}
+	if legal.Tenant != tenantOf(tenant) {
+		return nil, errors.ErrLegalNotFound
+	}
+	if legal.published() {
+		return nil, errors.ErrLegalPublished
+	}
+	return legal, nil
+}
+
+// 校验版本号、标题, 同一租户同类型的版本号不能重复
+func checkLegal(tenant string, legalID common.ID, legalDto *st.LegalDto) error {
+	legalDto.Version = common.Trim(legalDto.Version)
+	legalDto.Title = common.Trim(legalDto.Title)
+	if l := utf8.RuneCountInString(legalDto.Version); l < 1 || l > 20 {
+		return errors.ErrArgument
+	}
+	if l := utf8.RuneCountInString(legalDto.Title); l < 1 || l > 100 {
+		return errors.ErrArgument
+	}
+	if common.Trim(legalDto.Content) == "" {
+		return errors.ErrArgument
+	}
+	cond := builder.Eq{"tenant": tenantOf(tenant), "tp": legalDto.TP, "version": legalDto.Version}.And(builder.Neq{"legal_id": legalID})
+	count, err := getLegalCount(cond)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.ErrLegalVersion
+	}
+	return nil
+}
+
+func isLegalType(tp string) bool {
+	for _, t := range _LegalTypes {
+		if t == tp {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *legal) published() bool {
+	return time.Time(l.PublishTime).Year() > 1
+}
+
+func (l *legal) dto() *st.LegalDto {
+	return &st.LegalDto{
+		LegalID:     l.LegalID,
+		TP:          l.TP,
+		Version:     l.Version,
+		Title:       l.Title,
+		Content:     l.Content,
+		PublishTime: l.PublishTime,
+		CreateTime:  l.CreateTime,
+	}
+}
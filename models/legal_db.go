@@ -0,0 +1,150 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 新增协议草稿
+func createLegal(legal *legal) error {
+	legalID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	legal.LegalID = legalID
+	legal.Status = consts.Normal
+	legal.PublishTime = zeroTime()
+	legal.CreateTime = common.Now()
+	legal.UpdateTime = legal.CreateTime
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(legal); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 更新协议草稿, 已发布的协议不允许修改
+func updateLegal(legalID common.ID, legal *legal) error {
+	legal.UpdateTime = common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("version", "title", "content", "update_time").Where("legal_id = ?", legalID).Update(legal); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 发布协议
+func publishLegal(legalID common.ID) error {
+	now := common.Now()
+	legal := &legal{PublishTime: now, UpdateTime: now}
+	_, err := _Engine.Cols("publish_time", "update_time").Where("legal_id = ?", legalID).Update(legal)
+	return err
+}
+
+// 删除协议草稿
+func deleteLegal(legalID common.ID) error {
+	legal := &legal{Status: consts.Delete, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("status", "update_time").Where("legal_id = ?", legalID).Update(legal)
+	return err
+}
+
+// 根据编号获取协议
+func getLegalByID(legalID common.ID) (*legal, error) {
+	legal := new(legal)
+	has, err := _Engine.Where("legal_id = ? AND status = ?", legalID, consts.Normal).Get(legal)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrLegalNotFound
+	}
+	return legal, nil
+}
+
+// 协议数量, 包含已删除的协议, 版本号不允许复用
+func getLegalCount(cond builder.Cond) (int64, error) {
+	return _Engine.Where(cond).Count(new(legal))
+}
+
+// 协议列表, 不返回内容
+func getLegals(cond builder.Cond, page, limit int) (int64, []*legal, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var legals = make([]*legal, 0)
+	count, err := _Engine.Omit("content").Desc("create_time").Where(cond).Limit(limit, start).FindAndCount(&legals)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, legals, nil
+}
+
+// 租户某类型最新发布的协议
+func getPublishedLegal(tenant, tp string) (*legal, error) {
+	legal := new(legal)
+	has, err := _Engine.Where("tenant = ? AND tp = ? AND status = ? AND publish_time > ?", tenant, tp, consts.Normal, zeroTime()).
+		Desc("publish_time").Get(legal)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrLegalNotFound
+	}
+	return legal, nil
+}
+
+// 记录用户同意的协议, 已同意的忽略
+func acceptLegals(session *xorm.Session, userID common.ID, legals []*legal, ip string) error {
+	if len(legals) == 0 {
+		return nil
+	}
+	legalIDs := make([]common.ID, len(legals))
+	for i, legal := range legals {
+		legalIDs[i] = legal.LegalID
+	}
+	var accepted = make([]*legalAccept, 0)
+	if err := session.Where(builder.Eq{"user_id": userID}.And(builder.In("legal_id", legalIDs))).Find(&accepted); err != nil {
+		return err
+	}
+	seen := make(map[common.ID]bool, len(accepted))
+	for _, accept := range accepted {
+		seen[accept.LegalID] = true
+	}
+	now := common.Now()
+	accepts := make([]*legalAccept, 0, len(legals))
+	for _, legal := range legals {
+		if seen[legal.LegalID] {
+			continue
+		}
+		accepts = append(accepts, &legalAccept{UserID: userID, LegalID: legal.LegalID, TP: legal.TP, Version: legal.Version, IP: ip, CreateTime: now})
+	}
+	if len(accepts) == 0 {
+		return nil
+	}
+	_, err := session.Insert(&accepts)
+	return err
+}
+
+// 用户同意协议记录, 最近的在前
+func getLegalAccepts(userID common.ID) ([]*legalAccept, error) {
+	var accepts = make([]*legalAccept, 0)
+	if err := _Engine.Where("user_id = ?", userID).Desc("id").Find(&accepts); err != nil {
+		return nil, err
+	}
+	return accepts, nil
+}
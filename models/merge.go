@@ -0,0 +1,141 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// MergeUser 合并账号, 将source的第三方绑定、地址、登录历史、自定义属性、组织成员转移到target, source标记为删除
+// 角色和授予记录由 FinishMerges 转移
+// 用户名、邮箱、手机号及同类型的第三方绑定冲突时保留target的, 只能合并同一租户的账号, 合并后地址数量不能超过上限
+func MergeUser(sourceID, targetID, operatorID common.ID) (*st.UserMergeDto, error) {
+	if sourceID == targetID {
+		return nil, errors.ErrMergeSelf
+	}
+	source, err := getUserByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := getUserByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	// 不同租户的账号互相不可见
+	if source.Tenant != target.Tenant {
+		return nil, errors.ErrUserNotExist
+	}
+	if source.IsDelete() || target.IsDelete() {
+		return nil, errors.ErrUserDelete
+	}
+	// 合并后的地址数量不能超过上限, 超出的地址无法在列表中管理
+	addressCount, err := getAddressCount(builder.Eq{"status": consts.Normal}.And(builder.In("user_id", sourceID, targetID)))
+	if err != nil {
+		return nil, err
+	}
+	if addressCount > consts.AddressLimit {
+		return nil, errors.ErrAddressLimit
+	}
+
+	// 目标账号未设置的用户名、邮箱、手机号使用被合并账号的
+	moved := &user{}
+	var fields []string
+	if source.Name != source.UserID.Str() && target.Name == target.UserID.Str() {
+		moved.Name = source.Name
+		fields = append(fields, "name")
+	}
+	if source.Email != source.UserID.Str() && target.Email == target.UserID.Str() {
+		moved.Email = source.Email
+		fields = append(fields, "email")
+	}
+	if source.Mobile != source.UserID.Str() && target.Mobile == target.UserID.Str() {
+		moved.Mobile = source.Mobile
+		fields = append(fields, "mobile")
+	}
+
+	targetThirds, err := getUserThirds(builder.Eq{"user_id": targetID, "status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	sourceThirds, err := getUserThirds(builder.Eq{"user_id": sourceID, "status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	bound := map[string]bool{}
+	for _, third := range targetThirds {
+		bound[third.Type] = true
+	}
+	var moveThirds, dropThirds []string
+	for _, third := range sourceThirds {
+		if bound[third.Type] {
+			dropThirds = append(dropThirds, third.Type)
+		} else {
+			moveThirds = append(moveThirds, third.Type)
+		}
+	}
+
+	merge := &userMerge{
+		SourceID:   sourceID,
+		TargetID:   targetID,
+		OperatorID: operatorID,
+		Fields:     strings.Join(fields, ","),
+		Thirds:     strings.Join(moveThirds, ","),
+	}
+	if err := mergeUser(merge, moved, fields, moveThirds, dropThirds); err != nil {
+		return nil, err
+	}
+	return merge.dto(), nil
+}
+
+// FinishMerges 转移已合并账号的角色和授予记录, 转移失败的合并在下次执行时重试
+// moveRoles 转移权限系统中的角色, 需要可重复执行
+func FinishMerges(moveRoles func(sourceID, targetID common.ID) error) error {
+	merges, err := getUserMerges(builder.Eq{"roles_moved": false})
+	if err != nil {
+		return err
+	}
+	for _, merge := range merges {
+		if err := moveRoles(merge.SourceID, merge.TargetID); err != nil {
+			return err
+		}
+		if err := finishMerge(merge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUserMerges 用户的账号合并记录
+func GetUserMerges(userID common.ID) ([]*st.UserMergeDto, error) {
+	merges, err := getUserMerges(builder.Or(builder.Eq{"source_id": userID}, builder.Eq{"target_id": userID}))
+	if err != nil {
+		return nil, err
+	}
+	mergeDtos := make([]*st.UserMergeDto, len(merges))
+	for i, merge := range merges {
+		mergeDtos[i] = merge.dto()
+	}
+	return mergeDtos, nil
+}
+
+func (m *userMerge) dto() *st.UserMergeDto {
+	split := func(s string) []string {
+		if s == "" {
+			return []string{}
+		}
+		return strings.Split(s, ",")
+	}
+	return &st.UserMergeDto{
+		MergeID:    m.MergeID,
+		SourceID:   m.SourceID,
+		TargetID:   m.TargetID,
+		OperatorID: m.OperatorID,
+		Fields:     split(m.Fields),
+		Thirds:     split(m.Thirds),
+		CreateTime: m.CreateTime,
+	}
+}
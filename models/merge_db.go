@@ -0,0 +1,150 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 合并账号, 同一事务
+func mergeUser(merge *userMerge, moved *user, fields, moveThirds, dropThirds []string) error {
+	mergeID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	now := common.Now()
+	merge.MergeID = mergeID
+	merge.CreateTime = now
+	sourceID, targetID := merge.SourceID, merge.TargetID
+
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+
+	// 先释放被合并账号的唯一字段
+	placeholder := sourceID.Str()
+	source := &user{Name: placeholder, Email: placeholder, Mobile: placeholder, Status: consts.Delete, DeleteTime: now, UpdateTime: now}
+	if _, err := session.Cols("name", "email", "mobile", "status", "delete_time", "update_time").
+		Where("user_id = ?", sourceID).Update(source); err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		moved.UpdateTime = now
+		if _, err := session.Cols(append(fields, "update_time")...).Where("user_id = ?", targetID).Update(moved); err != nil {
+			return err
+		}
+	}
+
+	if len(moveThirds) > 0 {
+		third := &userThird{UserID: targetID, UpdateTime: now}
+		if _, err := session.Cols("user_id", "update_time").
+			Where(builder.Eq{"user_id": sourceID, "status": consts.Normal}.And(builder.In("type", moveThirds))).Update(third); err != nil {
+			return err
+		}
+	}
+	if len(dropThirds) > 0 {
+		third := &userThird{OpenID: "", Status: consts.Delete, UpdateTime: now}
+		if _, err := session.Cols("open_id", "status", "update_time").
+			Where(builder.Eq{"user_id": sourceID, "status": consts.Normal}.And(builder.In("type", dropThirds))).Update(third); err != nil {
+			return err
+		}
+	}
+
+	// 目标账号已有默认地址时, 转移的地址都不是默认地址
+	hasDefault, err := session.Where("user_id = ? AND status = ? AND def = ?", targetID, consts.Normal, addressDefault).Exist(new(userAddress))
+	if err != nil {
+		return err
+	}
+	address := &userAddress{UserID: targetID, Def: addressNotDefault}
+	addressCols := []string{"user_id"}
+	if hasDefault {
+		addressCols = append(addressCols, "def")
+	}
+	if _, err := session.Cols(addressCols...).Where("user_id = ?", sourceID).Update(address); err != nil {
+		return err
+	}
+
+	if _, err := session.Cols("user_id").Where("user_id = ?", sourceID).Update(&userLogin{UserID: targetID}); err != nil {
+		return err
+	}
+
+	// 自定义属性冲突时保留目标账号的值
+	var keys []string
+	if err := session.Table(new(userAttribute)).Cols("attr_key").Where("user_id = ?", targetID).Find(&keys); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		if _, err := session.Where(builder.Eq{"user_id": sourceID}.And(builder.In("attr_key", keys))).Delete(new(userAttribute)); err != nil {
+			return err
+		}
+	}
+	if _, err := session.Cols("user_id").Where("user_id = ?", sourceID).Update(&userAttribute{UserID: targetID}); err != nil {
+		return err
+	}
+
+	// 组织成员角色冲突时保留较高的, 被合并账号所有的组织转给目标账号
+	var sourceMembers, targetMembers []*orgMember
+	if err := session.Where("user_id = ?", sourceID).Find(&sourceMembers); err != nil {
+		return err
+	}
+	if err := session.Where("user_id = ?", targetID).Find(&targetMembers); err != nil {
+		return err
+	}
+	joined := map[common.ID]*orgMember{}
+	for _, member := range targetMembers {
+		joined[member.OrgID] = member
+	}
+	for _, member := range sourceMembers {
+		exist, ok := joined[member.OrgID]
+		if !ok {
+			if _, err := session.Cols("user_id", "update_time").ID(member.ID).Update(&orgMember{UserID: targetID, UpdateTime: now}); err != nil {
+				return err
+			}
+			continue
+		}
+		if member.Role.Level() > exist.Role.Level() {
+			if _, err := session.Cols("role", "update_time").ID(exist.ID).Update(&orgMember{Role: member.Role, UpdateTime: now}); err != nil {
+				return err
+			}
+		}
+		if _, err := session.ID(member.ID).Delete(new(orgMember)); err != nil {
+			return err
+		}
+	}
+	if _, err := session.Cols("owner_id", "update_time").Where("owner_id = ?", sourceID).
+		Update(&organization{OwnerID: targetID, UpdateTime: now}); err != nil {
+		return err
+	}
+
+	if _, err := session.Insert(merge); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 转移授予记录并标记角色已转移, 同一事务
+func finishMerge(merge *userMerge) error {
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := mergeRoleGrantsInSession(session, merge.SourceID, merge.TargetID); err != nil {
+		return err
+	}
+	if _, err := session.Cols("roles_moved").ID(merge.ID).Update(&userMerge{RolesMoved: true}); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 账号合并记录
+func getUserMerges(cond builder.Cond) ([]*userMerge, error) {
+	var merges = make([]*userMerge, 0)
+	if err := _Engine.Desc("create_time").Where(cond).Find(&merges); err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
@@ -35,6 +35,22 @@ var (
 		new(resource),
 		new(role),
 		new(roleResource),
+		new(attribute),
+		new(userAttribute),
+		new(userMerge),
+		new(organization),
+		new(orgMember),
+		new(orgInvite),
+		new(tenant),
+		new(inviteCode),
+		new(referral),
+		new(legal),
+		new(legalAccept),
+		new(word),
+		new(moderation),
+		new(roleGrant),
+		new(roleGrantLog),
+		new(policyCondition),
 	}
 )
 
@@ -82,6 +98,11 @@ func Init(config *config.Config) error {
 	return nil
 }
 
+// 空时间, 非空时间字段的默认值
+func zeroTime() common.DateTime {
+	return common.DateTime(time.Date(1, 1, 1, 0, 0, 0, 0, time.Local))
+}
+
 // DefauleEngine .
 func DefauleEngine() *xorm.Engine {
 	return _Engine
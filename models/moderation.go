@@ -0,0 +1,161 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// ModerationChecker 自动审核, 返回通过、拒绝或待人工审核及拒绝原因
+type ModerationChecker interface {
+	Check(userID common.ID, field, value string) (consts.ModerationStatus, string, error)
+}
+
+// 审核开关和自动审核
+var _Moderation = struct {
+	sync.RWMutex
+	enable  bool
+	checker ModerationChecker
+}{}
+
+// SetModeration 开启或关闭昵称和头像修改审核, checker为空时全部人工审核
+func SetModeration(enable bool, checker ModerationChecker) {
+	_Moderation.Lock()
+	defer _Moderation.Unlock()
+	_Moderation.enable = enable
+	_Moderation.checker = checker
+}
+
+// ApproveModeration 审核通过, 修改生效
+func ApproveModeration(tenant string, moderationID, reviewerID common.ID) (*st.ModerationDto, error) {
+	return reviewPendingModeration(tenant, moderationID, &moderation{Status: consts.ModerationApproved, ReviewerID: reviewerID})
+}
+
+// RejectModeration 审核拒绝, 保留原来的内容
+func RejectModeration(tenant string, moderationID, reviewerID common.ID, reason string) (*st.ModerationDto, error) {
+	return reviewPendingModeration(tenant, moderationID, &moderation{Status: consts.ModerationRejected, ReviewerID: reviewerID, Reason: common.Trim(reason)})
+}
+
+// GetModerations 审核记录列表
+func GetModerations(query st.ModerationQuery) (int64, []*st.ModerationDto, error) {
+	cond := builder.NewCond().And(builder.Eq{"tenant": tenantOf(query.Tenant)})
+	if query.Status != "" {
+		status, ok := consts.NewModerationStatus(query.Status)
+		if !ok {
+			return 0, nil, errors.ErrModerationStatus
+		}
+		cond = cond.And(builder.Eq{"status": status})
+	}
+	if query.Field != "" {
+		cond = cond.And(builder.Eq{"field": query.Field})
+	}
+	if query.UserID != "" {
+		cond = cond.And(builder.Eq{"user_id": common.StrToID(query.UserID)})
+	}
+	count, moderations, err := getModerations(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	moderationDtos := make([]*st.ModerationDto, len(moderations))
+	for i, moderation := range moderations {
+		moderationDtos[i] = moderation.dto()
+	}
+	return count, moderationDtos, nil
+}
+
+// GetPendingModerations 用户待审核的修改, 字段对应修改后的内容
+func GetPendingModerations(userID common.ID) (map[string]string, error) {
+	moderations, err := getPendingModerations(userID)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]string, len(moderations))
+	for _, moderation := range moderations {
+		pending[moderation.Field] = moderation.Value
+	}
+	return pending, nil
+}
+
+// 审核待审核的记录, 只能审核当前租户的
+func reviewPendingModeration(tenant string, moderationID common.ID, review *moderation) (*st.ModerationDto, error) {
+	moderation, err := getModerationByID(moderationID)
+	if err != nil {
+		return nil, err
+	}
+	if moderation.Tenant != tenantOf(tenant) {
+		return nil, errors.ErrModerationNotFound
+	}
+	if moderation.Status != consts.ModerationPending {
+		return nil, errors.ErrModerationReviewed
+	}
+	moderation.Status = review.Status
+	moderation.Reason = review.Reason
+	moderation.ReviewerID = review.ReviewerID
+	if err := reviewModeration(moderation); err != nil {
+		return nil, err
+	}
+	return moderation.dto(), nil
+}
+
+// 提交昵称或头像修改, 返回true时修改已进入审核队列, 返回false时由调用方直接更新
+// 未开启审核、内容未变化或自动审核通过时直接更新, 自动审核拒绝时返回错误
+func moderate(user *user, field, value, old string) (bool, error) {
+	_Moderation.RLock()
+	enable, checker := _Moderation.enable, _Moderation.checker
+	_Moderation.RUnlock()
+	if !enable {
+		return false, nil
+	}
+	if value == old {
+		return false, cancelModerations(user.UserID, field)
+	}
+	moderation := &moderation{
+		Tenant:   user.Tenant,
+		UserID:   user.UserID,
+		Field:    field,
+		Value:    value,
+		OldValue: old,
+		Status:   consts.ModerationPending,
+	}
+	if checker != nil {
+		status, reason, err := checker.Check(user.UserID, field, value)
+		if err != nil {
+			// 自动审核不可用时转人工审核
+			logger.Error(err)
+			status = consts.ModerationPending
+		}
+		switch status {
+		case consts.ModerationApproved:
+			return false, cancelModerations(user.UserID, field)
+		case consts.ModerationRejected:
+			moderation.Status = consts.ModerationRejected
+			moderation.Reason = reason
+			moderation.ReviewTime = common.Now()
+			if err := createModeration(moderation); err != nil {
+				return false, err
+			}
+			return false, errors.ErrModerationRejected
+		}
+	}
+	return true, createModeration(moderation)
+}
+
+func (m *moderation) dto() *st.ModerationDto {
+	return &st.ModerationDto{
+		ModerationID: m.ModerationID,
+		UserID:       m.UserID,
+		Field:        m.Field,
+		Value:        m.Value,
+		OldValue:     m.OldValue,
+		Status:       m.Status,
+		Reason:       m.Reason,
+		ReviewerID:   m.ReviewerID,
+		ReviewTime:   m.ReviewTime,
+		CreateTime:   m.CreateTime,
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 新增审核记录, 待审核时同时撤回该用户同一字段之前待审核的记录
+func createModeration(record *moderation) error {
+	moderationID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	now := common.Now()
+	record.ModerationID = moderationID
+	record.CreateTime = now
+	record.UpdateTime = now
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if record.Status == consts.ModerationPending {
+		record.ReviewTime = zeroTime()
+		canceled := &moderation{Status: consts.ModerationCanceled, UpdateTime: now}
+		if _, err := session.Cols("status", "update_time").
+			Where("user_id = ? AND field = ? AND status = ?", record.UserID, record.Field, consts.ModerationPending).
+			Update(canceled); err != nil {
+			return err
+		}
+	}
+	if _, err := session.Insert(record); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 撤回用户字段待审核的记录
+func cancelModerations(userID common.ID, field string) error {
+	canceled := &moderation{Status: consts.ModerationCanceled, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("status", "update_time").
+		Where("user_id = ? AND field = ? AND status = ?", userID, field, consts.ModerationPending).
+		Update(canceled)
+	return err
+}
+
+// 审核记录, 通过时同时更新用户信息
+func reviewModeration(moderation *moderation) error {
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	now := common.Now()
+	moderation.ReviewTime = now
+	moderation.UpdateTime = now
+	affected, err := session.Cols("status", "reason", "reviewer_id", "review_time", "update_time").
+		Where("moderation_id = ? AND status = ?", moderation.ModerationID, consts.ModerationPending).
+		Update(moderation)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.ErrModerationReviewed
+	}
+	if moderation.Status == consts.ModerationApproved {
+		userInfo := new(userInfo)
+		switch moderation.Field {
+		case consts.ModerationFieldNickname:
+			userInfo.Nickname = moderation.Value
+		case consts.ModerationFieldAvatar:
+			userInfo.Avatar = moderation.Value
+		}
+		if _, err := session.Cols(moderation.Field).Where("user_id = ?", moderation.UserID).Update(userInfo); err != nil {
+			return err
+		}
+		user := &user{UpdateTime: now}
+		if _, err := session.Cols("update_time").Where("user_id = ?", moderation.UserID).Update(user); err != nil {
+			return err
+		}
+	}
+	return session.Commit()
+}
+
+// 根据编号获取审核记录
+func getModerationByID(moderationID common.ID) (*moderation, error) {
+	moderation := new(moderation)
+	has, err := _Engine.Where("moderation_id = ?", moderationID).Get(moderation)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrModerationNotFound
+	}
+	return moderation, nil
+}
+
+// 审核记录列表
+func getModerations(cond builder.Cond, page, limit int) (int64, []*moderation, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var moderations = make([]*moderation, 0)
+	count, err := _Engine.Desc("id").Where(cond).Limit(limit, start).FindAndCount(&moderations)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, moderations, nil
+}
+
+// 用户待审核的记录
+func getPendingModerations(userID common.ID) ([]*moderation, error) {
+	var moderations = make([]*moderation, 0)
+	err := _Engine.Where("user_id = ? AND status = ?", userID, consts.ModerationPending).Find(&moderations)
+	return moderations, err
+}
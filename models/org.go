@@ -0,0 +1,343 @@
+package models
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// CreateOrg 创建组织, 创建者为所有者
+func CreateOrg(userID common.ID, orgDto *st.OrgDto) (common.ID, error) {
+	if err := checkOrg(orgDto); err != nil {
+		return 0, err
+	}
+	user, err := getUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	org := &organization{Tenant: user.Tenant, Name: orgDto.Name, Description: orgDto.Description, OwnerID: userID}
+	if err := createOrg(org); err != nil {
+		return 0, err
+	}
+	return org.OrgID, nil
+}
+
+// UpdateOrg 更新组织
+func UpdateOrg(orgID common.ID, orgDto *st.OrgDto) error {
+	if err := checkOrg(orgDto); err != nil {
+		return err
+	}
+	if _, err := getOrgByID(orgID); err != nil {
+		return err
+	}
+	org := &organization{Name: orgDto.Name, Description: orgDto.Description}
+	return updateOrg(orgID, org)
+}
+
+// DelOrg 删除组织及成员、邀请
+func DelOrg(orgID common.ID) error {
+	if _, err := getOrgByID(orgID); err != nil {
+		return err
+	}
+	return deleteOrg(orgID)
+}
+
+// GetOrg 组织信息
+func GetOrg(orgID common.ID) (*st.OrgDto, error) {
+	org, err := getOrgByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return org.dto(""), nil
+}
+
+// GetOrgs 组织列表
+func GetOrgs(query st.OrgQuery) (int64, []*st.OrgDto, error) {
+	cond := builder.And(builder.Eq{"tenant": tenantOf(query.Tenant), "status": consts.Normal})
+	if common.Trim(query.Name) != "" {
+		cond = cond.And(builder.Like{"name", query.Name + "%"})
+	}
+	count, orgs, err := getOrgs(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	orgDtos := make([]*st.OrgDto, len(orgs))
+	for i, org := range orgs {
+		orgDtos[i] = org.dto("")
+	}
+	return count, orgDtos, nil
+}
+
+// GetOrgsForUser 用户加入的组织
+func GetOrgsForUser(userID common.ID) ([]*st.OrgDto, error) {
+	members, err := getOrgMembers(builder.Eq{"user_id": userID}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	orgDtos := make([]*st.OrgDto, 0, len(members))
+	for _, member := range members {
+		org, err := getOrgByID(member.OrgID)
+		if err == errors.ErrOrgNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		orgDtos = append(orgDtos, org.dto(member.Role))
+	}
+	return orgDtos, nil
+}
+
+// GetOrgRolesForUser 用户在各组织中的角色, 用于令牌
+func GetOrgRolesForUser(userID common.ID) (map[string]consts.OrgRole, error) {
+	members, err := getOrgMembers(builder.Eq{"user_id": userID}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]consts.OrgRole, len(members))
+	for _, member := range members {
+		roles[member.OrgID.Str()] = member.Role
+	}
+	return roles, nil
+}
+
+// GetOrgRole 用户在组织中的角色
+func GetOrgRole(orgID, userID common.ID) (consts.OrgRole, error) {
+	if _, err := getOrgByID(orgID); err != nil {
+		return "", err
+	}
+	member, err := getOrgMember(orgID, userID)
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// GetOrgMembers 组织成员列表
+func GetOrgMembers(orgID common.ID, query st.EmptyQuery) (int64, []*st.OrgMemberDto, error) {
+	if _, err := getOrgByID(orgID); err != nil {
+		return 0, nil, err
+	}
+	cond := builder.Eq{"org_id": orgID}
+	count, err := getOrgMemberCount(cond)
+	if err != nil {
+		return 0, nil, err
+	}
+	members, err := getOrgMembers(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	memberDtos := make([]*st.OrgMemberDto, len(members))
+	for i, member := range members {
+		memberDtos[i] = &st.OrgMemberDto{UserID: member.UserID, Role: member.Role, CreateTime: member.CreateTime}
+		if userInfo, err := getUserInfoByID(member.UserID); err == nil {
+			memberDtos[i].Nickname = userInfo.Nickname
+			memberDtos[i].Avatar = userInfo.Avatar
+		}
+	}
+	return count, memberDtos, nil
+}
+
+// AddOrgMember 添加组织成员
+func AddOrgMember(orgID, userID common.ID, role consts.OrgRole) error {
+	if role != consts.OrgAdmin && role != consts.OrgMember {
+		return errors.ErrOrgRole
+	}
+	org, err := getOrgByID(orgID)
+	if err != nil {
+		return err
+	}
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Tenant != org.Tenant {
+		return errors.ErrUserNotExist
+	}
+	if _, err := getOrgMember(orgID, userID); err == nil {
+		return errors.ErrOrgMemberExist
+	} else if err != errors.ErrOrgNotMember {
+		return err
+	}
+	return createOrgMember(&orgMember{OrgID: orgID, UserID: userID, Role: role}, nil)
+}
+
+// SetOrgMemberRole 设置成员角色, 所有者通过转让变更
+func SetOrgMemberRole(orgID, userID common.ID, role consts.OrgRole) error {
+	if role != consts.OrgAdmin && role != consts.OrgMember {
+		return errors.ErrOrgRole
+	}
+	current, err := GetOrgRole(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if current == consts.OrgOwner {
+		return errors.ErrOrgOwner
+	}
+	return updateOrgMemberRole(orgID, userID, role)
+}
+
+// RemoveOrgMember 移除组织成员, 只能移除角色级别低于operator的成员
+func RemoveOrgMember(orgID, userID common.ID, operator consts.OrgRole) error {
+	role, err := GetOrgRole(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if role == consts.OrgOwner {
+		return errors.ErrOrgOwner
+	}
+	if role.Level() >= operator.Level() {
+		return errors.ErrOrgMemberRole
+	}
+	return deleteOrgMember(orgID, userID)
+}
+
+// LeaveOrg 退出组织
+func LeaveOrg(orgID, userID common.ID) error {
+	role, err := GetOrgRole(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if role == consts.OrgOwner {
+		return errors.ErrOrgOwner
+	}
+	return deleteOrgMember(orgID, userID)
+}
+
+// TransferOrg 转让组织, 原所有者成为管理员
+func TransferOrg(orgID, userID common.ID) error {
+	org, err := getOrgByID(orgID)
+	if err != nil {
+		return err
+	}
+	if org.OwnerID == userID {
+		return errors.ErrOrgMemberRole
+	}
+	if _, err := getOrgMember(orgID, userID); err != nil {
+		return err
+	}
+	return transferOrg(orgID, org.OwnerID, userID)
+}
+
+// CreateOrgInvite 创建组织邀请
+func CreateOrgInvite(orgID, inviterID common.ID, role consts.OrgRole) (*st.OrgInviteDto, error) {
+	if role != consts.OrgAdmin && role != consts.OrgMember {
+		return nil, errors.ErrOrgRole
+	}
+	if _, err := getOrgByID(orgID); err != nil {
+		return nil, err
+	}
+	invite := &orgInvite{
+		OrgID:      orgID,
+		Code:       common.RandomString(32),
+		Role:       role,
+		InviterID:  inviterID,
+		ExpireTime: common.DateTime(time.Now().AddDate(0, 0, consts.OrgInviteExpire)),
+	}
+	if err := createOrgInvite(invite); err != nil {
+		return nil, err
+	}
+	return invite.dto(), nil
+}
+
+// GetOrgInvites 组织未使用的邀请
+func GetOrgInvites(orgID common.ID) ([]*st.OrgInviteDto, error) {
+	invites, err := getOrgInvites(builder.Eq{"org_id": orgID, "status": consts.Normal, "accept_id": 0}.
+		And(builder.Gt{"expire_time": time.Now()}))
+	if err != nil {
+		return nil, err
+	}
+	inviteDtos := make([]*st.OrgInviteDto, len(invites))
+	for i, invite := range invites {
+		inviteDtos[i] = invite.dto()
+	}
+	return inviteDtos, nil
+}
+
+// RevokeOrgInvite 撤销组织邀请
+func RevokeOrgInvite(orgID, inviteID common.ID) error {
+	invite, err := getOrgInviteByID(inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.OrgID != orgID {
+		return errors.ErrOrgInviteNotFound
+	}
+	return revokeOrgInvite(inviteID)
+}
+
+// AcceptOrgInvite 接受组织邀请
+func AcceptOrgInvite(userID common.ID, code string) (*st.OrgDto, error) {
+	invite, err := getOrgInviteByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if invite.AcceptID > 0 || time.Now().After(time.Time(invite.ExpireTime)) {
+		return nil, errors.ErrOrgInviteNotFound
+	}
+	org, err := getOrgByID(invite.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := getUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// 邀请只对同一租户的用户有效
+	if user.Tenant != org.Tenant {
+		return nil, errors.ErrOrgInviteNotFound
+	}
+	if _, err := getOrgMember(invite.OrgID, userID); err == nil {
+		return nil, errors.ErrOrgMemberExist
+	} else if err != errors.ErrOrgNotMember {
+		return nil, err
+	}
+	member := &orgMember{OrgID: invite.OrgID, UserID: userID, Role: invite.Role}
+	if err := createOrgMember(member, invite); err != nil {
+		return nil, err
+	}
+	return org.dto(invite.Role), nil
+}
+
+func checkOrg(orgDto *st.OrgDto) error {
+	if orgDto == nil {
+		return errors.ErrArgument
+	}
+	orgDto.Name = common.Trim(orgDto.Name)
+	orgDto.Description = common.Trim(orgDto.Description)
+	if common.IsEmpty(orgDto.Name) || utf8.RuneCountInString(orgDto.Name) > 30 {
+		return errors.ErrOrgName
+	}
+	if utf8.RuneCountInString(orgDto.Description) > 255 {
+		return errors.ErrArgument
+	}
+	return nil
+}
+
+func (o *organization) dto(role consts.OrgRole) *st.OrgDto {
+	return &st.OrgDto{
+		OrgID:       o.OrgID,
+		Tenant:      o.Tenant,
+		Name:        o.Name,
+		Description: o.Description,
+		OwnerID:     o.OwnerID,
+		CreateTime:  o.CreateTime,
+		Role:        role,
+	}
+}
+
+func (i *orgInvite) dto() *st.OrgInviteDto {
+	return &st.OrgInviteDto{
+		InviteID:   i.InviteID,
+		OrgID:      i.OrgID,
+		Code:       i.Code,
+		Role:       i.Role,
+		InviterID:  i.InviterID,
+		ExpireTime: i.ExpireTime,
+		CreateTime: i.CreateTime,
+	}
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 新增组织, 同时添加所有者
+func createOrg(org *organization) error {
+	orgID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	org.OrgID = orgID
+	org.Status = consts.Normal
+	org.CreateTime = common.Now()
+	org.UpdateTime = org.CreateTime
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(org); err != nil {
+		return err
+	}
+	owner := &orgMember{OrgID: orgID, UserID: org.OwnerID, Role: consts.OrgOwner, CreateTime: org.CreateTime, UpdateTime: org.CreateTime}
+	if _, err := session.Insert(owner); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 更新组织
+func updateOrg(orgID common.ID, org *organization) error {
+	org.UpdateTime = common.Now()
+	_, err := _Engine.Cols("name", "description", "update_time").Where("org_id = ?", orgID).Update(org)
+	return err
+}
+
+// 删除组织
+func deleteOrg(orgID common.ID) error {
+	now := common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := deleteOrgInSession(session, orgID, now); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 在事务中删除组织及成员、邀请
+func deleteOrgInSession(session *xorm.Session, orgID common.ID, now common.DateTime) error {
+	org := &organization{Status: consts.Delete, UpdateTime: now}
+	if _, err := session.Cols("status", "update_time").Where("org_id = ?", orgID).Update(org); err != nil {
+		return err
+	}
+	if _, err := session.Where("org_id = ?", orgID).Delete(new(orgMember)); err != nil {
+		return err
+	}
+	invite := &orgInvite{Status: consts.Delete, UpdateTime: now}
+	_, err := session.Cols("status", "update_time").Where("org_id = ?", orgID).Update(invite)
+	return err
+}
+
+// 根据编号获取组织
+func getOrgByID(orgID common.ID) (*organization, error) {
+	org := new(organization)
+	has, err := _Engine.Where("org_id = ? AND status = ?", orgID, consts.Normal).Get(org)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.ErrOrgNotFound
+	}
+	return org, nil
+}
+
+// 组织列表
+func getOrgs(cond builder.Cond, page, limit int) (int64, []*organization, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var orgs = make([]*organization, 0)
+	count, err := _Engine.Desc("create_time").Where(cond).Limit(limit, start).FindAndCount(&orgs)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, orgs, nil
+}
+
+// 组织成员
+func getOrgMember(orgID, userID common.ID) (*orgMember, error) {
+	member := new(orgMember)
+	has, err := _Engine.Where("org_id = ? AND user_id = ?", orgID, userID).Get(member)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.ErrOrgNotMember
+	}
+	return member, nil
+}
+
+// 组织成员数量
+func getOrgMemberCount(cond builder.Cond) (int64, error) {
+	return _Engine.Where(cond).Count(new(orgMember))
+}
+
+// 组织成员列表, limit为0时返回全部
+func getOrgMembers(cond builder.Cond, page, limit int) ([]*orgMember, error) {
+	var members = make([]*orgMember, 0)
+	session := _Engine.Asc("create_time").Where(cond)
+	if limit > 0 {
+		if page <= 0 {
+			page = 1
+		}
+		session = session.Limit(limit, (page-1)*limit)
+	}
+	if err := session.Find(&members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// 新增组织成员, 通过邀请加入时同时标记邀请已使用
+func createOrgMember(member *orgMember, invite *orgInvite) error {
+	member.CreateTime = common.Now()
+	member.UpdateTime = member.CreateTime
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(member); err != nil {
+		return err
+	}
+	if invite != nil {
+		accepted := &orgInvite{AcceptID: member.UserID, UpdateTime: member.CreateTime}
+		n, err := session.Cols("accept_id", "update_time").Where("invite_id = ? AND accept_id = 0", invite.InviteID).Update(accepted)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.ErrOrgInviteNotFound
+		}
+	}
+	return session.Commit()
+}
+
+// 更新成员角色
+func updateOrgMemberRole(orgID, userID common.ID, role consts.OrgRole) error {
+	member := &orgMember{Role: role, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("role", "update_time").Where("org_id = ? AND user_id = ?", orgID, userID).Update(member)
+	return err
+}
+
+// 删除组织成员
+func deleteOrgMember(orgID, userID common.ID) error {
+	_, err := _Engine.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(new(orgMember))
+	return err
+}
+
+// 转让组织
+func transferOrg(orgID, ownerID, userID common.ID) error {
+	now := common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	org := &organization{OwnerID: userID, UpdateTime: now}
+	if _, err := session.Cols("owner_id", "update_time").Where("org_id = ?", orgID).Update(org); err != nil {
+		return err
+	}
+	admin := &orgMember{Role: consts.OrgAdmin, UpdateTime: now}
+	if _, err := session.Cols("role", "update_time").Where("org_id = ? AND user_id = ?", orgID, ownerID).Update(admin); err != nil {
+		return err
+	}
+	owner := &orgMember{Role: consts.OrgOwner, UpdateTime: now}
+	if _, err := session.Cols("role", "update_time").Where("org_id = ? AND user_id = ?", orgID, userID).Update(owner); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 新增组织邀请
+func createOrgInvite(invite *orgInvite) error {
+	inviteID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	invite.InviteID = inviteID
+	invite.Status = consts.Normal
+	invite.CreateTime = common.Now()
+	invite.UpdateTime = invite.CreateTime
+	_, err = _Engine.Insert(invite)
+	return err
+}
+
+// 根据编号获取邀请
+func getOrgInviteByID(inviteID common.ID) (*orgInvite, error) {
+	return getOrgInvite(builder.Eq{"invite_id": inviteID, "status": consts.Normal})
+}
+
+// 根据邀请码获取邀请
+func getOrgInviteByCode(code string) (*orgInvite, error) {
+	return getOrgInvite(builder.Eq{"code": code, "status": consts.Normal})
+}
+
+func getOrgInvite(cond builder.Cond) (*orgInvite, error) {
+	invite := new(orgInvite)
+	has, err := _Engine.Where(cond).Get(invite)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.ErrOrgInviteNotFound
+	}
+	return invite, nil
+}
+
+// 组织邀请列表
+func getOrgInvites(cond builder.Cond) ([]*orgInvite, error) {
+	var invites = make([]*orgInvite, 0)
+	if err := _Engine.Desc("create_time").Where(cond).Find(&invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// 撤销组织邀请
+func revokeOrgInvite(inviteID common.ID) error {
+	invite := &orgInvite{Status: consts.Delete, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("status", "update_time").Where("invite_id = ?", inviteID).Update(invite)
+	return err
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+const (
+	cateRegister = "register"
+	tpRegister   = "setting"
+)
+
+// 内置的一次性邮箱域名
+var _DisposableDomains = map[string]bool{
+	"10minutemail.com":   true,
+	"20minutemail.com":   true,
+	"33mail.com":         true,
+	"anonbox.net":        true,
+	"burnermail.io":      true,
+	"discard.email":      true,
+	"dispostable.com":    true,
+	"dropmail.me":        true,
+	"emailondeck.com":    true,
+	"fakeinbox.com":      true,
+	"fakemail.net":       true,
+	"getairmail.com":     true,
+	"getnada.com":        true,
+	"guerrillamail.biz":  true,
+	"guerrillamail.com":  true,
+	"guerrillamail.de":   true,
+	"guerrillamail.info": true,
+	"guerrillamail.net":  true,
+	"guerrillamail.org":  true,
+	"harakirimail.com":   true,
+	"incognitomail.org":  true,
+	"inboxkitten.com":    true,
+	"jetable.org":        true,
+	"linshiyouxiang.net": true,
+	"mailcatch.com":      true,
+	"maildrop.cc":        true,
+	"mailinator.com":     true,
+	"mailinator.net":     true,
+	"mailnesia.com":      true,
+	"mailpoof.com":       true,
+	"mintemail.com":      true,
+	"moakt.com":          true,
+	"mohmal.com":         true,
+	"mytemp.email":       true,
+	"mytrashmail.com":    true,
+	"nada.email":         true,
+	"sharklasers.com":    true,
+	"spambog.com":        true,
+	"spamgourmet.com":    true,
+	"spam4.me":           true,
+	"temp-mail.io":       true,
+	"temp-mail.org":      true,
+	"tempail.com":        true,
+	"tempmail.com":       true,
+	"tempmail.net":       true,
+	"tempmailo.com":      true,
+	"tempr.email":        true,
+	"throwawaymail.com":  true,
+	"trashmail.com":      true,
+	"trashmail.de":       true,
+	"trashmail.net":      true,
+	"yopmail.com":        true,
+	"yopmail.fr":         true,
+	"yopmail.net":        true,
+}
+
+// GetRegisterSetting 租户的注册设置, 未配置时开放所有注册方式
+func GetRegisterSetting(tenant string) (*st.RegisterSettingDto, error) {
+	setting := new(st.RegisterSettingDto)
+	dictDto, err := GetOneDict(tenant, cateRegister, tpRegister)
+	if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrDictNotFound.Code() {
+		return setting, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := common.FromJSON([]byte(dictDto.Value), setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// CheckRegister 检查注册是否开放及注册方式是否可用
+func CheckRegister(tenant, mode string) error {
+	setting, err := GetRegisterSetting(tenant)
+	if err != nil {
+		return err
+	}
+	if setting.Closed {
+		return errors.ErrRegisterClosed
+	}
+	for _, disabled := range setting.Disabled {
+		if strings.EqualFold(common.Trim(disabled), mode) {
+			return errors.ErrRegisterMode
+		}
+	}
+	return nil
+}
+
+// 检查邮箱域名, 先匹配允许列表再匹配禁止列表, 子域名按上级域名匹配
+func checkEmailDomain(tenant, email string) error {
+	setting, err := GetRegisterSetting(tenant)
+	if err != nil {
+		return err
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if len(setting.AllowDomains) > 0 && !matchDomain(domain, setting.AllowDomains) {
+		return errors.ErrEmailDomain
+	}
+	if matchDomain(domain, setting.BlockDomains) {
+		return errors.ErrEmailDomain
+	}
+	if setting.BlockDisposable {
+		for d := domain; d != ""; {
+			if _DisposableDomains[d] {
+				return errors.ErrEmailDomain
+			}
+			i := strings.Index(d, ".")
+			if i < 0 {
+				break
+			}
+			d = d[i+1:]
+		}
+	}
+	return nil
+}
+
+func matchDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(common.Trim(d), "@"))
+		if d == "" {
+			continue
+		}
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
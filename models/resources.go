@@ -1,20 +1,46 @@
 package models
 
 import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/convert"
+	"github.com/ihuanglei/authenticator/pkg/errors"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/simplexwork/common"
 	"xorm.io/builder"
 )
 
-// GetResources 获取资源数据
+// 资源支持的方法
+var _ResourceMethods = map[string]bool{"get": true, "post": true, "put": true, "patch": true, "delete": true}
+
+// 域与租户代码的规则相同, 空为管理后台
+var domainRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{1,31}$`)
+
+// CheckDomain 校验域, 空为管理后台
+func CheckDomain(domain string) error {
+	if domain != "" && !domainRegexp.MatchString(domain) {
+		return errors.ErrDomain
+	}
+	return nil
+}
+
+// GetResources 获取域中的资源数据
 func GetResources(query st.ResourceQuery) (int64, []*st.ResourceDto, error) {
 	page := query.Page
 	limit := query.Limit
-	cond := builder.NewCond()
+	cond := builder.NewCond().And(builder.Eq{"domain": common.Trim(query.Domain)})
 	if common.Trim(query.Name) != "" {
 		cond = cond.And(builder.Like{"name", query.Name + "%"})
 	}
+	if query.Source != "" {
+		cond = cond.And(builder.Eq{"source": query.Source})
+	}
+	if query.Stale {
+		cond = cond.And(builder.Eq{"stale": true})
+	}
 	count, resources, err := getResources(cond, page, limit)
 	if err != nil {
 		return 0, nil, err
@@ -41,3 +67,165 @@ func GetResourcesByIDs(ids []common.ID) ([]*st.ResourceDto, error) {
 	}
 	return resourceDtos, nil
 }
+
+// SyncResources 将已注册的路由同步到管理后台的资源, 返回新增和标记为不存在的数量
+// 地址和方法相同的自定义资源转为路由资源, 已不存在的路由资源标记后保留, 由管理员确认删除
+func SyncResources(routes []*st.ResourceDto) (int, int, error) {
+	resources, err := getAllResources(builder.Eq{"domain": ""})
+	if err != nil {
+		return 0, 0, err
+	}
+	existing := make(map[string]*resource, len(resources))
+	for _, res := range resources {
+		existing[res.Method+" "+res.URL] = res
+	}
+	var inserts, updates []*resource
+	seen := map[int64]bool{}
+	for _, route := range routes {
+		name := truncate(route.Name, 30)
+		res, ok := existing[route.Method+" "+route.URL]
+		if !ok {
+			inserts = append(inserts, &resource{Name: name, URL: route.URL, Method: route.Method, Source: consts.ResourceRoute})
+			continue
+		}
+		seen[res.ID] = true
+		if name == "" {
+			name = res.Name
+		}
+		if res.Source != consts.ResourceRoute || res.Stale || res.Name != name {
+			res.Source, res.Stale, res.Name = consts.ResourceRoute, false, name
+			updates = append(updates, res)
+		}
+	}
+	stale := 0
+	for _, res := range resources {
+		if res.Source == consts.ResourceRoute && !seen[res.ID] && !res.Stale {
+			res.Stale = true
+			updates = append(updates, res)
+			stale++
+		}
+	}
+	if len(inserts) == 0 && len(updates) == 0 {
+		return 0, 0, nil
+	}
+	return len(inserts), stale, syncResources(inserts, updates)
+}
+
+// CreateResource 创建自定义资源
+func CreateResource(resourceDto *st.ResourceDto) (int64, error) {
+	res, err := checkResource(resourceDto)
+	if err != nil {
+		return 0, err
+	}
+	res.Domain = common.Trim(resourceDto.Domain)
+	if err := CheckDomain(res.Domain); err != nil {
+		return 0, err
+	}
+	if err := checkResourceExist(0, res); err != nil {
+		return 0, err
+	}
+	res.Source = consts.ResourceCustom
+	if err := createResource(res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// UpdateResource 更新自定义资源, 已分配给角色的资源只能修改名称
+func UpdateResource(id int64, resourceDto *st.ResourceDto) error {
+	old, err := getResourceByID(id)
+	if err != nil {
+		return err
+	}
+	if old.Source != consts.ResourceCustom {
+		return errors.ErrResourceRoute
+	}
+	res, err := checkResource(resourceDto)
+	if err != nil {
+		return err
+	}
+	res.Domain = old.Domain
+	if res.URL != old.URL || res.Method != old.Method {
+		if err := checkResourceExist(id, res); err != nil {
+			return err
+		}
+		if err := checkResourceUnused(old); err != nil {
+			return err
+		}
+	}
+	return updateResource(id, res)
+}
+
+// DelResource 删除自定义资源或已不存在的路由资源, 已分配给角色的不能删除
+func DelResource(id int64) error {
+	res, err := getResourceByID(id)
+	if err != nil {
+		return err
+	}
+	if res.Source != consts.ResourceCustom && !res.Stale {
+		return errors.ErrResourceRoute
+	}
+	if err := checkResourceUnused(res); err != nil {
+		return err
+	}
+	return deleteResource(id)
+}
+
+// 校验资源, 方法统一为小写
+func checkResource(resourceDto *st.ResourceDto) (*resource, error) {
+	res := &resource{
+		Name:   common.Trim(resourceDto.Name),
+		URL:    common.Trim(resourceDto.URL),
+		Method: strings.ToLower(common.Trim(resourceDto.Method)),
+	}
+	if res.Name == "" || utf8.RuneCountInString(res.Name) > 30 {
+		return nil, errors.ErrResource
+	}
+	if !strings.HasPrefix(res.URL, "/") || len(res.URL) > 90 || !_ResourceMethods[res.Method] {
+		return nil, errors.ErrResource
+	}
+	return res, nil
+}
+
+// 同一个域中地址和方法相同的资源是否已存在
+func checkResourceExist(id int64, res *resource) error {
+	count, err := getResourceCount(builder.Eq{"domain": res.Domain, "url": res.URL, "method": res.Method}.And(builder.Neq{"id": id}))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.ErrResourceExist
+	}
+	return nil
+}
+
+// 资源是否已分配给有效的角色
+func checkResourceUnused(res *resource) error {
+	_, roleResources, err := getRoleResources(builder.Eq{"domain": res.Domain, "url": res.URL, "method": res.Method})
+	if err != nil {
+		return err
+	}
+	if len(roleResources) == 0 {
+		return nil
+	}
+	roleIDs := make([]common.ID, len(roleResources))
+	for i, roleResource := range roleResources {
+		roleIDs[i] = roleResource.RoleID
+	}
+	count, err := getRoleCount(builder.Eq{"status": consts.Normal}.And(builder.In("role_id", roleIDs)))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.ErrResourceInUse
+	}
+	return nil
+}
+
+// 按字符截断
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
 	"xorm.io/builder"
 )
 
@@ -20,3 +22,71 @@ func getResources(cond builder.Cond, page, limit int) (int64, []*resource, error
 	}
 	return count, resources, nil
 }
+
+// 全部资源
+func getAllResources(cond builder.Cond) ([]*resource, error) {
+	var resources = make([]*resource, 0)
+	err := _Engine.Where(cond).Find(&resources)
+	return resources, err
+}
+
+// 资源数量
+func getResourceCount(cond builder.Cond) (int64, error) {
+	return _Engine.Where(cond).Count(new(resource))
+}
+
+// 根据编号获取资源
+func getResourceByID(id int64) (*resource, error) {
+	res := new(resource)
+	has, err := _Engine.Where("id = ?", id).Get(res)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrResourceNotFound
+	}
+	return res, nil
+}
+
+// 新增资源
+func createResource(res *resource) error {
+	res.CreateTime = common.Now()
+	_, err := _Engine.Insert(res)
+	return err
+}
+
+// 更新资源
+func updateResource(id int64, res *resource) error {
+	_, err := _Engine.Cols("name", "url", "method").Where("id = ?", id).Update(res)
+	return err
+}
+
+// 删除资源
+func deleteResource(id int64) error {
+	_, err := _Engine.Where("id = ?", id).Delete(new(resource))
+	return err
+}
+
+// 同步路由资源
+func syncResources(inserts, updates []*resource) error {
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if len(inserts) > 0 {
+		now := common.Now()
+		for _, res := range inserts {
+			res.CreateTime = now
+		}
+		if _, err := session.Insert(&inserts); err != nil {
+			return err
+		}
+	}
+	for _, res := range updates {
+		if _, err := session.Cols("name", "source", "stale").Where("id = ?", res.ID).Update(res); err != nil {
+			return err
+		}
+	}
+	return session.Commit()
+}
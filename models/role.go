@@ -9,9 +9,15 @@ import (
 	"xorm.io/builder"
 )
 
-// CreateRole 创建角色
-func CreateRole(name string, resource []*st.ResourceDto) (common.ID, error) {
-	has, err := HasRoleByName(name)
+// CreateRole 创建角色, 资源必须属于角色所在的域
+func CreateRole(domain, name string, resource []*st.ResourceDto) (common.ID, error) {
+	if err := CheckDomain(domain); err != nil {
+		return 0, err
+	}
+	if err := checkRoleResources(domain, resource); err != nil {
+		return 0, err
+	}
+	has, err := HasRoleByName(domain, name)
 	if err != nil {
 		return 0, err
 	}
@@ -23,7 +29,7 @@ func CreateRole(name string, resource []*st.ResourceDto) (common.ID, error) {
 	if err != nil {
 		return 0, err
 	}
-	return createRole(name, roleRess)
+	return createRole(domain, name, roleRess)
 }
 
 // UpdataRole 更新角色
@@ -35,8 +41,11 @@ func UpdataRole(roleID common.ID, name string, resource []*st.ResourceDto) error
 	if role == nil {
 		return errors.ErrRoleNotFound
 	}
+	if err := checkRoleResources(role.Domain, resource); err != nil {
+		return err
+	}
 	if role.Name != name {
-		has, err := HasRoleByName(name)
+		has, err := HasRoleByName(role.Domain, name)
 		if err != nil {
 			return err
 		}
@@ -54,11 +63,21 @@ func UpdataRole(roleID common.ID, name string, resource []*st.ResourceDto) error
 	return updateRole(roleID, name, roleRess)
 }
 
+// 资源必须属于角色所在的域
+func checkRoleResources(domain string, resource []*st.ResourceDto) error {
+	for _, res := range resource {
+		if res.Domain != domain {
+			return errors.ErrRoleDomain
+		}
+	}
+	return nil
+}
+
 // GetRoles .
 func GetRoles(query st.RoleQuery) (int64, []*st.RoleDto, error) {
 	page := query.Page
 	limit := query.Limit
-	cond := builder.And(builder.Eq{"status": consts.Normal})
+	cond := builder.And(builder.Eq{"status": consts.Normal, "domain": common.Trim(query.Domain)})
 	if common.Trim(query.Name) != "" {
 		cond = cond.And(builder.Like{"name", query.Name + "%"})
 	}
@@ -74,6 +93,19 @@ func GetRoles(query st.RoleQuery) (int64, []*st.RoleDto, error) {
 	return count, roleDtos, nil
 }
 
+// GetRoleNames 角色编号与名称对应关系
+func GetRoleNames() (map[string]string, error) {
+	roles, err := getAllRoles(builder.Eq{"status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(roles))
+	for _, role := range roles {
+		names[role.RoleID.Str()] = role.Name
+	}
+	return names, nil
+}
+
 // GetRoleResourceByID .
 func GetRoleResourceByID(roleID common.ID) (int64, []*st.RoleResourceDto, error) {
 	count, roleResource, err := getRoleResources(builder.Eq{"role_id": roleID})
@@ -88,9 +120,70 @@ func GetRoleResourceByID(roleID common.ID) (int64, []*st.RoleResourceDto, error)
 	return count, roleResourceDtos, nil
 }
 
-// HasRoleByName .
-func HasRoleByName(name string) (bool, error) {
-	count, err := getRoleCount(builder.Eq{"name": name, "status": consts.Normal})
+// GetRoleByID 获取有效的角色
+func GetRoleByID(roleID common.ID) (*st.RoleDto, error) {
+	role, err := getRole(builder.Eq{"role_id": roleID, "status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	return &st.RoleDto{RoleID: role.RoleID, Domain: role.Domain, Name: role.Name}, nil
+}
+
+// GetRolesByIDs 获取有效的角色, 按编号顺序返回, 不存在或已删除的忽略
+func GetRolesByIDs(roleIDs []common.ID) ([]*st.RoleDto, error) {
+	if len(roleIDs) == 0 {
+		return []*st.RoleDto{}, nil
+	}
+	roles, err := getAllRoles(builder.Eq{"status": consts.Normal}.And(builder.In("role_id", roleIDs)))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[common.ID]*role, len(roles))
+	for _, role := range roles {
+		found[role.RoleID] = role
+	}
+	roleDtos := make([]*st.RoleDto, 0, len(roles))
+	for _, roleID := range roleIDs {
+		if role, ok := found[roleID]; ok {
+			roleDtos = append(roleDtos, &st.RoleDto{RoleID: roleID, Domain: role.Domain, Name: role.Name})
+		}
+	}
+	return roleDtos, nil
+}
+
+// GetInheritedResources 上级角色的资源, 按角色顺序返回
+func GetInheritedResources(roles []*st.RoleDto) ([]*st.InheritedResourceDto, error) {
+	resources := make([]*st.InheritedResourceDto, 0)
+	if len(roles) == 0 {
+		return resources, nil
+	}
+	roleIDs := make([]common.ID, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.RoleID
+	}
+	_, roleResources, err := getRoleResources(builder.In("role_id", roleIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		for _, res := range roleResources {
+			if res.RoleID == role.RoleID {
+				resources = append(resources, &st.InheritedResourceDto{
+					RoleID:   role.RoleID,
+					RoleName: role.Name,
+					Name:     res.Name,
+					URL:      res.URL,
+					Method:   res.Method,
+				})
+			}
+		}
+	}
+	return resources, nil
+}
+
+// HasRoleByName 域中是否已有同名角色
+func HasRoleByName(domain, name string) (bool, error) {
+	count, err := getRoleCount(builder.Eq{"domain": domain, "name": name, "status": consts.Normal})
 	if err != nil {
 		return false, err
 	}
@@ -8,7 +8,7 @@ import (
 )
 
 // 新增角色
-func createRole(name string, roleRess []*roleResource) (common.ID, error) {
+func createRole(domain, name string, roleRess []*roleResource) (common.ID, error) {
 	roleID, err := _IDWorker.Next()
 	if err != nil {
 		return 0, err
@@ -16,6 +16,7 @@ func createRole(name string, roleRess []*roleResource) (common.ID, error) {
 
 	var role role
 	role.RoleID = roleID
+	role.Domain = domain
 	role.Name = name
 	role.CreateTime = common.Now()
 	role.UpdateTime = role.CreateTime
@@ -73,6 +74,9 @@ func updateRole(roleID common.ID, name string, roleRess []*roleResource) error {
 	if err != nil {
 		return err
 	}
+	if err := prunePolicyConditions(session, roleID, roleRess); err != nil {
+		return err
+	}
 	return session.Commit()
 }
 
@@ -88,6 +92,10 @@ func deleteRole(roleID common.ID) error {
 	if err != nil {
 		return err
 	}
+	_, err = session.Where("role_id = ?", roleID).Delete(new(policyCondition))
+	if err != nil {
+		return err
+	}
 	return session.Commit()
 }
 
@@ -131,6 +139,15 @@ func getRoles(cond builder.Cond, page, limit int) (int64, []*role, error) {
 	return count, roles, nil
 }
 
+// 获取全部角色
+func getAllRoles(cond builder.Cond) ([]*role, error) {
+	var roles = make([]*role, 0)
+	if err := _Engine.Omit("id").Where(cond).Find(&roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
 // 获取角色资源
 func getRoleResources(cond builder.Cond) (int64, []*roleResource, error) {
 	var roleResources = make([]*roleResource, 0)
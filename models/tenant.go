@@ -0,0 +1,195 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+var tenantCodeRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{1,31}$`)
+
+// 有效租户缓存, 按租户代码和域名索引
+var _TenantCache = struct {
+	sync.RWMutex
+	codes map[string]*st.TenantDto
+	hosts map[string]*st.TenantDto
+}{}
+
+// CreateTenant 创建租户
+func CreateTenant(tenantDto *st.TenantDto) (common.ID, error) {
+	tenantDto.Code = common.Trim(tenantDto.Code)
+	if !tenantCodeRegexp.MatchString(tenantDto.Code) || tenantDto.Code == consts.DefaultTenant {
+		return 0, errors.ErrTenantCode
+	}
+	count, err := getTenantCount(builder.Eq{"code": tenantDto.Code})
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, errors.ErrTenantExist
+	}
+	if err := checkTenant(0, tenantDto); err != nil {
+		return 0, err
+	}
+	tenant := &tenant{Code: tenantDto.Code, Name: tenantDto.Name, Hosts: strings.Join(tenantDto.Hosts, ","), Secret: tenantDto.Secret}
+	if err := createTenant(tenant); err != nil {
+		return 0, err
+	}
+	return tenant.TenantID, ReloadTenants()
+}
+
+// UpdateTenant 更新租户, 租户代码不允许修改, 密钥为空时不修改
+func UpdateTenant(tenantID common.ID, tenantDto *st.TenantDto) error {
+	old, err := getTenantByID(tenantID)
+	if err != nil {
+		return err
+	}
+	if err := checkTenant(tenantID, tenantDto); err != nil {
+		return err
+	}
+	tenant := &tenant{Name: tenantDto.Name, Hosts: strings.Join(tenantDto.Hosts, ","), Secret: tenantDto.Secret}
+	if tenant.Secret == "" {
+		tenant.Secret = old.Secret
+	}
+	if err := updateTenant(tenantID, tenant); err != nil {
+		return err
+	}
+	return ReloadTenants()
+}
+
+// DelTenant 删除租户, 删除后该租户的请求无法识别, 用户数据保留
+func DelTenant(tenantID common.ID) error {
+	if _, err := getTenantByID(tenantID); err != nil {
+		return err
+	}
+	if err := deleteTenant(tenantID); err != nil {
+		return err
+	}
+	return ReloadTenants()
+}
+
+// GetTenant 获取租户
+func GetTenant(tenantID common.ID) (*st.TenantDto, error) {
+	tenant, err := getTenantByID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.dto(), nil
+}
+
+// GetTenants 租户列表
+func GetTenants() ([]*st.TenantDto, error) {
+	tenants, err := getTenants(builder.Eq{"status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	tenantDtos := make([]*st.TenantDto, len(tenants))
+	for i, tenant := range tenants {
+		tenantDtos[i] = tenant.dto()
+	}
+	return tenantDtos, nil
+}
+
+// ReloadTenants 重新加载租户缓存, 租户变更后自动调用, 多实例部署时由定时任务同步
+func ReloadTenants() error {
+	tenantDtos, err := GetTenants()
+	if err != nil {
+		return err
+	}
+	codes := make(map[string]*st.TenantDto, len(tenantDtos))
+	hosts := make(map[string]*st.TenantDto)
+	for _, tenantDto := range tenantDtos {
+		codes[tenantDto.Code] = tenantDto
+		for _, host := range tenantDto.Hosts {
+			hosts[host] = tenantDto
+		}
+	}
+	_TenantCache.Lock()
+	_TenantCache.codes = codes
+	_TenantCache.hosts = hosts
+	_TenantCache.Unlock()
+	return nil
+}
+
+// LookupTenant 根据租户代码查找有效租户
+func LookupTenant(code string) (*st.TenantDto, bool) {
+	_TenantCache.RLock()
+	defer _TenantCache.RUnlock()
+	tenantDto, ok := _TenantCache.codes[code]
+	return tenantDto, ok
+}
+
+// LookupTenantByHost 根据域名查找有效租户
+func LookupTenantByHost(host string) (*st.TenantDto, bool) {
+	_TenantCache.RLock()
+	defer _TenantCache.RUnlock()
+	tenantDto, ok := _TenantCache.hosts[strings.ToLower(host)]
+	return tenantDto, ok
+}
+
+// 校验租户名称和域名, 域名不能被其他租户使用
+func checkTenant(tenantID common.ID, tenantDto *st.TenantDto) error {
+	tenantDto.Name = common.Trim(tenantDto.Name)
+	if l := utf8.RuneCountInString(tenantDto.Name); l < 1 || l > 30 {
+		return errors.ErrTenantName
+	}
+	hosts := make([]string, 0, len(tenantDto.Hosts))
+	seen := map[string]bool{}
+	for _, host := range tenantDto.Hosts {
+		host = strings.ToLower(common.Trim(host))
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	tenantDto.Hosts = hosts
+	if len(hosts) == 0 {
+		return nil
+	}
+	tenants, err := getTenants(builder.Eq{"status": consts.Normal}.And(builder.Neq{"tenant_id": tenantID}))
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		for _, host := range tenant.hosts() {
+			if seen[host] {
+				return errors.ErrTenantHost
+			}
+		}
+	}
+	return nil
+}
+
+// 租户代码, 为空时为默认租户
+func tenantOf(code string) string {
+	if code == "" {
+		return consts.DefaultTenant
+	}
+	return code
+}
+
+func (t *tenant) hosts() []string {
+	if t.Hosts == "" {
+		return []string{}
+	}
+	return strings.Split(t.Hosts, ",")
+}
+
+func (t *tenant) dto() *st.TenantDto {
+	return &st.TenantDto{
+		TenantID:   t.TenantID,
+		Code:       t.Code,
+		Name:       t.Name,
+		Hosts:      t.hosts(),
+		Secret:     t.Secret,
+		CreateTime: t.CreateTime,
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 新增租户
+func createTenant(tenant *tenant) error {
+	tenantID, err := _IDWorker.Next()
+	if err != nil {
+		return err
+	}
+	tenant.TenantID = tenantID
+	tenant.Status = consts.Normal
+	tenant.CreateTime = common.Now()
+	tenant.UpdateTime = tenant.CreateTime
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(tenant); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 更新租户, 租户代码不允许修改
+func updateTenant(tenantID common.ID, tenant *tenant) error {
+	tenant.UpdateTime = common.Now()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("name", "hosts", "secret", "update_time").Where("tenant_id = ?", tenantID).Update(tenant); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 删除租户, 租户下的用户数据保留
+func deleteTenant(tenantID common.ID) error {
+	tenant := &tenant{Status: consts.Delete, UpdateTime: common.Now()}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("status", "update_time").Where("tenant_id = ?", tenantID).Update(tenant); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 根据编号获取租户
+func getTenantByID(tenantID common.ID) (*tenant, error) {
+	tenant := new(tenant)
+	has, err := _Engine.Where("tenant_id = ? AND status = ?", tenantID, consts.Normal).Get(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrTenantNotFound
+	}
+	return tenant, nil
+}
+
+// 租户数量, 包含已删除的租户, 租户代码不允许复用
+func getTenantCount(cond builder.Cond) (int64, error) {
+	return _Engine.Where(cond).Count(new(tenant))
+}
+
+// 租户列表
+func getTenants(cond builder.Cond) ([]*tenant, error) {
+	var tenants = make([]*tenant, 0)
+	if err := _Engine.Where(cond).Asc("id").Find(&tenants); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
@@ -13,12 +13,14 @@ type user struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
 	// 用户编号 业务主键
 	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'user_id' COMMENT('用户编号')"`
+	// 租户, 用户名、邮箱、手机在租户内唯一
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' UNIQUE(tenant_name) UNIQUE(tenant_email) UNIQUE(tenant_mobile) 'tenant' COMMENT('租户')"`
 	// 用户名
-	Name string `xorm:"VARCHAR(32) NOT NULL UNIQUE 'name' COMMENT('用户名')"`
+	Name string `xorm:"VARCHAR(32) NOT NULL UNIQUE(tenant_name) 'name' COMMENT('用户名')"`
 	// 邮箱
-	Email string `xorm:"VARCHAR(32) NOT NULL UNIQUE 'email' COMMENT('邮箱')"`
+	Email string `xorm:"VARCHAR(32) NOT NULL UNIQUE(tenant_email) 'email' COMMENT('邮箱')"`
 	// 手机
-	Mobile string `xorm:"varchar(32) NOT NULL UNIQUE 'mobile' COMMENT('手机')"`
+	Mobile string `xorm:"varchar(32) NOT NULL UNIQUE(tenant_mobile) 'mobile' COMMENT('手机')"`
 	// 密码
 	Password string `xorm:"VARCHAR(64) NOT NULL 'password' COMMENT('密码')"`
 	// 密码密钥
@@ -41,10 +43,14 @@ type user struct {
 	ActivateCode string `xorm:"VARCHAR(32) NOT NULL 'activate_code' COMMENT('激活码')"`
 	// 激活时间
 	ActivateTime common.DateTime `xorm:"NOT NULL 'activate_time' COMMENT('激活时间')"`
+	// 注销时间, 到期后清除用户数据
+	DeleteTime common.DateTime `xorm:"NOT NULL INDEX 'delete_time' COMMENT('注销时间')"`
 	// 创建时间
 	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
 	// 修改时间
 	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+	// 导入的密码已是哈希值, 不保存
+	passwordHashed bool `xorm:"-"`
 }
 
 func (u *user) canLogin(numb int) bool {
@@ -72,6 +78,26 @@ func (u *user) IsDelete() bool {
 	return u.Status == consts.Delete
 }
 
+// 除第三方外可用的登录方式数量, 手机号可验证码登录, 邮箱可找回密码
+func (u *user) loginMethods() int {
+	count := 0
+	placeholder := u.UserID.Str()
+	if u.Name != placeholder {
+		count++
+	}
+	if u.Email != placeholder {
+		count++
+	}
+	if u.Mobile != placeholder {
+		count++
+	}
+	return count
+}
+
+func (u *user) IsDeleting() bool {
+	return !u.IsDelete() && time.Time(u.DeleteTime).Year() > 1
+}
+
 // 用户详细信息
 type userInfo struct {
 	// 递增主键
@@ -95,7 +121,7 @@ type userInfo struct {
 	// 区
 	County string `xorm:"VARCHAR(30) NOT NULL 'county' COMMENT('区')"`
 	// 注册ip
-	IP string `xorm:"VARCHAR(30) NOT NULL 'ip' COMMENT('注册ip')"`
+	IP string `xorm:"VARCHAR(45) NOT NULL 'ip' COMMENT('注册ip')"`
 }
 
 //  第三方登录
@@ -103,6 +129,8 @@ type userThird struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
 	// 用户编号
 	UserID common.ID `xorm:"BIGINT NOT NULL INDEX 'user_id' COMMENT('用户编号')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX(type+openid) 'tenant' COMMENT('租户')"`
 	// 第三方类型 qq , weibo, weixin
 	Type string `xorm:"VARCHAR(15) NOT NULL INDEX(type+openid) 'type' COMMENT('第三方类型')"`
 	// 第三方唯一编号
@@ -121,7 +149,7 @@ type userLogin struct {
 	// 用户编号
 	UserID common.ID `xorm:"BIGINT NOT NULL INDEX 'user_id' COMMENT('用户编号')"`
 	// 登录ip
-	IP string `xorm:"VARCHAR(30) NOT NULL 'ip' COMMENT('登录ip')"`
+	IP string `xorm:"VARCHAR(45) NOT NULL 'ip' COMMENT('登录ip')"`
 	// 国家
 	Country string `xorm:"VARCHAR(30) NOT NULL 'country' DEFAULT '-' COMMENT('国家')"`
 	// 省
@@ -175,6 +203,8 @@ type dict struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
 	// 字典编号
 	DictID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'dict_id' COMMENT('字典编号')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX 'tenant' COMMENT('租户')"`
 	// 类型
 	Cate string `xorm:"VARCHAR(10) NOT NULL INDEX 'cate' COMMENT('类型')"`
 	// 业务类型
@@ -191,14 +221,58 @@ type dict struct {
 	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
 }
 
+// 自定义属性定义
+type attribute struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 属性编号
+	AttrID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'attr_id' COMMENT('属性编号')"`
+	// 属性键
+	Key string `xorm:"VARCHAR(30) NOT NULL INDEX 'attr_key' COMMENT('属性键')"`
+	// 名称
+	Name string `xorm:"VARCHAR(30) NOT NULL 'name' COMMENT('名称')"`
+	// 类型 string, number, bool, date, enum
+	Type consts.AttrType `xorm:"VARCHAR(10) NOT NULL 'type' COMMENT('类型')"`
+	// 校验规则, string为正则, enum为逗号分隔的可选值
+	Rule string `xorm:"VARCHAR(255) NOT NULL 'rule' COMMENT('校验规则')"`
+	// 可见范围 user, admin, public
+	Visibility consts.Visibility `xorm:"VARCHAR(10) NOT NULL 'visibility' COMMENT('可见范围')"`
+	// 是否放入令牌
+	Claim bool `xorm:"TINYINT NOT NULL DEFAULT 0 'claim' COMMENT('是否放入令牌')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 用户自定义属性值
+type userAttribute struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE(user_key) 'user_id' COMMENT('用户编号')"`
+	// 属性键
+	Key string `xorm:"VARCHAR(30) NOT NULL UNIQUE(user_key) 'attr_key' COMMENT('属性键')"`
+	// 值
+	Value string `xorm:"VARCHAR(1024) NOT NULL 'value' COMMENT('值')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
 type resource struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 域, 空为管理后台, 其他为应用或租户
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' INDEX 'domain' COMMENT('域')"`
 	// 名称
 	Name string `xorm:"VARCHAR(30) NOT NULL INDEX 'name' COMMENT('名称')"`
 	// 资源
 	URL string `xorm:"VARCHAR(90) NOT NULL 'url' COMMENT('资源')"`
 	// 方法
 	Method string `xorm:"VARCHAR(10) NOT NULL 'method' COMMENT('方法')"`
+	// 来源, route 启动时从路由同步, custom 自定义
+	Source string `xorm:"VARCHAR(10) NOT NULL DEFAULT 'custom' 'source' COMMENT('来源')"`
+	// 路由已不存在
+	Stale bool `xorm:"TINYINT NOT NULL DEFAULT 0 'stale' COMMENT('路由已不存在')"`
 	// 创建时间
 	CreateTime common.DateTime `xorm:"NOT NULL DEFAULT current_timestamp() 'create_time' COMMENT('创建时间')"`
 }
@@ -207,6 +281,8 @@ type role struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
 	// 角色编号
 	RoleID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'role_id' COMMENT('角色编号')"`
+	// 域, 空为管理后台, 其他为应用或租户
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' INDEX 'domain' COMMENT('域')"`
 	// 名称
 	Name string `xorm:"VARCHAR(30) NOT NULL INDEX 'name' COMMENT('名称')"`
 	// 状态
@@ -221,6 +297,8 @@ type roleResource struct {
 	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
 	// 角色编号
 	RoleID common.ID `xorm:"BIGINT NOT NULL INDEX 'role_id' COMMENT('角色编号')"`
+	// 域, 空为管理后台, 其他为应用或租户
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' INDEX 'domain' COMMENT('域')"`
 	// 名称
 	Name string `xorm:"VARCHAR(30) NOT NULL INDEX 'name' COMMENT('名称')"`
 	// 资源
@@ -230,3 +308,301 @@ type roleResource struct {
 	// 创建时间
 	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
 }
+
+// 账号合并记录
+type userMerge struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 合并编号
+	MergeID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'merge_id' COMMENT('合并编号')"`
+	// 被合并的用户
+	SourceID common.ID `xorm:"BIGINT NOT NULL INDEX 'source_id' COMMENT('被合并的用户')"`
+	// 合并到的用户
+	TargetID common.ID `xorm:"BIGINT NOT NULL INDEX 'target_id' COMMENT('合并到的用户')"`
+	// 操作人
+	OperatorID common.ID `xorm:"BIGINT NOT NULL 'operator_id' COMMENT('操作人')"`
+	// 转移的用户名、邮箱、手机号
+	Fields string `xorm:"VARCHAR(30) NOT NULL 'fields' COMMENT('转移的字段')"`
+	// 转移的第三方绑定
+	Thirds string `xorm:"VARCHAR(255) NOT NULL 'thirds' COMMENT('转移的第三方绑定')"`
+	// 角色及授予记录已转移
+	RolesMoved bool `xorm:"NOT NULL DEFAULT 0 'roles_moved' COMMENT('角色已转移')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+}
+
+// 组织
+type organization struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 组织编号
+	OrgID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'org_id' COMMENT('组织编号')"`
+	// 租户, 与所有者一致
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX 'tenant' COMMENT('租户')"`
+	// 名称
+	Name string `xorm:"VARCHAR(60) NOT NULL INDEX 'name' COMMENT('名称')"`
+	// 描述
+	Description string `xorm:"VARCHAR(255) NOT NULL 'description' COMMENT('描述')"`
+	// 所有者
+	OwnerID common.ID `xorm:"BIGINT NOT NULL INDEX 'owner_id' COMMENT('所有者')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 组织成员
+type orgMember struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 组织编号
+	OrgID common.ID `xorm:"BIGINT NOT NULL UNIQUE(org_user) 'org_id' COMMENT('组织编号')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE(org_user) INDEX 'user_id' COMMENT('用户编号')"`
+	// 角色
+	Role consts.OrgRole `xorm:"VARCHAR(10) NOT NULL 'role' COMMENT('角色')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 组织邀请
+type orgInvite struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 邀请编号
+	InviteID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'invite_id' COMMENT('邀请编号')"`
+	// 组织编号
+	OrgID common.ID `xorm:"BIGINT NOT NULL INDEX 'org_id' COMMENT('组织编号')"`
+	// 邀请码
+	Code string `xorm:"VARCHAR(32) NOT NULL UNIQUE 'code' COMMENT('邀请码')"`
+	// 加入后的角色
+	Role consts.OrgRole `xorm:"VARCHAR(10) NOT NULL 'role' COMMENT('角色')"`
+	// 邀请人
+	InviterID common.ID `xorm:"BIGINT NOT NULL 'inviter_id' COMMENT('邀请人')"`
+	// 接受邀请的用户
+	AcceptID common.ID `xorm:"BIGINT NOT NULL DEFAULT 0 'accept_id' COMMENT('接受邀请的用户')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 过期时间
+	ExpireTime common.DateTime `xorm:"NOT NULL 'expire_time' COMMENT('过期时间')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 租户
+type tenant struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 租户编号
+	TenantID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'tenant_id' COMMENT('租户编号')"`
+	// 租户代码, 用于请求头和路径中识别租户
+	Code string `xorm:"VARCHAR(32) NOT NULL UNIQUE 'code' COMMENT('租户代码')"`
+	// 名称
+	Name string `xorm:"VARCHAR(60) NOT NULL 'name' COMMENT('名称')"`
+	// 绑定的域名, 逗号分隔
+	Hosts string `xorm:"VARCHAR(255) NOT NULL 'hosts' COMMENT('绑定的域名')"`
+	// 令牌签名密钥, 为空时使用全局密钥
+	Secret string `xorm:"VARCHAR(64) NOT NULL 'secret' COMMENT('令牌签名密钥')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 注册邀请码
+type inviteCode struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 邀请码编号
+	InviteID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'invite_id' COMMENT('邀请码编号')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX 'tenant' COMMENT('租户')"`
+	// 邀请码
+	Code string `xorm:"VARCHAR(32) NOT NULL UNIQUE 'code' COMMENT('邀请码')"`
+	// 邀请人
+	InviterID common.ID `xorm:"BIGINT NOT NULL INDEX 'inviter_id' COMMENT('邀请人')"`
+	// 注册后授予的角色, 0为不授予
+	RoleID common.ID `xorm:"BIGINT NOT NULL DEFAULT 0 'role_id' COMMENT('注册后授予的角色')"`
+	// 可使用次数, 0为不限
+	MaxUses int `xorm:"INT NOT NULL DEFAULT 1 'max_uses' COMMENT('可使用次数')"`
+	// 已使用次数
+	Used int `xorm:"INT NOT NULL DEFAULT 0 'used' COMMENT('已使用次数')"`
+	// 备注
+	Remark string `xorm:"VARCHAR(60) NOT NULL 'remark' COMMENT('备注')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 过期时间, 空时间为永不过期
+	ExpireTime common.DateTime `xorm:"NOT NULL 'expire_time' COMMENT('过期时间')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 邀请注册记录
+type referral struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX 'tenant' COMMENT('租户')"`
+	// 邀请码编号
+	InviteID common.ID `xorm:"BIGINT NOT NULL INDEX 'invite_id' COMMENT('邀请码编号')"`
+	// 邀请人
+	InviterID common.ID `xorm:"BIGINT NOT NULL INDEX 'inviter_id' COMMENT('邀请人')"`
+	// 被邀请人
+	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'user_id' COMMENT('被邀请人')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+}
+
+// 协议文档, 服务条款和隐私政策
+type legal struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 文档编号
+	LegalID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'legal_id' COMMENT('文档编号')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' UNIQUE(tenant_tp_version) 'tenant' COMMENT('租户')"`
+	// 类型, terms 服务条款, privacy 隐私政策
+	TP string `xorm:"VARCHAR(10) NOT NULL UNIQUE(tenant_tp_version) 'tp' COMMENT('类型')"`
+	// 版本号
+	Version string `xorm:"VARCHAR(20) NOT NULL UNIQUE(tenant_tp_version) 'version' COMMENT('版本号')"`
+	// 标题
+	Title string `xorm:"VARCHAR(100) NOT NULL 'title' COMMENT('标题')"`
+	// 内容
+	Content string `xorm:"MEDIUMTEXT NOT NULL 'content' COMMENT('内容')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 发布时间, 空时间为草稿
+	PublishTime common.DateTime `xorm:"NOT NULL 'publish_time' COMMENT('发布时间')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 用户同意协议记录
+type legalAccept struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE(user_legal) 'user_id' COMMENT('用户编号')"`
+	// 文档编号
+	LegalID common.ID `xorm:"BIGINT NOT NULL UNIQUE(user_legal) 'legal_id' COMMENT('文档编号')"`
+	// 类型
+	TP string `xorm:"VARCHAR(10) NOT NULL 'tp' COMMENT('类型')"`
+	// 版本号
+	Version string `xorm:"VARCHAR(20) NOT NULL 'version' COMMENT('版本号')"`
+	// 同意时的ip
+	IP string `xorm:"VARCHAR(45) NOT NULL 'ip' COMMENT('同意时的ip')"`
+	// 同意时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('同意时间')"`
+}
+
+// 保留词和敏感词
+type word struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 词编号
+	WordID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'word_id' COMMENT('词编号')"`
+	// 词
+	Word string `xorm:"VARCHAR(50) NOT NULL INDEX 'word' COMMENT('词')"`
+	// 类型, reserved 保留词整体匹配, sensitive 敏感词包含匹配
+	TP string `xorm:"VARCHAR(10) NOT NULL 'tp' COMMENT('类型')"`
+	// 适用的字段, 逗号分隔
+	Fields string `xorm:"VARCHAR(50) NOT NULL 'fields' COMMENT('适用的字段')"`
+	// 状态
+	Status consts.Status `xorm:"TINYINT NOT NULL DEFAULT 1 'status' COMMENT('状态')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 昵称和头像修改审核
+type moderation struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 审核编号
+	ModerationID common.ID `xorm:"BIGINT NOT NULL UNIQUE 'moderation_id' COMMENT('审核编号')"`
+	// 租户
+	Tenant string `xorm:"VARCHAR(32) NOT NULL DEFAULT 'default' INDEX(tenant_status) 'tenant' COMMENT('租户')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL INDEX 'user_id' COMMENT('用户编号')"`
+	// 字段, nickname 昵称, avatar 头像
+	Field string `xorm:"VARCHAR(20) NOT NULL 'field' COMMENT('字段')"`
+	// 修改后的内容
+	Value string `xorm:"VARCHAR(255) NOT NULL 'value' COMMENT('修改后的内容')"`
+	// 修改前的内容
+	OldValue string `xorm:"VARCHAR(255) NOT NULL 'old_value' COMMENT('修改前的内容')"`
+	// 状态, 0 待审核, 1 通过, -1 拒绝, -2 已撤回
+	Status consts.ModerationStatus `xorm:"TINYINT NOT NULL DEFAULT 0 INDEX(tenant_status) 'status' COMMENT('状态')"`
+	// 拒绝原因
+	Reason string `xorm:"VARCHAR(255) NOT NULL 'reason' COMMENT('拒绝原因')"`
+	// 审核人, 自动审核为0
+	ReviewerID common.ID `xorm:"BIGINT NOT NULL 'reviewer_id' COMMENT('审核人')"`
+	// 审核时间
+	ReviewTime common.DateTime `xorm:"NOT NULL 'review_time' COMMENT('审核时间')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 修改时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('修改时间')"`
+}
+
+// 用户角色授予记录, 与权限规则中的用户角色对应, 记录授予人和有效期
+type roleGrant struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 域, 空为管理后台
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' UNIQUE(domain_user_role) 'domain' COMMENT('域')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL UNIQUE(domain_user_role) 'user_id' COMMENT('用户编号')"`
+	// 角色编号, 包含内置的超级管理员角色
+	RoleID string `xorm:"VARCHAR(32) NOT NULL UNIQUE(domain_user_role) 'role_id' COMMENT('角色编号')"`
+	// 授予人, 系统授予为0
+	GranterID common.ID `xorm:"BIGINT NOT NULL 'granter_id' COMMENT('授予人')"`
+	// 过期时间, 空时间为永不过期
+	ExpireTime common.DateTime `xorm:"NOT NULL INDEX 'expire_time' COMMENT('过期时间')"`
+	// 授予时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('授予时间')"`
+}
+
+// 用户角色变更记录
+type roleGrantLog struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 域, 空为管理后台
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' 'domain' COMMENT('域')"`
+	// 用户编号
+	UserID common.ID `xorm:"BIGINT NOT NULL INDEX 'user_id' COMMENT('用户编号')"`
+	// 角色编号
+	RoleID string `xorm:"VARCHAR(32) NOT NULL 'role_id' COMMENT('角色编号')"`
+	// 操作, grant 授予, revoke 取消, expire 过期移除
+	Action string `xorm:"VARCHAR(10) NOT NULL 'action' COMMENT('操作')"`
+	// 授予时的过期时间
+	ExpireTime common.DateTime `xorm:"NOT NULL 'expire_time' COMMENT('过期时间')"`
+	// 操作人, 系统操作为0
+	OperatorID common.ID `xorm:"BIGINT NOT NULL 'operator_id' COMMENT('操作人')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+}
+
+// 策略条件, 角色的资源附加的时间、IP和所有者条件, 全部满足时策略才生效
+type policyCondition struct {
+	ID int64 `xorm:"id PK AUTOINCR COMMENT('主键')"`
+	// 角色编号
+	RoleID common.ID `xorm:"BIGINT NOT NULL UNIQUE(role_url_method) 'role_id' COMMENT('角色编号')"`
+	// 域, 空为管理后台
+	Domain string `xorm:"VARCHAR(32) NOT NULL DEFAULT '' 'domain' COMMENT('域')"`
+	// 资源
+	URL string `xorm:"VARCHAR(90) NOT NULL UNIQUE(role_url_method) 'url' COMMENT('资源')"`
+	// 方法
+	Method string `xorm:"VARCHAR(10) NOT NULL UNIQUE(role_url_method) 'method' COMMENT('方法')"`
+	// 每天允许的时间段, 例如 09:00-18:00
+	Hours string `xorm:"VARCHAR(11) NOT NULL DEFAULT '' 'hours' COMMENT('时间段')"`
+	// 允许的星期, 逗号分隔, 0为周日
+	Weekdays string `xorm:"VARCHAR(13) NOT NULL DEFAULT '' 'weekdays' COMMENT('星期')"`
+	// 允许的IP或网段, 逗号分隔
+	IPRanges string `xorm:"VARCHAR(255) NOT NULL DEFAULT '' 'ip_ranges' COMMENT('IP范围')"`
+	// 只允许访问自己的数据
+	Owner bool `xorm:"TINYINT NOT NULL DEFAULT 0 'owner' COMMENT('只允许所有者')"`
+	// 创建时间
+	CreateTime common.DateTime `xorm:"NOT NULL 'create_time' COMMENT('创建时间')"`
+	// 更新时间
+	UpdateTime common.DateTime `xorm:"NOT NULL 'update_time' COMMENT('更新时间')"`
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/convert"
 	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
 	"github.com/ihuanglei/authenticator/pkg/mapper/st"
 	"github.com/simplexwork/common"
 
@@ -17,14 +18,14 @@ import (
 
 // CreateUserWithThird 三方注册登录
 func CreateUserWithThird(register *st.RegisterDto) (common.ID, error) {
-	has, err := HasUserByThird(register.TP, register.OpenID)
+	has, err := HasUserByThird(register.Tenant, register.TP, register.OpenID)
 	if err != nil {
 		return 0, err
 	}
 	if has {
 		return 0, errors.ErrUserExist
 	}
-	user := &user{Mode: consts.Third, Mobile: register.Mobile}
+	user := &user{Tenant: register.Tenant, Mode: consts.Third, Mobile: register.Mobile}
 	userInfo := &userInfo{
 		Nickname: register.Nickname,
 		Avatar:   register.Avatar,
@@ -33,7 +34,7 @@ func CreateUserWithThird(register *st.RegisterDto) (common.ID, error) {
 		IP:       register.IP,
 	}
 	thirdUser := &userThird{OpenID: register.OpenID, Type: register.TP}
-	if err := createUser(user, userInfo, thirdUser); err != nil {
+	if err := registerUser(register, user, userInfo, thirdUser); err != nil {
 		return 0, err
 	}
 	return user.UserID, nil
@@ -42,27 +43,25 @@ func CreateUserWithThird(register *st.RegisterDto) (common.ID, error) {
 // CreateUserWithName 用户名密码创建用户
 func CreateUserWithName(register *st.RegisterDto) (common.ID, error) {
 	name := common.Trim(register.LoginName)
-	// 必须包含一个字母或下划线
-	if b, err := regexp.MatchString(`^\w{5,20}$`, name); !b || err != nil {
-		return 0, errors.ErrName
+	if err := checkLoginName(name); err != nil {
+		return 0, err
 	}
-	if b, err := regexp.MatchString(`^\d{5,20}$`, name); b || err != nil {
-		return 0, errors.ErrName
+	if err := checkWords(consts.WordFieldName, name); err != nil {
+		return 0, err
 	}
-
 	if !common.IsSimplePassword(register.Password) {
 		return 0, errors.ErrPassword
 	}
-	has, err := HasUserByName(name)
+	has, err := HasUserByName(register.Tenant, name)
 	if err != nil {
 		return 0, err
 	}
 	if has {
 		return 0, errors.ErrUserExist
 	}
-	user := &user{Name: name, Password: register.Password, Mode: consts.Name}
+	user := &user{Tenant: register.Tenant, Name: name, Password: register.Password, Mode: consts.Name}
 	userInfo := &userInfo{IP: register.IP}
-	if err := createUser(user, userInfo, nil); err != nil {
+	if err := registerUser(register, user, userInfo, nil); err != nil {
 		return 0, err
 	}
 	return user.UserID, nil
@@ -76,7 +75,10 @@ func CreateUserWithEmail(register *st.RegisterDto) (common.ID, string, error) {
 	if !common.IsSimplePassword(register.Password) {
 		return 0, "", errors.ErrPassword
 	}
-	has, err := HasUserByEmail(register.Email)
+	if err := checkEmailDomain(register.Tenant, register.Email); err != nil {
+		return 0, "", err
+	}
+	has, err := HasUserByEmail(register.Tenant, register.Email)
 	if err != nil {
 		return 0, "", err
 	}
@@ -84,9 +86,9 @@ func CreateUserWithEmail(register *st.RegisterDto) (common.ID, string, error) {
 		return 0, "", errors.ErrUserEmailExist
 	}
 	activateCode := common.MD5(fmt.Sprintf("%v%s", time.Now().UnixNano(), common.RandomString(24)))
-	user := &user{Email: register.Email, Password: register.Password, Activate: consts.UnActivated, ActivateCode: activateCode, Mode: consts.Email}
+	user := &user{Tenant: register.Tenant, Email: register.Email, Password: register.Password, Activate: consts.UnActivated, ActivateCode: activateCode, Mode: consts.Email}
 	userInfo := &userInfo{IP: register.IP}
-	if err := createUser(user, userInfo, nil); err != nil {
+	if err := registerUser(register, user, userInfo, nil); err != nil {
 		return 0, "", err
 	}
 	return user.UserID, activateCode, nil
@@ -100,21 +102,41 @@ func CreateUserWithMobile(register *st.RegisterDto) (common.ID, error) {
 	if !common.IsSimplePassword(register.Password) {
 		return 0, errors.ErrPassword
 	}
-	has, err := HasUserByMobile(register.Mobile)
+	has, err := HasUserByMobile(register.Tenant, register.Mobile)
 	if err != nil {
 		return 0, err
 	}
 	if has {
 		return 0, errors.ErrUserMobileExist
 	}
-	user := &user{Mobile: register.Mobile, Mode: consts.Mobile}
+	user := &user{Tenant: register.Tenant, Mobile: register.Mobile, Mode: consts.Mobile}
 	userInfo := &userInfo{IP: register.IP}
-	if err := createUser(user, userInfo, nil); err != nil {
+	if err := registerUser(register, user, userInfo, nil); err != nil {
 		return 0, err
 	}
 	return user.UserID, nil
 }
 
+// 注册用户, 使用邀请码并记录用户同意的协议, 必须同意全部当前生效的协议
+func registerUser(register *st.RegisterDto, user *user, userInfo *userInfo, userThird *userThird) error {
+	invite, err := getValidInvite(register.Tenant, register.InviteCode)
+	if err != nil {
+		return err
+	}
+	current, err := getCurrentLegals(register.Tenant)
+	if err != nil {
+		return err
+	}
+	legals, err := pickCurrentLegals(current, register.LegalIDs)
+	if err != nil {
+		return err
+	}
+	if len(legals) < len(current) {
+		return errors.ErrLegalMustAccept
+	}
+	return createUser(user, userInfo, userThird, invite, legals)
+}
+
 // ActivateUser 激活用户
 func ActivateUser(userID common.ID, activateCode string) error {
 	user, err := getUserByID(userID)
@@ -155,26 +177,45 @@ func UpdateActivateCodeForUser(userID common.ID) (string, error) {
 	return activateCode, updateActivateCodeForUser(userID, activateCode)
 }
 
-// UpdateAvatarForUser 更新头像
-func UpdateAvatarForUser(userID common.ID, avatar string) error {
+// UpdateAvatarForUser 更新头像, 开启审核时进入审核队列并返回true
+func UpdateAvatarForUser(userID common.ID, avatar string) (bool, error) {
 	if common.IsEmpty(avatar) {
-		return errors.ErrAvatar
+		return false, errors.ErrAvatar
 	}
-	if _, err := getUserByID(userID); err != nil {
-		return err
+	user, err := getUserByID(userID)
+	if err != nil {
+		return false, err
 	}
-	return updateAvatarForUser(userID, avatar)
+	old, err := getUserInfoByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if pending, err := moderate(user, consts.ModerationFieldAvatar, avatar, old.Avatar); err != nil || pending {
+		return pending, err
+	}
+	return false, updateAvatarForUser(userID, avatar)
 }
 
-// UpdateNicknameForUser 更新昵称
-func UpdateNicknameForUser(userID common.ID, nickname string) error {
-	if common.IsEmpty(nickname) || utf8.RuneCountInString(nickname) > 15 {
-		return errors.ErrNickname
+// UpdateNicknameForUser 更新昵称, 开启审核时进入审核队列并返回true
+func UpdateNicknameForUser(userID common.ID, nickname string) (bool, error) {
+	if err := checkNickname(nickname); err != nil {
+		return false, err
 	}
-	if _, err := getUserByID(userID); err != nil {
-		return err
+	if err := checkWords(consts.WordFieldNickname, nickname); err != nil {
+		return false, err
 	}
-	return updateNicknameForUser(userID, nickname)
+	user, err := getUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	old, err := getUserInfoByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if pending, err := moderate(user, consts.ModerationFieldNickname, nickname, old.Nickname); err != nil || pending {
+		return pending, err
+	}
+	return false, updateNicknameForUser(userID, nickname)
 }
 
 // UpdateGenderForUser 更新性别
@@ -191,10 +232,14 @@ func UpdateEmailForUser(userID common.ID, email string) error {
 	if !common.IsEmail(email) {
 		return errors.ErrEmail
 	}
-	if _, err := getUserByID(userID); err != nil {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := checkEmailDomain(user.Tenant, email); err != nil {
 		return err
 	}
-	has, err := HasUserByEmail(email)
+	has, err := HasUserByEmail(user.Tenant, email)
 	if err != nil {
 		return err
 	}
@@ -209,10 +254,11 @@ func UpdateMobileForUser(userID common.ID, mobile string) error {
 	if !common.IsMobile(mobile) {
 		return errors.ErrMobile
 	}
-	if _, err := getUserByID(userID); err != nil {
+	user, err := getUserByID(userID)
+	if err != nil {
 		return err
 	}
-	has, err := HasUserByMobile(mobile)
+	has, err := HasUserByMobile(user.Tenant, mobile)
 	if err != nil {
 		return err
 	}
@@ -222,16 +268,92 @@ func UpdateMobileForUser(userID common.ID, mobile string) error {
 	return updateMobileForUser(userID, mobile)
 }
 
-// UpdateMy 更新信息
-func UpdateMy(userID common.ID, userInfoDto *st.UserInfoDto) error {
-	if _, err := getUserByID(userID); err != nil {
-		return err
+// UpdateMy 更新信息, 只校验和更新fields中指定且有变化的字段, 返回已更新的字段和进入审核队列的字段
+func UpdateMy(userID common.ID, userInfoDto *st.UserInfoDto, fields ...string) ([]string, []string, error) {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, err := getUserInfoByID(userID)
+	if err != nil {
+		return nil, nil, err
 	}
 	userInfo := new(userInfo)
 	if err := convert.Map(userInfoDto, userInfo); err != nil {
-		return err
+		return nil, nil, err
+	}
+	var columns []string
+	for _, field := range fields {
+		var changed bool
+		var err error
+		switch field {
+		case "nickname":
+			userInfo.Nickname = common.Trim(userInfo.Nickname)
+			err = checkNickname(userInfo.Nickname)
+			changed = userInfo.Nickname != old.Nickname
+			if err == nil && changed {
+				err = checkWords(consts.WordFieldNickname, userInfo.Nickname)
+			}
+		case "avatar":
+			userInfo.Avatar = common.Trim(userInfo.Avatar)
+			err = checkAvatar(userInfo.Avatar)
+			changed = userInfo.Avatar != old.Avatar
+		case "gender":
+			changed = userInfo.Gender != old.Gender
+		case "qq":
+			err = checkPattern(userInfo.QQ, `^[1-9]\d{4,11}$`, errors.ErrQQ)
+			changed = userInfo.QQ != old.QQ
+		case "weixin":
+			err = checkPattern(userInfo.WeiXin, `^[a-zA-Z][-_a-zA-Z0-9]{5,19}$`, errors.ErrWeiXin)
+			changed = userInfo.WeiXin != old.WeiXin
+		case "province":
+			err = checkRegion(userInfo.Province)
+			changed = userInfo.Province != old.Province
+		case "city":
+			err = checkRegion(userInfo.City)
+			changed = userInfo.City != old.City
+		case "county":
+			err = checkRegion(userInfo.County)
+			changed = userInfo.County != old.County
+		default:
+			err = errors.ErrArgument
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if changed {
+			columns = append(columns, field)
+		}
+	}
+	// 开启审核时昵称和头像进入审核队列, 审核通过后生效
+	var pending []string
+	moderated := map[string][2]string{
+		consts.ModerationFieldNickname: {userInfo.Nickname, old.Nickname},
+		consts.ModerationFieldAvatar:   {userInfo.Avatar, old.Avatar},
+	}
+	isPending := map[string]bool{}
+	for _, field := range fields {
+		values, ok := moderated[field]
+		if !ok {
+			continue
+		}
+		if isPending[field], err = moderate(user, field, values[0], values[1]); err != nil {
+			return nil, nil, err
+		}
+		if isPending[field] {
+			pending = append(pending, field)
+		}
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isPending[column] {
+			updates = append(updates, column)
+		}
 	}
-	return updateMy(userID, userInfo)
+	if len(updates) == 0 {
+		return nil, pending, nil
+	}
+	return updates, pending, updateMy(userID, userInfo, updates...)
 }
 
 // UpdatePasswordForUser 修改密码
@@ -257,6 +379,132 @@ func UpdatePassword1ForUser(userID common.ID, password string) error {
 	return updatePasswordForUser(userID, password)
 }
 
+// GetThirdsForUser 获取用户第三方绑定
+func GetThirdsForUser(userID common.ID) ([]*st.UserThirdDto, error) {
+	thirds, err := getUserThirds(builder.Eq{"user_id": userID, "status": consts.Normal})
+	if err != nil {
+		return nil, err
+	}
+	var thirdDtos = make([]*st.UserThirdDto, len(thirds))
+	if err := convert.Map(&thirds, &thirdDtos); err != nil {
+		return nil, err
+	}
+	return thirdDtos, nil
+}
+
+// BindThirdForUser 绑定第三方, 每种类型只能绑定一个
+func BindThirdForUser(userID common.ID, tp, openID string) error {
+	if common.IsEmpty(tp) || common.IsEmpty(openID) {
+		return errors.ErrArgument
+	}
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	has, err := HasUserByThird(user.Tenant, tp, openID)
+	if err != nil {
+		return err
+	}
+	if has {
+		return errors.ErrUserAlreadyBind
+	}
+	count, err := getUserThirdCount(builder.Eq{"user_id": userID, "type": tp, "status": consts.Normal})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.ErrThirdAlreadyBind
+	}
+	return createUserThird(&userThird{UserID: userID, Tenant: user.Tenant, Type: tp, OpenID: openID})
+}
+
+// UnbindThirdForUser 解绑第三方, 不允许移除最后一种登录方式
+func UnbindThirdForUser(userID common.ID, tp string) error {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	thirds, err := getUserThirds(builder.Eq{"user_id": userID, "status": consts.Normal})
+	if err != nil {
+		return err
+	}
+	bound := false
+	for _, third := range thirds {
+		if third.Type == tp {
+			bound = true
+		}
+	}
+	if !bound {
+		return errors.ErrThirdNotBind
+	}
+	if user.loginMethods()+len(thirds) <= 1 {
+		return errors.ErrLastLoginMethod
+	}
+	return deleteUserThird(userID, tp)
+}
+
+// CheckPasswordForUser 校验用户密码, 错误时增加登录错误次数
+func CheckPasswordForUser(userID common.ID, password string) error {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := checkState(user); err != nil {
+		return err
+	}
+	if common.MD5(password+user.Salt) != user.Password {
+		UpdateLoginErrorForUser(userID)
+		return errors.ErrInvalidPassword
+	}
+	return nil
+}
+
+// DeleteUser 申请注销用户, 宽限期后清除用户数据
+func DeleteUser(userID common.ID, grace time.Duration) (common.DateTime, error) {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return common.DateTime{}, err
+	}
+	if user.IsDelete() {
+		return common.DateTime{}, errors.ErrUserDelete
+	}
+	if user.IsDeleting() {
+		return common.DateTime{}, errors.ErrUserDeleting
+	}
+	deleteTime := common.DateTime(time.Now().Add(grace))
+	return deleteTime, updateDeleteTimeForUser(userID, deleteTime)
+}
+
+// CancelDeleteForUser 取消注销
+func CancelDeleteForUser(userID common.ID) error {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsDeleting() {
+		return nil
+	}
+	return updateDeleteTimeForUser(userID, zeroTime())
+}
+
+// PurgeDeletedUsers 清除超过注销宽限期的用户数据, revoke 先移除用户在权限规则中的角色, 失败时下次重试
+func PurgeDeletedUsers(revoke func(userID common.ID) error) error {
+	users, err := getUsersToPurge(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := revoke(user.UserID); err != nil {
+			return err
+		}
+		if err := purgeUser(user.UserID); err != nil {
+			return err
+		}
+		logger.Infof("[USER] purge deleted user %v", user.UserID)
+	}
+	return nil
+}
+
 // UpdateForbiddenForUser 更新禁用状态
 func UpdateForbiddenForUser(userID common.ID, forbidden consts.Forbidden) error {
 	user, err := getUserByID(userID)
@@ -293,12 +541,12 @@ func ResetLoginErrorForUser(userID common.ID) error {
 	return updateLoginErrorForUser(userID, 0)
 }
 
-// GetUserByMobile 根据手机号查询用户信息
-func GetUserByMobile(mobile string) (*st.UserDto, error) {
+// GetUserByMobile 根据手机号查询租户内的用户信息
+func GetUserByMobile(tenant, mobile string) (*st.UserDto, error) {
 	if !common.IsMobile(mobile) {
 		return nil, errors.ErrMobile
 	}
-	user, err := getUserByMobile(mobile)
+	user, err := getUserByMobile(tenant, mobile)
 	if err != nil {
 		return nil, err
 	}
@@ -310,12 +558,12 @@ func GetUserByMobile(mobile string) (*st.UserDto, error) {
 	return userDto, nil
 }
 
-// GetUserByEmail 根据邮箱获取用户信息
-func GetUserByEmail(email string) (*st.UserDto, error) {
+// GetUserByEmail 根据邮箱获取租户内的用户信息
+func GetUserByEmail(tenant, email string) (*st.UserDto, error) {
 	if !common.IsEmail(email) {
 		return nil, errors.ErrEmail
 	}
-	user, err := getUserByEmail(email)
+	user, err := getUserByEmail(tenant, email)
 	if err != nil {
 		return nil, err
 	}
@@ -360,6 +608,21 @@ func GetUsers(query st.UserQuery) (int64, []*st.UserDto, error) {
 	page := query.Page
 	limit := query.Limit
 
+	cond := userQueryCond(query)
+	count, users, err := getUsers(cond, page, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	var usersDtos = make([]*st.UserDto, len(users))
+	err = convert.Map(&users, &usersDtos)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, usersDtos, nil
+}
+
+// 用户搜索条件, 性别在用户详情表中
+func userQueryCond(query st.UserQuery) builder.Cond {
 	cond := builder.NewCond()
 
 	keyword := query.Keyword
@@ -368,6 +631,8 @@ func GetUsers(query st.UserQuery) (int64, []*st.UserDto, error) {
 		cond = builder.Or(builder.Like{"mobile", keyword}, builder.Like{"email", keyword}, builder.Like{"name", keyword})
 	}
 
+	cond = cond.And(builder.Eq{"tenant": tenantOf(query.Tenant)})
+
 	if common.Trim(query.Name) != "" {
 		cond = cond.And(builder.Like{"name", query.Name + "%"})
 	}
@@ -384,7 +649,7 @@ func GetUsers(query st.UserQuery) (int64, []*st.UserDto, error) {
 		for _, value := range genderValues {
 			genders = append(genders, consts.NewGender(value))
 		}
-		cond = cond.And(builder.In("gender", genders))
+		cond = cond.And(builder.In("user_id", builder.Select("user_id").From(_Engine.TableName(new(userInfo))).Where(builder.In("gender", genders))))
 	}
 
 	forbiddenValues := query.Forbiddens
@@ -405,16 +670,7 @@ func GetUsers(query st.UserQuery) (int64, []*st.UserDto, error) {
 		cond = cond.And(builder.In("activate", activates))
 	}
 
-	count, users, err := getUsers(cond, page, limit)
-	if err != nil {
-		return 0, nil, err
-	}
-	var usersDtos = make([]*st.UserDto, len(users))
-	err = convert.Map(&users, &usersDtos)
-	if err != nil {
-		return 0, nil, err
-	}
-	return count, usersDtos, nil
+	return cond
 }
 
 // GetUserLoginByID 根据用户ID获取用户登录历史
@@ -433,42 +689,42 @@ func GetUserLoginByID(userID common.ID, query st.EmptyQuery) (int64, []*st.UserL
 	return count, userLoginDtos, nil
 }
 
-// HasUserByEmail 是否存在有效邮箱
-func HasUserByEmail(email string) (bool, error) {
+// HasUserByEmail 租户内是否存在有效邮箱
+func HasUserByEmail(tenant, email string) (bool, error) {
 	if !common.IsEmail(email) {
 		return false, errors.ErrEmail
 	}
-	count, err := getUserCount(builder.Eq{"email": email})
+	count, err := getUserCount(builder.Eq{"tenant": tenantOf(tenant), "email": email})
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-// HasUserByMobile 是否存在有效手机号
-func HasUserByMobile(mobile string) (bool, error) {
+// HasUserByMobile 租户内是否存在有效手机号
+func HasUserByMobile(tenant, mobile string) (bool, error) {
 	if !common.IsMobile(mobile) {
 		return false, errors.ErrMobile
 	}
-	count, err := getUserCount(builder.Eq{"mobile": mobile})
+	count, err := getUserCount(builder.Eq{"tenant": tenantOf(tenant), "mobile": mobile})
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-// HasUserByName 是否存在有效用户名
-func HasUserByName(name string) (bool, error) {
-	count, err := getUserCount(builder.Eq{"name": name})
+// HasUserByName 租户内是否存在有效用户名
+func HasUserByName(tenant, name string) (bool, error) {
+	count, err := getUserCount(builder.Eq{"tenant": tenantOf(tenant), "name": name})
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-// HasUserByThird 是否存在第三方绑定
-func HasUserByThird(tp, openID string) (bool, error) {
-	_, err := getUserByTypeAndOpenID(tp, openID)
+// HasUserByThird 租户内是否存在第三方绑定
+func HasUserByThird(tenant, tp, openID string) (bool, error) {
+	_, err := getUserByTypeAndOpenID(tenant, tp, openID)
 	if err != nil {
 		if e, ok := err.(errors.Error); ok && e.Code() == errors.ErrUserNotExist.Code() {
 			return false, nil
@@ -478,12 +734,12 @@ func HasUserByThird(tp, openID string) (bool, error) {
 	return true, nil
 }
 
-// CheckUserByMobile 根据手机号检查用户状态
-func CheckUserByMobile(mobile string) error {
+// CheckUserByMobile 根据手机号检查租户内的用户状态
+func CheckUserByMobile(tenant, mobile string) error {
 	if !common.IsMobile(mobile) {
 		return errors.ErrMobile
 	}
-	user, err := getUserByMobile(mobile)
+	user, err := getUserByMobile(tenant, mobile)
 	if err != nil {
 		return err
 	}
@@ -516,21 +772,21 @@ func Login(loginDto *st.LoginDto) (*st.UserDto, error) {
 	}
 
 	if guessLoginType&ltEmail == ltEmail {
-		user, err = getUserByEmail(loginDto.LoginName)
+		user, err = getUserByEmail(loginDto.Tenant, loginDto.LoginName)
 		if err == nil {
 			goto check
 		}
 	}
 
 	if guessLoginType&ltMobile == ltMobile {
-		user, err = getUserByMobile(loginDto.LoginName)
+		user, err = getUserByMobile(loginDto.Tenant, loginDto.LoginName)
 		if err == nil {
 			goto check
 		}
 	}
 
 	if guessLoginType&ltName == ltName {
-		user, err = getUserByName(loginDto.LoginName)
+		user, err = getUserByName(loginDto.Tenant, loginDto.LoginName)
 		if err == nil {
 			goto check
 		}
@@ -555,7 +811,7 @@ check:
 
 // LoginByMobile 手机验证码登录
 func LoginByMobile(loginDto *st.LoginDto) (*st.UserDto, error) {
-	user, err := getUserByMobile(loginDto.Mobile)
+	user, err := getUserByMobile(loginDto.Tenant, loginDto.Mobile)
 	if err != nil {
 		return nil, errors.ErrUserNotExist
 	}
@@ -564,7 +820,7 @@ func LoginByMobile(loginDto *st.LoginDto) (*st.UserDto, error) {
 
 // LoginByOpenID 根据用户OpenID获取用户信息
 func LoginByOpenID(loginDto *st.LoginDto) (*st.UserDto, error) {
-	user, err := getUserByTypeAndOpenID(loginDto.Type, loginDto.OpenID)
+	user, err := getUserByTypeAndOpenID(loginDto.Tenant, loginDto.Type, loginDto.OpenID)
 	if err != nil {
 		return nil, err
 	}
@@ -582,6 +838,49 @@ func login(user *user, ip string) (*st.UserDto, error) {
 	return userDto, nil
 }
 
+// 用户名必须包含一个字母或下划线
+func checkLoginName(name string) error {
+	if b, err := regexp.MatchString(`^\w{5,20}$`, name); !b || err != nil {
+		return errors.ErrName
+	}
+	if b, err := regexp.MatchString(`^\d{5,20}$`, name); b || err != nil {
+		return errors.ErrName
+	}
+	return nil
+}
+
+func checkNickname(nickname string) error {
+	if common.IsEmpty(nickname) || utf8.RuneCountInString(nickname) > 15 {
+		return errors.ErrNickname
+	}
+	return nil
+}
+
+func checkAvatar(avatar string) error {
+	if common.IsEmpty(avatar) || len(avatar) > 255 {
+		return errors.ErrAvatar
+	}
+	return nil
+}
+
+func checkRegion(region string) error {
+	if utf8.RuneCountInString(region) > 30 {
+		return errors.ErrRegion
+	}
+	return nil
+}
+
+// 允许为空, 不为空时必须匹配
+func checkPattern(value, pattern string, e error) error {
+	if value == "" {
+		return nil
+	}
+	if b, err := regexp.MatchString(pattern, value); !b || err != nil {
+		return e
+	}
+	return nil
+}
+
 func checkState(user *user) error {
 	if !user.canLogin(consts.LoginErrorCount) {
 		return errors.ErrUserLocked
@@ -9,6 +9,7 @@ import (
 	"github.com/ihuanglei/authenticator/pkg/region"
 	"github.com/simplexwork/common"
 	"xorm.io/builder"
+	"xorm.io/xorm"
 )
 
 // 用户登录历史
@@ -28,6 +29,76 @@ func getUserLogins(cond builder.Cond, page, limit int) (int64, []*userLogin, err
 	return count, userLogins, nil
 }
 
+// 用户全部登录历史
+func getAllUserLogins(cond builder.Cond) ([]*userLogin, error) {
+	var userLogins = make([]*userLogin, 0)
+	err := _Engine.Desc("create_time").Where(cond).Find(&userLogins)
+	if err != nil {
+		return nil, err
+	}
+	return userLogins, nil
+}
+
+// 用户最后一次登录, 按用户编号返回
+func getLastUserLogins(userIDs []common.ID) (map[common.ID]*userLogin, error) {
+	var userLogins = make([]*userLogin, 0)
+	latest := builder.Select("MAX(id)").From(_Engine.TableName(new(userLogin))).Where(builder.In("user_id", userIDs)).GroupBy("user_id")
+	if err := _Engine.Where(builder.In("id", latest)).Find(&userLogins); err != nil {
+		return nil, err
+	}
+	logins := make(map[common.ID]*userLogin, len(userLogins))
+	for _, userLogin := range userLogins {
+		logins[userLogin.UserID] = userLogin
+	}
+	return logins, nil
+}
+
+// 用户第三方绑定
+func getUserThirds(cond builder.Cond) ([]*userThird, error) {
+	var userThirds = make([]*userThird, 0)
+	err := _Engine.Asc("create_time").Where(cond).Find(&userThirds)
+	if err != nil {
+		return nil, err
+	}
+	return userThirds, nil
+}
+
+// 第三方绑定数量
+func getUserThirdCount(cond builder.Cond) (int64, error) {
+	userThird := new(userThird)
+	return _Engine.Where(cond).Count(userThird)
+}
+
+// 新增第三方绑定
+func createUserThird(userThird *userThird) error {
+	userThird.Status = consts.Normal
+	userThird.CreateTime = common.Now()
+	userThird.UpdateTime = zeroTime()
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(userThird); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 解除第三方绑定
+func deleteUserThird(userID common.ID, tp string) error {
+	userThird := &userThird{Status: consts.Delete, UpdateTime: common.Now()}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Cols("status", "update_time").Where("user_id = ? AND type = ? AND status = ?", userID, tp, consts.Normal).Update(userThird); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
 // 获取用户列表
 func getUsers(cond builder.Cond, page, limit int) (int64, []*user, error) {
 	if limit <= 0 {
@@ -45,30 +116,53 @@ func getUsers(cond builder.Cond, page, limit int) (int64, []*user, error) {
 	return count, users, nil
 }
 
+// 按主键顺序分批获取用户, 用于导出
+func getUsersAfter(cond builder.Cond, lastID int64, limit int) ([]*user, error) {
+	var users = make([]*user, 0)
+	err := _Engine.Omit("password", "salt", "activate_code").Asc("id").Where(cond).And("id > ?", lastID).Limit(limit).Find(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// 批量获取用户详情, 按用户编号返回
+func getUserInfos(userIDs []common.ID) (map[common.ID]*userInfo, error) {
+	var userInfos = make([]*userInfo, 0)
+	if err := _Engine.Where(builder.In("user_id", userIDs)).Find(&userInfos); err != nil {
+		return nil, err
+	}
+	infos := make(map[common.ID]*userInfo, len(userInfos))
+	for _, userInfo := range userInfos {
+		infos[userInfo.UserID] = userInfo
+	}
+	return infos, nil
+}
+
 // 根据用户ID获取用户信息
 func getUserByID(userID common.ID) (*user, error) {
 	return getUser(builder.Eq{"user_id": userID})
 }
 
-// 根据用户名获取用户信息
-func getUserByName(name string) (*user, error) {
-	return getUser(builder.Eq{"name": name})
+// 根据用户名获取租户内的用户信息
+func getUserByName(tenant, name string) (*user, error) {
+	return getUser(builder.Eq{"tenant": tenantOf(tenant), "name": name})
 }
 
-// 根据邮箱获取用户信息
-func getUserByEmail(email string) (*user, error) {
-	return getUser(builder.Eq{"email": email})
+// 根据邮箱获取租户内的用户信息
+func getUserByEmail(tenant, email string) (*user, error) {
+	return getUser(builder.Eq{"tenant": tenantOf(tenant), "email": email})
 }
 
-// 根据手机号获取用户信息
-func getUserByMobile(mobile string) (*user, error) {
-	return getUser(builder.Eq{"mobile": mobile})
+// 根据手机号获取租户内的用户信息
+func getUserByMobile(tenant, mobile string) (*user, error) {
+	return getUser(builder.Eq{"tenant": tenantOf(tenant), "mobile": mobile})
 }
 
-// 获取第三方绑定的账号
-func getUserByTypeAndOpenID(t string, openID string) (*user, error) {
+// 获取租户内第三方绑定的账号
+func getUserByTypeAndOpenID(tenant, t, openID string) (*user, error) {
 	third := new(userThird)
-	has, err := _Engine.Where("type = ? AND open_id = ? AND status = ?", t, openID, consts.Normal).Get(third)
+	has, err := _Engine.Where("tenant = ? AND type = ? AND open_id = ? AND status = ?", tenantOf(tenant), t, openID, consts.Normal).Get(third)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +176,19 @@ func getUserByTypeAndOpenID(t string, openID string) (*user, error) {
 	return user, nil
 }
 
+// 已到注销时间的用户
+func getUsersToPurge(now time.Time) ([]*user, error) {
+	var users = make([]*user, 0)
+	cond := builder.Eq{"status": consts.Normal}.
+		And(builder.Gt{"delete_time": time.Time(zeroTime())}).
+		And(builder.Lte{"delete_time": now})
+	err := _Engine.Cols("user_id").Where(cond).Find(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // 用户数量
 func getUserCount(cond builder.Cond) (int64, error) {
 	user := new(user)
@@ -134,8 +241,8 @@ func updateLoginErrorForUser(userID common.ID, numb int) error {
 }
 
 // 更新用户详细信息
-func updateMy(userID common.ID, userInfo *userInfo) error {
-	return updateUserInfo(userID, userInfo, "nickname", "gender", "qq", "weixin")
+func updateMy(userID common.ID, userInfo *userInfo, columns ...string) error {
+	return updateUserInfo(userID, userInfo, columns...)
 }
 
 // 更新头像
@@ -189,8 +296,9 @@ func updateLoginForUser(userID common.ID, ip string) error {
 	if err := session.Begin(); err != nil {
 		return err
 	}
-	user := user{Error: 0}
-	if _, err := session.Cols("error").Where("user_id = ?", userID).Update(&user); err != nil {
+	// 登录即取消注销
+	user := user{Error: 0, DeleteTime: zeroTime()}
+	if _, err := session.Cols("error", "delete_time").Where("user_id = ?", userID).Update(&user); err != nil {
 		return err
 	}
 	userLogin := userLogin{UserID: userID, IP: ip, CreateTime: common.Now()}
@@ -218,6 +326,78 @@ func updateEmailForUser(userID common.ID, email string) error {
 	return updateUser(userID, user, "email", "update_time")
 }
 
+// 更新注销时间
+func updateDeleteTimeForUser(userID common.ID, deleteTime common.DateTime) error {
+	user := &user{DeleteTime: deleteTime, UpdateTime: common.Now()}
+	return updateUser(userID, user, "delete_time", "update_time")
+}
+
+// 清除用户数据, 用户名、邮箱、手机号恢复为用户编号以便重新使用
+// 同时移除角色授予记录和组织成员, 用户拥有的组织一并删除
+func purgeUser(userID common.ID) error {
+	now := common.Now()
+	placeholder := userID.Str()
+	salt := common.RandomString(6)
+
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+
+	user := &user{
+		Name:         placeholder,
+		Email:        placeholder,
+		Mobile:       placeholder,
+		Password:     common.MD5(common.RandomString(24) + salt),
+		Salt:         salt,
+		ActivateCode: "",
+		Status:       consts.Delete,
+		UpdateTime:   now,
+	}
+	if _, err := session.Cols("name", "email", "mobile", "password", "salt", "activate_code", "status", "update_time").
+		Where("user_id = ?", userID).Update(user); err != nil {
+		return err
+	}
+
+	userInfo := &userInfo{Gender: consts.Unknown}
+	if _, err := session.Cols("nickname", "avatar", "gender", "qq", "weixin", "province", "city", "county", "ip").
+		Where("user_id = ?", userID).Update(userInfo); err != nil {
+		return err
+	}
+
+	userThird := &userThird{OpenID: "", Status: consts.Delete, UpdateTime: now}
+	if _, err := session.Cols("open_id", "status", "update_time").
+		Where("user_id = ?", userID).Update(userThird); err != nil {
+		return err
+	}
+
+	address := &userAddress{Status: consts.Delete, UpdateTime: now}
+	if _, err := session.Cols("name", "mobile", "province", "city", "county", "address", "zip", "status", "update_time").
+		Where("user_id = ?", userID).Update(address); err != nil {
+		return err
+	}
+
+	if _, err := session.Where("user_id = ?", userID).Delete(new(roleGrant)); err != nil {
+		return err
+	}
+
+	var orgs = make([]*organization, 0)
+	if err := session.Cols("org_id").Where("owner_id = ? AND status = ?", userID, consts.Normal).Find(&orgs); err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := deleteOrgInSession(session, org.OrgID, now); err != nil {
+			return err
+		}
+	}
+	if _, err := session.Where("user_id = ?", userID).Delete(new(orgMember)); err != nil {
+		return err
+	}
+
+	return session.Commit()
+}
+
 // 更新用户信息
 func updateUser(userID common.ID, user *user, columns ...string) error {
 	session := _Engine.NewSession()
@@ -247,31 +427,85 @@ func updateUserInfo(userID common.ID, userInfo *userInfo, columns ...string) err
 	return session.Commit()
 }
 
-func createUser(user *user, userInfo *userInfo, userThird *userThird) error {
+// 创建用户, 同一事务内记录邀请关系和用户同意的协议
+func createUser(user *user, userInfo *userInfo, userThird *userThird, invite *inviteCode, legals []*legal) error {
 	if user == nil || userInfo == nil {
 		return errors.ErrArgument
 	}
+	if err := newUser(user); err != nil {
+		return err
+	}
+
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := insertUser(session, user, userInfo, userThird); err != nil {
+		return err
+	}
+	if invite != nil {
+		if err := useInviteCode(session, invite, user); err != nil {
+			return err
+		}
+	}
+	if err := acceptLegals(session, user.UserID, legals, userInfo.IP); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 批量创建用户, 同一事务
+func createUsers(users []*user, userInfos []*userInfo) error {
+	if len(users) != len(userInfos) {
+		return errors.ErrArgument
+	}
+	for _, user := range users {
+		if err := newUser(user); err != nil {
+			return err
+		}
+	}
+
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	for i, user := range users {
+		if err := insertUser(session, user, userInfos[i], nil); err != nil {
+			return err
+		}
+	}
+	return session.Commit()
+}
+
+// 新用户默认值处理, passwordHashed 的用户保留导入的密码哈希和密钥
+func newUser(user *user) error {
 	uid, err := _IDWorker.Next()
 	if err != nil {
 		return err
 	}
 
 	// 默认时间处理
-	nullDate := common.DateTime(time.Date(1, 1, 1, 0, 0, 0, 0, time.Local))
+	nullDate := zeroTime()
 	user.CreateTime = common.Now()
 	user.ForbiddenTime = nullDate
 	user.LastErrorTime = nullDate
 	user.ActivateTime = nullDate
+	user.DeleteTime = nullDate
 	user.UpdateTime = nullDate
 
 	user.UserID = uid
+	user.Tenant = tenantOf(user.Tenant)
 
-	user.Salt = common.RandomString(6)
-	// 无密码注册，自动生成密码
-	if user.Password == "" {
-		user.Password = common.RandomNumber(12)
+	if !user.passwordHashed {
+		user.Salt = common.RandomString(6)
+		// 无密码注册，自动生成密码
+		if user.Password == "" {
+			user.Password = common.RandomNumber(12)
+		}
+		user.Password = common.MD5(user.Password + user.Salt)
 	}
-	user.Password = common.MD5(user.Password + user.Salt)
 	user.Status = consts.Normal
 	user.Forbidden = consts.Available
 	if user.Activate != consts.UnActivated {
@@ -290,13 +524,10 @@ func createUser(user *user, userInfo *userInfo, userThird *userThird) error {
 	if user.Name == "" {
 		user.Name = user.UserID.Str()
 	}
+	return nil
+}
 
-	session := _Engine.NewSession()
-	defer session.Close()
-	if err := session.Begin(); err != nil {
-		return err
-	}
-
+func insertUser(session *xorm.Session, user *user, userInfo *userInfo, userThird *userThird) error {
 	if _, err := session.Insert(user); err != nil {
 		return err
 	}
@@ -310,9 +541,10 @@ func createUser(user *user, userInfo *userInfo, userThird *userThird) error {
 	// 三方注册
 	if userThird != nil {
 		userThird.UserID = user.UserID
+		userThird.Tenant = user.Tenant
 		userThird.Status = consts.Normal
 		userThird.CreateTime = user.CreateTime
-		userThird.UpdateTime = nullDate
+		userThird.UpdateTime = zeroTime()
 		if _, err := session.Insert(userThird); err != nil {
 			return err
 		}
@@ -331,6 +563,5 @@ func createUser(user *user, userInfo *userInfo, userThird *userThird) error {
 		logger.Error(userLogin)
 		return err
 	}
-
-	return session.Commit()
+	return nil
 }
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/wordfilter"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 可配置保留词和敏感词的字段
+var _WordFields = []string{consts.WordFieldName, consts.WordFieldNickname, consts.WordFieldAddress}
+
+// 各字段命中保留词、敏感词时返回的错误
+var _WordErrors = map[string][2]errors.Error{
+	consts.WordFieldName:     {errors.ErrNameReserved, errors.ErrNameSensitive},
+	consts.WordFieldNickname: {errors.ErrNicknameReserved, errors.ErrNicknameSensitive},
+	consts.WordFieldAddress:  {errors.ErrAddressNameReserved, errors.ErrAddressNameSensitive},
+}
+
+// 保留词和敏感词缓存, 按字段索引
+var _WordCache = struct {
+	sync.RWMutex
+	reserved  map[string]map[string]bool
+	sensitive map[string]*wordfilter.Matcher
+}{}
+
+// CreateWords 批量添加保留词或敏感词, 同类型已存在的词忽略, 返回添加的数量
+func CreateWords(words []string, tp string, fields []string) (int, error) {
+	fields, err := checkWordType(tp, fields)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(words))
+	seen := map[string]bool{}
+	for _, w := range words {
+		w = common.Trim(w)
+		if w == "" {
+			continue
+		}
+		if utf8.RuneCountInString(w) > 50 || wordfilter.Normalize(w) == "" {
+			return 0, errors.ErrWord
+		}
+		if !seen[w] {
+			seen[w] = true
+			keys = append(keys, w)
+		}
+	}
+	if len(keys) == 0 || len(keys) > consts.WordBatchLimit {
+		return 0, errors.ErrWord
+	}
+	_, exists, err := getWords(builder.Eq{"tp": tp, "status": consts.Normal}.And(builder.In("word", keys)), 1, len(keys))
+	if err != nil {
+		return 0, err
+	}
+	for _, exist := range exists {
+		seen[exist.Word] = false
+	}
+	newWords := make([]*word, 0, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			newWords = append(newWords, &word{Word: key, TP: tp, Fields: strings.Join(fields, ",")})
+		}
+	}
+	if len(newWords) == 0 {
+		return 0, nil
+	}
+	if err := createWords(newWords); err != nil {
+		return 0, err
+	}
+	return len(newWords), ReloadWords()
+}
+
+// UpdateWord 更新保留词或敏感词
+func UpdateWord(wordID common.ID, wordDto *st.WordDto) error {
+	if _, err := getWordByID(wordID); err != nil {
+		return err
+	}
+	fields, err := checkWordType(wordDto.TP, wordDto.Fields)
+	if err != nil {
+		return err
+	}
+	w := common.Trim(wordDto.Word)
+	if utf8.RuneCountInString(w) > 50 || wordfilter.Normalize(w) == "" {
+		return errors.ErrWord
+	}
+	if err := updateWord(wordID, &word{Word: w, TP: wordDto.TP, Fields: strings.Join(fields, ",")}); err != nil {
+		return err
+	}
+	return ReloadWords()
+}
+
+// DelWord 删除保留词或敏感词
+func DelWord(wordID common.ID) error {
+	if _, err := getWordByID(wordID); err != nil {
+		return err
+	}
+	if err := deleteWord(wordID); err != nil {
+		return err
+	}
+	return ReloadWords()
+}
+
+// GetWords 保留词和敏感词列表
+func GetWords(query st.WordQuery) (int64, []*st.WordDto, error) {
+	cond := builder.NewCond().And(builder.Eq{"status": consts.Normal})
+	if query.Word != "" {
+		cond = cond.And(builder.Like{"word", query.Word})
+	}
+	if query.TP != "" {
+		cond = cond.And(builder.Eq{"tp": query.TP})
+	}
+	if query.Field != "" {
+		cond = cond.And(builder.Expr("FIND_IN_SET(?, fields) > 0", query.Field))
+	}
+	count, words, err := getWords(cond, query.Page, query.Limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	wordDtos := make([]*st.WordDto, len(words))
+	for i, word := range words {
+		wordDtos[i] = word.dto()
+	}
+	return count, wordDtos, nil
+}
+
+// ReloadWords 重新加载保留词和敏感词缓存, 变更后自动调用
+func ReloadWords() error {
+	words, err := getAllWords()
+	if err != nil {
+		return err
+	}
+	reserved := map[string]map[string]bool{}
+	sensitive := map[string][]string{}
+	for _, word := range words {
+		for _, field := range word.fields() {
+			switch word.TP {
+			case consts.WordReserved:
+				if reserved[field] == nil {
+					reserved[field] = map[string]bool{}
+				}
+				reserved[field][wordfilter.Normalize(word.Word)] = true
+			case consts.WordSensitive:
+				sensitive[field] = append(sensitive[field], word.Word)
+			}
+		}
+	}
+	matchers := make(map[string]*wordfilter.Matcher, len(sensitive))
+	for field, words := range sensitive {
+		matchers[field] = wordfilter.New(words)
+	}
+	_WordCache.Lock()
+	_WordCache.reserved = reserved
+	_WordCache.sensitive = matchers
+	_WordCache.Unlock()
+	return nil
+}
+
+// 检查字段内容是否为保留词或包含敏感词
+func checkWords(field, value string) error {
+	_WordCache.RLock()
+	defer _WordCache.RUnlock()
+	if _WordCache.reserved[field][wordfilter.Normalize(value)] {
+		return _WordErrors[field][0]
+	}
+	if matcher, ok := _WordCache.sensitive[field]; ok {
+		if _, found := matcher.Find(value); found {
+			return _WordErrors[field][1]
+		}
+	}
+	return nil
+}
+
+// 校验类型和适用字段, 返回去重后的字段
+func checkWordType(tp string, fields []string) ([]string, error) {
+	if tp != consts.WordReserved && tp != consts.WordSensitive {
+		return nil, errors.ErrWord
+	}
+	seen := map[string]bool{}
+	for _, field := range fields {
+		field = common.Trim(field)
+		if _, ok := _WordErrors[field]; !ok {
+			return nil, errors.ErrWord
+		}
+		seen[field] = true
+	}
+	checked := make([]string, 0, len(seen))
+	for _, field := range _WordFields {
+		if seen[field] {
+			checked = append(checked, field)
+		}
+	}
+	if len(checked) == 0 {
+		return nil, errors.ErrWord
+	}
+	return checked, nil
+}
+
+func (w *word) fields() []string {
+	if w.Fields == "" {
+		return []string{}
+	}
+	return strings.Split(w.Fields, ",")
+}
+
+func (w *word) dto() *st.WordDto {
+	return &st.WordDto{
+		WordID:     w.WordID,
+		Word:       w.Word,
+		TP:         w.TP,
+		Fields:     w.fields(),
+		CreateTime: w.CreateTime,
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/simplexwork/common"
+	"xorm.io/builder"
+)
+
+// 批量新增词
+func createWords(words []*word) error {
+	now := common.Now()
+	for _, word := range words {
+		wordID, err := _IDWorker.Next()
+		if err != nil {
+			return err
+		}
+		word.WordID = wordID
+		word.Status = consts.Normal
+		word.CreateTime = now
+		word.UpdateTime = now
+	}
+	session := _Engine.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if _, err := session.Insert(&words); err != nil {
+		return err
+	}
+	return session.Commit()
+}
+
+// 更新词
+func updateWord(wordID common.ID, word *word) error {
+	word.UpdateTime = common.Now()
+	_, err := _Engine.Cols("word", "tp", "fields", "update_time").Where("word_id = ?", wordID).Update(word)
+	return err
+}
+
+// 删除词
+func deleteWord(wordID common.ID) error {
+	word := &word{Status: consts.Delete, UpdateTime: common.Now()}
+	_, err := _Engine.Cols("status", "update_time").Where("word_id = ?", wordID).Update(word)
+	return err
+}
+
+// 根据编号获取词
+func getWordByID(wordID common.ID) (*word, error) {
+	word := new(word)
+	has, err := _Engine.Where("word_id = ? AND status = ?", wordID, consts.Normal).Get(word)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errors.ErrWordNotFound
+	}
+	return word, nil
+}
+
+// 词列表
+func getWords(cond builder.Cond, page, limit int) (int64, []*word, error) {
+	if limit <= 0 {
+		limit = consts.PageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	var words = make([]*word, 0)
+	count, err := _Engine.Desc("id").Where(cond).Limit(limit, start).FindAndCount(&words)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, words, nil
+}
+
+// 全部有效的词, 用于构建匹配缓存
+func getAllWords() ([]*word, error) {
+	var words = make([]*word, 0)
+	if err := _Engine.Where("status = ?", consts.Normal).Find(&words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
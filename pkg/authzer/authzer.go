@@ -5,14 +5,17 @@ import (
 	"github.com/casbin/casbin/v2/model"
 	xormadapter "github.com/casbin/xorm-adapter/v2"
 	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
 )
 
+// 资源地址支持 * 通配和从路由同步的 :param 路径参数, env为请求属性, 用于检查策略的条件
 const (
 	_TableName = "at_rules"
 
 	_ModelText = `
 [request_definition]
-r = sub, obj, act
+r = sub, obj, act, env
 
 [policy_definition]
 p = sub, obj, act
@@ -24,23 +27,108 @@ g = _, _
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act || r.sub == "10000"
+m = g(r.sub, p.sub) && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && r.act == p.act && cond(r.sub, r.env, p.sub, p.obj, p.act)
 `
 )
 
-// NewAuthzer .
-func NewAuthzer() *casbin.Enforcer {
-	m, err := model.NewModelFromString(_ModelText)
-	if err != nil {
-		panic(err)
-	}
+// 超级管理员角色的权限
+var superAdminActs = []string{"get", "post", "put", "patch", "delete"}
+
+// NewAuthzer conds为nil时不检查策略的条件
+func NewAuthzer(conds *Conditions) *casbin.Enforcer {
 	a, err := xormadapter.NewAdapterByEngineWithTableName(models.DefauleEngine(), _TableName)
 	if err != nil {
 		panic(err)
 	}
-	e, err := casbin.NewEnforcer(m, a)
+	e, err := newAuthzer(conds, a)
 	if err != nil {
 		panic(err)
 	}
 	return e
 }
+
+func newAuthzer(conds *Conditions, params ...interface{}) (*casbin.Enforcer, error) {
+	m, err := model.NewModelFromString(_ModelText)
+	if err != nil {
+		return nil, err
+	}
+	e, err := casbin.NewEnforcer(append([]interface{}{m}, params...)...)
+	if err != nil {
+		return nil, err
+	}
+	e.AddFunction(_CondFunc, conds.matchFunc())
+	return e, nil
+}
+
+// SetupSuperAdmin 为内置的超级管理员角色添加全部权限, 并授予配置中的用户和角色
+// 授予的关系会保存, 从配置中移除后需要在后台取消
+func SetupSuperAdmin(e *casbin.Enforcer, users, roles []string) error {
+	for _, act := range superAdminActs {
+		if _, err := e.AddPolicy(consts.SuperAdminRole, "/*", act); err != nil {
+			return err
+		}
+	}
+	for _, user := range users {
+		if _, err := e.AddRoleForUser(user, consts.SuperAdminRole); err != nil {
+			return err
+		}
+	}
+	for _, role := range roles {
+		if has, _ := e.HasRoleForUser(role, consts.SuperAdminRole); has {
+			continue
+		}
+		if err := AddRoleParent(e, role, consts.SuperAdminRole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckSuperAdminRoles 授予、取消或继承的角色中有超级管理员或继承自超级管理员的角色时, operator必须是超级管理员
+// 超级管理员包括通过继承拥有的
+func CheckSuperAdminRoles(e *casbin.Enforcer, operator string, roles ...string) error {
+	rm := e.GetRoleManager()
+	for _, role := range roles {
+		super, err := rm.HasLink(role, consts.SuperAdminRole)
+		if err != nil {
+			return err
+		}
+		if !super {
+			continue
+		}
+		has, err := rm.HasLink(operator, consts.SuperAdminRole)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return errors.ErrSuperAdmin
+		}
+		return nil
+	}
+	return nil
+}
+
+// MergeRoles 将source的角色转移给target
+func MergeRoles(e *casbin.Enforcer, source, target string) error {
+	roles, err := e.GetRolesForUser(source)
+	if err != nil {
+		return err
+	}
+	// target已有的角色会导致批量添加失败, 逐个添加
+	for _, role := range roles {
+		if _, err := e.AddRoleForUser(target, role); err != nil {
+			return err
+		}
+	}
+	_, err = e.DeleteRolesForUser(source)
+	return err
+}
+
+// RemoveUser 移除用户在管理后台和各个域中的全部角色
+func RemoveUser(e *casbin.Enforcer, de *DomainEnforcer, user string) error {
+	if _, err := e.DeleteUser(user); err != nil {
+		return err
+	}
+	_, err := de.DeleteUser(user)
+	return err
+}
@@ -0,0 +1,69 @@
+package authzer
+
+import (
+	"testing"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSuperAdmin(t *testing.T) {
+	Convey("超级管理员", t, func() {
+		e, err := newAuthzer(nil)
+		So(err, ShouldBeNil)
+		So(SetupSuperAdmin(e, []string{"100"}, []string{"1"}), ShouldBeNil)
+		// 重复启动不报错
+		So(SetupSuperAdmin(e, []string{"100"}, []string{"1"}), ShouldBeNil)
+		e.AddRoleForUser("200", "1")
+
+		ok, _ := e.Enforce("100", "/v1/admin/user/300", "post", Env{})
+		So(ok, ShouldBeTrue)
+		ok, _ = e.Enforce("200", "/v1/admin/authority/role", "get", Env{})
+		So(ok, ShouldBeTrue)
+
+		Convey("不再有固定编号的特权", func() {
+			ok, _ := e.Enforce("10000", "/v1/admin/user", "get", Env{})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("只有超级管理员可以授予或取消超级管理员", func() {
+			So(CheckSuperAdminRoles(e, "300", consts.SuperAdminRole), ShouldEqual, errors.ErrSuperAdmin)
+			So(CheckSuperAdminRoles(e, "300", "2"), ShouldBeNil)
+			So(CheckSuperAdminRoles(e, "300"), ShouldBeNil)
+			So(CheckSuperAdminRoles(e, "100", consts.SuperAdminRole), ShouldBeNil)
+			So(CheckSuperAdminRoles(e, "200", consts.SuperAdminRole), ShouldBeNil)
+		})
+
+		Convey("继承超级管理员的角色同样限制", func() {
+			So(CheckSuperAdminRoles(e, "300", "1"), ShouldEqual, errors.ErrSuperAdmin)
+			So(CheckSuperAdminRoles(e, "300", "2", "1"), ShouldEqual, errors.ErrSuperAdmin)
+			So(AddRoleParent(e, "7", "1"), ShouldBeNil)
+			So(CheckSuperAdminRoles(e, "300", "7"), ShouldEqual, errors.ErrSuperAdmin)
+			So(CheckSuperAdminRoles(e, "100", "7"), ShouldBeNil)
+			So(CheckSuperAdminRoles(e, "200", "1"), ShouldBeNil)
+		})
+	})
+}
+
+func TestRemoveUser(t *testing.T) {
+	Convey("移除用户的全部角色", t, func() {
+		e, err := newAuthzer(nil)
+		So(err, ShouldBeNil)
+		de, err := newDomainEnforcer(nil)
+		So(err, ShouldBeNil)
+		e.AddPolicy("1", "/v1/admin/user", "get")
+		e.AddRoleForUser("100", "1")
+		e.AddRoleForUser("200", "1")
+		de.AddPolicy("2", "shop", "/order", "get")
+		de.AddRoleForUser("100", "2", "shop")
+
+		So(RemoveUser(e, de, "100"), ShouldBeNil)
+		ok, _ := e.Enforce("100", "/v1/admin/user", "get", Env{})
+		So(ok, ShouldBeFalse)
+		ok, _ = de.Enforce("100", "shop", "/order", "get", Env{})
+		So(ok, ShouldBeFalse)
+		ok, _ = e.Enforce("200", "/v1/admin/user", "get", Env{})
+		So(ok, ShouldBeTrue)
+	})
+}
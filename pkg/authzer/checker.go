@@ -0,0 +1,103 @@
+package authzer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+var _ persist.Watcher = (*Checker)(nil)
+
+// 缓存的决策数量上限, 超过时清空
+const maxCheckEntries = 100000
+
+type checkEntry struct {
+	allow  bool
+	expire time.Time
+}
+
+// Checker 供其他服务调用的权限检查, 使用管理后台相同的Enforcer并缓存决策结果
+// 作为Enforcer的Watcher, 本实例修改策略时清空缓存, 其他实例的修改在缓存过期后生效
+// 条件或有效期变更时清空缓存, 缓存不超过用户角色的过期时间, 有条件限制时间段时不超过下一分钟的开始
+type Checker struct {
+	sync.RWMutex
+	e       *casbin.Enforcer
+	de      *DomainEnforcer
+	grants  *Grants
+	conds   *Conditions
+	ttl     time.Duration
+	entries map[string]checkEntry
+	// 缓存对应的条件和有效期变更次数
+	gen uint64
+}
+
+// NewChecker ttl为0时不缓存, grants和conds为Enforcer使用的有效期和条件, 可以为nil
+func NewChecker(e *casbin.Enforcer, de *DomainEnforcer, grants *Grants, conds *Conditions, ttl time.Duration) *Checker {
+	c := &Checker{e: e, de: de, grants: grants, conds: conds, ttl: ttl, entries: map[string]checkEntry{}}
+	if ttl > 0 {
+		e.SetWatcher(c)
+		de.SetWatcher(c)
+	}
+	return c
+}
+
+// Check 检查sub是否可以在域dom中对obj执行act, dom为空时检查管理后台的权限, env用于检查策略的条件
+func (c *Checker) Check(sub, dom, obj, act string, env Env) (bool, error) {
+	if c.ttl <= 0 {
+		return c.enforce(sub, dom, obj, act, env)
+	}
+	key := sub + "\x00" + dom + "\x00" + obj + "\x00" + act + "\x00" + env.IP + "\x00" + env.Owner
+	now := time.Now()
+	gen := c.grants.generation() + c.conds.generation()
+	c.RLock()
+	entry, ok := c.entries[key]
+	stale := gen != c.gen
+	c.RUnlock()
+	if ok && !stale && now.Before(entry.expire) {
+		return entry.allow, nil
+	}
+	allow, err := c.enforce(sub, dom, obj, act, env)
+	if err != nil {
+		return false, err
+	}
+	expire := now.Add(c.ttl)
+	if next := c.grants.nextExpire(sub, now); !next.IsZero() && next.Before(expire) {
+		expire = next
+	}
+	if next := now.Truncate(time.Minute).Add(time.Minute); c.conds.isTimed() && next.Before(expire) {
+		expire = next
+	}
+	c.Lock()
+	if c.gen != gen || len(c.entries) >= maxCheckEntries {
+		c.entries = map[string]checkEntry{}
+		c.gen = gen
+	}
+	c.entries[key] = checkEntry{allow: allow, expire: expire}
+	c.Unlock()
+	return allow, nil
+}
+
+func (c *Checker) enforce(sub, dom, obj, act string, env Env) (bool, error) {
+	if dom == "" {
+		return c.e.Enforce(sub, obj, act, env)
+	}
+	return c.de.Enforce(sub, dom, obj, act, env)
+}
+
+// SetUpdateCallback 只在本实例内使用, 不接收其他实例的通知
+func (c *Checker) SetUpdateCallback(func(string)) error {
+	return nil
+}
+
+// Update 策略变更, 清空缓存
+func (c *Checker) Update() error {
+	c.Lock()
+	c.entries = map[string]checkEntry{}
+	c.Unlock()
+	return nil
+}
+
+// Close .
+func (c *Checker) Close() {}
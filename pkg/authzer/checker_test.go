@@ -0,0 +1,114 @@
+package authzer
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChecker(t *testing.T) {
+	Convey("权限检查缓存", t, func() {
+		e, err := newAuthzer(nil)
+		So(err, ShouldBeNil)
+		e.AddPolicy("1", "/v1/admin/user/:userID", "get")
+		e.AddRoleForUser("100", "1")
+		de, err := newDomainEnforcer(nil)
+		So(err, ShouldBeNil)
+		de.AddPolicy("2", "shop", "/order/*", "get")
+		de.AddRoleForUser("100", "2", "shop")
+		c := NewChecker(e, de, nil, nil, time.Minute)
+
+		ok, err := c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		ok, _ = c.Check("100", "", "/v1/admin/user/200", "post", Env{})
+		So(ok, ShouldBeFalse)
+		So(len(c.entries), ShouldEqual, 2)
+
+		Convey("域中的权限", func() {
+			ok, _ := c.Check("100", "shop", "/order/1", "get", Env{})
+			So(ok, ShouldBeTrue)
+			ok, _ = c.Check("100", "blog", "/order/1", "get", Env{})
+			So(ok, ShouldBeFalse)
+			ok, _ = c.Check("100", "shop", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("策略变更后清空缓存", func() {
+			e.DeleteRoleForUser("100", "1")
+			So(len(c.entries), ShouldEqual, 0)
+			ok, _ := c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("缓存不超过角色的过期时间", func() {
+			grants := NewGrants()
+			So(grants.Enable(e), ShouldBeNil)
+			grants.Set("100", "1", "", time.Now().Add(50*time.Millisecond))
+			c := NewChecker(e, de, grants, nil, time.Minute)
+			ok, _ := c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeTrue)
+			time.Sleep(60 * time.Millisecond)
+			ok, _ = c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("条件限制时间段时缓存到下一分钟开始", func() {
+			conds := NewConditions()
+			c := NewChecker(e, de, nil, conds, time.Hour)
+			c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(c.entries["100\x00\x00/v1/admin/user/200\x00get\x00\x00"].expire.Sub(time.Now()), ShouldBeGreaterThan, time.Minute)
+
+			work, _ := ParseCondition("09:00-18:00", "", "", false)
+			conds.Set("", "1", "/v1/admin/user/:userID", "get", work)
+			c.Update()
+			c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(c.entries["100\x00\x00/v1/admin/user/200\x00get\x00\x00"].expire.Sub(time.Now()), ShouldBeLessThanOrEqualTo, time.Minute)
+		})
+
+		Convey("条件和有效期变更后清空缓存", func() {
+			conds := NewConditions()
+			e, err := newAuthzer(conds)
+			So(err, ShouldBeNil)
+			e.AddPolicy("1", "/v1/admin/user/:userID", "get")
+			e.AddRoleForUser("100", "1")
+			grants := NewGrants()
+			So(grants.Enable(e), ShouldBeNil)
+			c := NewChecker(e, de, grants, conds, time.Hour)
+			ok, _ := c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeTrue)
+
+			office, _ := ParseCondition("", "", "10.0.0.0/8", false)
+			conds.Set("", "1", "/v1/admin/user/:userID", "get", office)
+			ok, _ = c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeFalse)
+			ok, _ = c.Check("100", "", "/v1/admin/user/200", "get", Env{IP: "10.0.0.1"})
+			So(ok, ShouldBeTrue)
+
+			grants.Set("100", "1", "", time.Now().Add(-time.Second))
+			ok, _ = c.Check("100", "", "/v1/admin/user/200", "get", Env{IP: "10.0.0.1"})
+			So(ok, ShouldBeFalse)
+
+			Convey("重新加载没有变化时保留缓存", func() {
+				grants.Load(nil)
+				ok, _ := c.Check("100", "", "/v1/admin/user/200", "get", Env{IP: "10.0.0.1"})
+				So(ok, ShouldBeTrue)
+				gen := c.gen
+				conds.Load(nil)
+				conds.Load(nil)
+				grants.Load(nil)
+				c.Check("100", "", "/v1/admin/user/200", "get", Env{IP: "10.0.0.1"})
+				So(c.gen, ShouldEqual, gen+1)
+				So(len(c.entries), ShouldEqual, 1)
+			})
+		})
+
+		Convey("不缓存", func() {
+			c := NewChecker(e, de, nil, nil, 0)
+			ok, _ := c.Check("100", "", "/v1/admin/user/200", "get", Env{})
+			So(ok, ShouldBeTrue)
+			So(len(c.entries), ShouldEqual, 0)
+		})
+	})
+}
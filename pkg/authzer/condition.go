@@ -0,0 +1,285 @@
+package authzer
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+)
+
+// 匹配器中检查策略条件的函数
+const _CondFunc = "cond"
+
+// Env 检查权限时的请求属性, 由Authorize中间件或权限检查接口传入
+type Env struct {
+	// 客户端IP
+	IP string
+	// 请求时间, 零值时使用当前时间
+	Time time.Time
+	// 访问对象的所有者, 例如路径中的用户编号
+	Owner string
+}
+
+// Condition 策略的附加条件, 全部满足时策略才生效
+type Condition struct {
+	// 每天允许的时间段, 为一天中的分钟数, start为-1时不限制
+	start, end int
+	// 允许的星期, 为空时不限制
+	weekdays map[time.Weekday]bool
+	// 允许的IP或网段, 为空时不限制
+	nets []*net.IPNet
+	// 只允许访问自己的数据
+	owner bool
+	// 保存的条件无法解析, 策略不生效
+	invalid bool
+}
+
+// ParseCondition hours 为 09:00-18:00 格式, 结束早于开始时跨越零点, 使用服务器时区
+// weekdays 为逗号分隔的 0-6, 0为周日, ipRanges 为逗号分隔的IP或CIDR, 都为空时返回nil
+func ParseCondition(hours, weekdays, ipRanges string, owner bool) (*Condition, error) {
+	if strings.TrimSpace(hours) == "" && strings.TrimSpace(weekdays) == "" && strings.TrimSpace(ipRanges) == "" && !owner {
+		return nil, nil
+	}
+	c := &Condition{start: -1, owner: owner}
+	if hours = strings.TrimSpace(hours); hours != "" {
+		parts := strings.Split(hours, "-")
+		if len(parts) != 2 {
+			return nil, errors.ErrCondition
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, errors.ErrCondition
+		}
+		c.start, c.end = start, end
+	}
+	for _, day := range splitList(weekdays) {
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 0 || n > 6 {
+			return nil, errors.ErrCondition
+		}
+		if c.weekdays == nil {
+			c.weekdays = map[time.Weekday]bool{}
+		}
+		c.weekdays[time.Weekday(n)] = true
+	}
+	for _, r := range splitList(ipRanges) {
+		if strings.Contains(r, "/") {
+			_, ipNet, err := net.ParseCIDR(r)
+			if err != nil {
+				return nil, errors.ErrCondition
+			}
+			c.nets = append(c.nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(r)
+		if ip == nil {
+			return nil, errors.ErrCondition
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			c.nets = append(c.nets, &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)})
+		} else {
+			c.nets = append(c.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+	return c, nil
+}
+
+// Match sub的请求是否满足条件
+func (c *Condition) Match(sub string, env Env) bool {
+	if c.invalid {
+		return false
+	}
+	now := env.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if c.start >= 0 {
+		minute := now.Hour()*60 + now.Minute()
+		if c.start < c.end && (minute < c.start || minute >= c.end) {
+			return false
+		}
+		if c.start > c.end && minute < c.start && minute >= c.end {
+			return false
+		}
+	}
+	if len(c.weekdays) > 0 && !c.weekdays[now.Weekday()] {
+		return false
+	}
+	if len(c.nets) > 0 {
+		ip := net.ParseIP(env.IP)
+		if ip == nil {
+			return false
+		}
+		in := false
+		for _, ipNet := range c.nets {
+			if ipNet.Contains(ip) {
+				in = true
+				break
+			}
+		}
+		if !in {
+			return false
+		}
+	}
+	return !c.owner || (env.Owner != "" && env.Owner == sub)
+}
+
+// Conditions 全部策略的条件, 按域、角色、资源和方法索引
+type Conditions struct {
+	sync.RWMutex
+	conds map[string]*Condition
+	// 有条件限制了时间段或星期
+	timed bool
+	// 条件变更的次数, 用于清空权限检查的缓存
+	gen uint64
+}
+
+// NewConditions .
+func NewConditions() *Conditions {
+	return &Conditions{conds: map[string]*Condition{}}
+}
+
+// Set 设置策略的条件, cond为nil时移除
+func (c *Conditions) Set(domain, role, obj, act string, cond *Condition) {
+	c.Lock()
+	defer c.Unlock()
+	if cond == nil {
+		delete(c.conds, conditionKey(domain, role, obj, act))
+	} else {
+		c.conds[conditionKey(domain, role, obj, act)] = cond
+	}
+	c.timed = anyTimed(c.conds)
+	c.gen++
+}
+
+// Load 替换全部条件, 无法解析的条件使策略不生效
+func (c *Conditions) Load(condDtos []*st.PolicyConditionDto) {
+	conds := make(map[string]*Condition, len(condDtos))
+	for _, dto := range condDtos {
+		cond, err := ParseCondition(dto.Hours, dto.Weekdays, dto.IPRanges, dto.Owner)
+		if err != nil {
+			logger.Errorf("invalid condition of role %s on %s %s: %v", dto.RoleID.Str(), dto.Method, dto.URL, err)
+			cond = &Condition{invalid: true}
+		}
+		if cond == nil {
+			continue
+		}
+		conds[conditionKey(dto.Domain, dto.RoleID.Str(), dto.URL, dto.Method)] = cond
+	}
+	c.Lock()
+	defer c.Unlock()
+	// 定时重新加载, 没有变化时不清空缓存
+	if reflect.DeepEqual(c.conds, conds) {
+		return
+	}
+	c.conds = conds
+	c.timed = anyTimed(conds)
+	c.gen++
+}
+
+// Reload 重新加载保存的条件, 包含其他实例的修改
+func (c *Conditions) Reload() error {
+	condDtos, err := models.GetPolicyConditions()
+	if err != nil {
+		return err
+	}
+	c.Load(condDtos)
+	return nil
+}
+
+// 检查结果是否可能随时间变化, 时间段和星期以分钟为单位, 只在每分钟开始时变化
+func (c *Conditions) isTimed() bool {
+	if c == nil {
+		return false
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c.timed
+}
+
+// 条件变更的次数
+func (c *Conditions) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c.gen
+}
+
+// 匹配器中的条件函数, 为nil时全部策略都没有条件
+func (c *Conditions) matchFunc() func(args ...interface{}) (interface{}, error) {
+	if c == nil {
+		return noCondition
+	}
+	return c.match
+}
+
+// cond(r.sub, r.env, p.sub, p.obj, p.act[, p.dom])
+func (c *Conditions) match(args ...interface{}) (interface{}, error) {
+	if len(args) < 5 {
+		return false, errors.ErrCondition
+	}
+	sub, _ := args[0].(string)
+	env, _ := args[1].(Env)
+	role, _ := args[2].(string)
+	obj, _ := args[3].(string)
+	act, _ := args[4].(string)
+	domain := ""
+	if len(args) > 5 {
+		domain, _ = args[5].(string)
+	}
+	c.RLock()
+	cond, ok := c.conds[conditionKey(domain, role, obj, act)]
+	c.RUnlock()
+	return !ok || cond.Match(sub, env), nil
+}
+
+func anyTimed(conds map[string]*Condition) bool {
+	for _, cond := range conds {
+		if !cond.invalid && (cond.start >= 0 || len(cond.weekdays) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func noCondition(args ...interface{}) (interface{}, error) {
+	return true, nil
+}
+
+func conditionKey(domain, role, obj, act string) string {
+	return domain + "\x00" + role + "\x00" + act + "\x00" + obj
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.ErrCondition
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,91 @@
+package authzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConditions(t *testing.T) {
+	Convey("条件格式", t, func() {
+		_, err := ParseCondition("09:00-18:00", "1,2,3,4,5", "10.0.0.0/8, 192.168.1.1", true)
+		So(err, ShouldBeNil)
+		c, err := ParseCondition("", " ", "", false)
+		So(err, ShouldBeNil)
+		So(c, ShouldBeNil)
+		_, err = ParseCondition("09:00", "", "", false)
+		So(err, ShouldEqual, errors.ErrCondition)
+		_, err = ParseCondition("", "7", "", false)
+		So(err, ShouldEqual, errors.ErrCondition)
+		_, err = ParseCondition("", "", "10.0.0.0/33", false)
+		So(err, ShouldEqual, errors.ErrCondition)
+	})
+
+	Convey("条件匹配", t, func() {
+		monday := time.Date(2020, 6, 1, 10, 0, 0, 0, time.Local)
+
+		c, _ := ParseCondition("09:00-18:00", "1,2,3,4,5", "", false)
+		So(c.Match("100", Env{Time: monday}), ShouldBeTrue)
+		So(c.Match("100", Env{Time: monday.Add(9 * time.Hour)}), ShouldBeFalse)
+		So(c.Match("100", Env{Time: monday.AddDate(0, 0, 5)}), ShouldBeFalse)
+
+		c, _ = ParseCondition("22:00-06:00", "", "", false)
+		So(c.Match("100", Env{Time: monday.Add(14 * time.Hour)}), ShouldBeTrue)
+		So(c.Match("100", Env{Time: monday}), ShouldBeFalse)
+
+		c, _ = ParseCondition("", "", "10.0.0.0/8,192.168.1.1", false)
+		So(c.Match("100", Env{IP: "10.1.2.3"}), ShouldBeTrue)
+		So(c.Match("100", Env{IP: "192.168.1.1"}), ShouldBeTrue)
+		So(c.Match("100", Env{IP: "192.168.1.2"}), ShouldBeFalse)
+		So(c.Match("100", Env{}), ShouldBeFalse)
+
+		c, _ = ParseCondition("", "", "", true)
+		So(c.Match("100", Env{Owner: "100"}), ShouldBeTrue)
+		So(c.Match("100", Env{Owner: "200"}), ShouldBeFalse)
+		So(c.Match("100", Env{}), ShouldBeFalse)
+	})
+
+	Convey("检查权限时检查策略的条件", t, func() {
+		conds := NewConditions()
+		e, err := newAuthzer(conds)
+		So(err, ShouldBeNil)
+		e.AddPolicy("1", "/v1/admin/user/:userID", "get")
+		e.AddPolicy("2", "/v1/admin/user/:userID", "get")
+		e.AddRoleForUser("100", "1")
+		office, _ := ParseCondition("", "", "10.0.0.0/8", false)
+		conds.Set("", "1", "/v1/admin/user/:userID", "get", office)
+
+		ok, _ := e.Enforce("100", "/v1/admin/user/200", "get", Env{IP: "10.0.0.1"})
+		So(ok, ShouldBeTrue)
+		ok, _ = e.Enforce("100", "/v1/admin/user/200", "get", Env{IP: "8.8.8.8"})
+		So(ok, ShouldBeFalse)
+
+		Convey("其他角色的策略不受影响", func() {
+			e.AddRoleForUser("100", "2")
+			ok, _ := e.Enforce("100", "/v1/admin/user/200", "get", Env{IP: "8.8.8.8"})
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("移除条件", func() {
+			conds.Set("", "1", "/v1/admin/user/:userID", "get", nil)
+			ok, _ := e.Enforce("100", "/v1/admin/user/200", "get", Env{IP: "8.8.8.8"})
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("域中的条件", func() {
+			de, err := newDomainEnforcer(conds)
+			So(err, ShouldBeNil)
+			de.AddPolicy("3", "shop", "/order/:userID", "get")
+			de.AddRoleForUser("100", "3", "shop")
+			own, _ := ParseCondition("", "", "", true)
+			conds.Set("shop", "3", "/order/:userID", "get", own)
+
+			ok, _ := de.Enforce("100", "shop", "/order/100", "get", Env{Owner: "100"})
+			So(ok, ShouldBeTrue)
+			ok, _ = de.Enforce("100", "shop", "/order/200", "get", Env{Owner: "200"})
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package authzer
+
+import (
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	xormadapter "github.com/casbin/xorm-adapter/v2"
+	"github.com/ihuanglei/authenticator/models"
+)
+
+const (
+	_DomainTableName = "at_domain_rules"
+
+	_DomainModelText = `
+[request_definition]
+r = sub, dom, obj, act, env
+
+[policy_definition]
+p = sub, dom, obj, act
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && r.dom == p.dom && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && r.act == p.act && cond(r.sub, r.env, p.sub, p.obj, p.act, p.dom)
+`
+)
+
+// DomainEnforcer 应用或租户自定义的权限, 资源和角色属于各自的域, 用户在每个域中的角色独立
+// 管理后台的权限仍由 NewAuthzer 的Enforcer检查, 对应空的域
+type DomainEnforcer struct {
+	*casbin.Enforcer
+}
+
+// NewDomainAuthzer conds为nil时不检查策略的条件
+func NewDomainAuthzer(conds *Conditions) *DomainEnforcer {
+	a, err := xormadapter.NewAdapterByEngineWithTableName(models.DefauleEngine(), _DomainTableName)
+	if err != nil {
+		panic(err)
+	}
+	e, err := newDomainEnforcer(conds, a)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func newDomainEnforcer(conds *Conditions, params ...interface{}) (*DomainEnforcer, error) {
+	m, err := model.NewModelFromString(_DomainModelText)
+	if err != nil {
+		return nil, err
+	}
+	e, err := casbin.NewEnforcer(append([]interface{}{m}, params...)...)
+	if err != nil {
+		return nil, err
+	}
+	e.AddFunction(_CondFunc, conds.matchFunc())
+	return &DomainEnforcer{e}, nil
+}
+
+// MergeRoles 将source在各个域中的角色转移给target
+func (e *DomainEnforcer) MergeRoles(source, target string) error {
+	for _, rule := range e.GetFilteredGroupingPolicy(0, source) {
+		if _, err := e.AddRoleForUser(target, rule[1], rule[2]); err != nil {
+			return err
+		}
+	}
+	_, err := e.RemoveFilteredGroupingPolicy(0, source)
+	return err
+}
@@ -0,0 +1,220 @@
+package authzer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/rbac"
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/common"
+)
+
+// Grants 设置了有效期的用户角色, 过期后检查权限时立即忽略, 由定时任务从权限规则中移除
+type Grants struct {
+	sync.RWMutex
+	// 用户 -> 域和角色 -> 过期时间
+	expires map[string]map[string]time.Time
+	// 有效期变更的次数, 用于清空权限检查的缓存
+	gen uint64
+}
+
+// NewGrants .
+func NewGrants() *Grants {
+	return &Grants{expires: map[string]map[string]time.Time{}}
+}
+
+// Set 设置用户在域中角色的过期时间, 零值为永不过期
+func (g *Grants) Set(user, role, domain string, expire time.Time) {
+	g.Lock()
+	defer g.Unlock()
+	g.set(user, role, domain, expire)
+	g.gen++
+}
+
+func (g *Grants) set(user, role, domain string, expire time.Time) {
+	roles := g.expires[user]
+	if expire.IsZero() {
+		delete(roles, grantKey(role, domain))
+		if len(roles) == 0 {
+			delete(g.expires, user)
+		}
+		return
+	}
+	if roles == nil {
+		roles = map[string]time.Time{}
+		g.expires[user] = roles
+	}
+	roles[grantKey(role, domain)] = expire
+}
+
+// Load 替换全部过期时间
+func (g *Grants) Load(grants []*st.RoleGrantDto) {
+	g.Lock()
+	defer g.Unlock()
+	old := g.expires
+	g.expires = map[string]map[string]time.Time{}
+	for _, grant := range grants {
+		g.set(grant.UserID.Str(), grant.RoleID, grant.Domain, time.Time(grant.ExpireTime))
+	}
+	// 定时重新加载, 没有变化时不清空缓存
+	if !sameExpires(old, g.expires) {
+		g.gen++
+	}
+}
+
+// 有效期变更的次数
+func (g *Grants) generation() uint64 {
+	if g == nil {
+		return 0
+	}
+	g.RLock()
+	defer g.RUnlock()
+	return g.gen
+}
+
+// Expired 用户在域中的角色是否已过期
+func (g *Grants) Expired(user, role, domain string) bool {
+	g.RLock()
+	defer g.RUnlock()
+	expire, ok := g.expires[user][grantKey(role, domain)]
+	return ok && !time.Now().Before(expire)
+}
+
+// 用户的角色中晚于now的最早过期时间, 没有时返回零值
+func (g *Grants) nextExpire(user string, now time.Time) time.Time {
+	if g == nil {
+		return time.Time{}
+	}
+	g.RLock()
+	defer g.RUnlock()
+	var next time.Time
+	for _, expire := range g.expires[user] {
+		if expire.After(now) && (next.IsZero() || expire.Before(next)) {
+			next = expire
+		}
+	}
+	return next
+}
+
+func (g *Grants) hasExpiry(user string) bool {
+	g.RLock()
+	defer g.RUnlock()
+	return len(g.expires[user]) > 0
+}
+
+// Enable 检查权限时忽略过期的用户角色
+func (g *Grants) Enable(e *casbin.Enforcer) error {
+	e.SetRoleManager(&grantRoleManager{RoleManager: e.GetRoleManager(), grants: g})
+	return e.BuildRoleLinks()
+}
+
+// Sweep 从权限规则中移除过期的用户角色, 并重新加载其他实例授予的有效期
+func (g *Grants) Sweep(e *casbin.Enforcer, de *DomainEnforcer) error {
+	expired, err := models.GetExpiredRoleGrants()
+	if err != nil {
+		return err
+	}
+	removed := make([]*st.RoleGrantDto, 0, len(expired))
+	for _, grant := range expired {
+		var err error
+		if grant.Domain == "" {
+			_, err = e.DeleteRoleForUser(grant.UserID.Str(), grant.RoleID)
+		} else {
+			_, err = de.DeleteRoleForUser(grant.UserID.Str(), grant.RoleID, grant.Domain)
+		}
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		removed = append(removed, grant)
+	}
+	if err := models.ExpireRoleGrants(removed); err != nil {
+		return err
+	}
+	return g.Reload()
+}
+
+// FinishMerges 转移已合并账号的角色、授予记录和有效期, 失败的在下次执行时重试
+func (g *Grants) FinishMerges(e *casbin.Enforcer, de *DomainEnforcer) error {
+	err := models.FinishMerges(func(sourceID, targetID common.ID) error {
+		if err := MergeRoles(e, sourceID.Str(), targetID.Str()); err != nil {
+			return err
+		}
+		return de.MergeRoles(sourceID.Str(), targetID.Str())
+	})
+	if err != nil {
+		return err
+	}
+	return g.Reload()
+}
+
+// Reload 从授予记录重新加载过期时间
+func (g *Grants) Reload() error {
+	grants, err := models.GetExpiringRoleGrants()
+	if err != nil {
+		return err
+	}
+	g.Load(grants)
+	return nil
+}
+
+func sameExpires(a, b map[string]map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for user, roles := range a {
+		if len(roles) != len(b[user]) {
+			return false
+		}
+		for key, expire := range roles {
+			if other, ok := b[user][key]; !ok || !other.Equal(expire) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func grantKey(role, domain string) string {
+	return domain + "\x00" + role
+}
+
+// 包装默认的角色管理, 只有用户直接拥有的角色有有效期, 角色之间的继承不受影响
+type grantRoleManager struct {
+	rbac.RoleManager
+	grants *Grants
+}
+
+// HasLink 用户有过期的角色时, 跳过过期的角色逐个检查
+func (rm *grantRoleManager) HasLink(name1, name2 string, domain ...string) (bool, error) {
+	if name1 == name2 || !rm.grants.hasExpiry(name1) {
+		return rm.RoleManager.HasLink(name1, name2, domain...)
+	}
+	dom := ""
+	if len(domain) > 0 {
+		dom = domain[0]
+	}
+	roles, err := rm.RoleManager.GetRoles(name1, domain...)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if rm.grants.Expired(name1, role, dom) {
+			continue
+		}
+		if role == name2 {
+			return true, nil
+		}
+		ok, err := rm.RoleManager.HasLink(role, name2, domain...)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
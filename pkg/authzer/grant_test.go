@@ -0,0 +1,55 @@
+package authzer
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGrants(t *testing.T) {
+	Convey("有效期角色", t, func() {
+		g := NewGrants()
+		e, err := newAuthzer(nil)
+		So(err, ShouldBeNil)
+		So(g.Enable(e), ShouldBeNil)
+		e.AddPolicy("1", "/v1/admin/user", "get")
+		e.AddPolicy("2", "/v1/admin/tenant", "get")
+		e.AddRoleForUser("100", "1")
+		e.AddRoleForUser("100", "3")
+		AddRoleParent(e, "3", "2")
+
+		ok, _ := e.Enforce("100", "/v1/admin/tenant", "get", Env{})
+		So(ok, ShouldBeTrue)
+
+		Convey("过期后立即忽略, 其他角色不受影响", func() {
+			g.Set("100", "3", "", time.Now().Add(-time.Second))
+			ok, _ := e.Enforce("100", "/v1/admin/tenant", "get", Env{})
+			So(ok, ShouldBeFalse)
+			ok, _ = e.Enforce("100", "/v1/admin/user", "get", Env{})
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("未过期", func() {
+			g.Set("100", "3", "", time.Now().Add(time.Hour))
+			ok, _ := e.Enforce("100", "/v1/admin/tenant", "get", Env{})
+			So(ok, ShouldBeTrue)
+			g.Set("100", "3", "", time.Time{})
+			So(g.hasExpiry("100"), ShouldBeFalse)
+		})
+
+		Convey("域中的角色", func() {
+			de, err := newDomainEnforcer(nil)
+			So(err, ShouldBeNil)
+			So(g.Enable(de.Enforcer), ShouldBeNil)
+			de.AddPolicy("5", "shop", "/order", "get")
+			de.AddRoleForUser("100", "5", "shop")
+			g.Set("100", "5", "shop", time.Now().Add(-time.Second))
+			ok, _ := de.Enforce("100", "shop", "/order", "get", Env{})
+			So(ok, ShouldBeFalse)
+			// 管理后台的同名角色不受影响
+			ok, _ = e.Enforce("100", "/v1/admin/user", "get", Env{})
+			So(ok, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package authzer
+
+import (
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+)
+
+const (
+	// 所有组织通用的策略
+	_AllOrgs = "*"
+
+	// 请求的sub为成员在组织中的角色, 成员关系只保存在组织成员表中
+	_OrgModelText = `
+[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && (p.dom == "*" || r.dom == p.dom) && keyMatch2(r.obj, p.obj) && r.act == p.act
+`
+)
+
+// 组织角色的默认权限
+var orgPolicies = []struct {
+	role consts.OrgRole
+	path string
+	act  string
+}{
+	{consts.OrgMember, "/v1/api/org/:orgID", "get"},
+	{consts.OrgMember, "/v1/api/org/:orgID/member", "get"},
+	{consts.OrgMember, "/v1/api/org/:orgID/leave", "post"},
+	{consts.OrgAdmin, "/v1/api/org/:orgID/update", "post"},
+	{consts.OrgAdmin, "/v1/api/org/:orgID/invite", "get"},
+	{consts.OrgAdmin, "/v1/api/org/:orgID/invite", "post"},
+	{consts.OrgAdmin, "/v1/api/org/:orgID/invite/:inviteID/revoke", "post"},
+	{consts.OrgAdmin, "/v1/api/org/:orgID/member/:userID/remove", "post"},
+	{consts.OrgOwner, "/v1/api/org/:orgID/delete", "post"},
+	{consts.OrgOwner, "/v1/api/org/:orgID/transfer", "post"},
+	{consts.OrgOwner, "/v1/api/org/:orgID/member/:userID/role", "post"},
+}
+
+// OrgEnforcer 组织内权限, 按成员在组织中的角色检查
+type OrgEnforcer struct {
+	*casbin.Enforcer
+}
+
+// NewOrgAuthzer .
+func NewOrgAuthzer() *OrgEnforcer {
+	e, err := newOrgEnforcer()
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func newOrgEnforcer() (*OrgEnforcer, error) {
+	m, err := model.NewModelFromString(_OrgModelText)
+	if err != nil {
+		return nil, err
+	}
+	e, err := casbin.NewEnforcer(m)
+	if err != nil {
+		return nil, err
+	}
+	// 高级别角色拥有低级别角色的全部权限
+	for _, policy := range orgPolicies {
+		for _, role := range []consts.OrgRole{consts.OrgOwner, consts.OrgAdmin, consts.OrgMember} {
+			if role.Level() < policy.role.Level() {
+				continue
+			}
+			if _, err := e.AddPolicy(string(role), _AllOrgs, policy.path, policy.act); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &OrgEnforcer{e}, nil
+}
+
+// Enforce 检查组织中角色为role的成员是否可以对obj执行act
+func (e *OrgEnforcer) Enforce(role consts.OrgRole, orgID, obj, act string) (bool, error) {
+	return e.Enforcer.Enforce(string(role), orgID, obj, act)
+}
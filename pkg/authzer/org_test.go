@@ -0,0 +1,38 @@
+package authzer
+
+import (
+	"testing"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrgEnforcer(t *testing.T) {
+	Convey("组织内权限", t, func() {
+		e, err := newOrgEnforcer()
+		So(err, ShouldBeNil)
+
+		Convey("按成员角色检查", func() {
+			ok, _ := e.Enforce(consts.OrgMember, "1", "/v1/api/org/1/update", "post")
+			So(ok, ShouldBeFalse)
+			ok, _ = e.Enforce(consts.OrgAdmin, "2", "/v1/api/org/2/update", "post")
+			So(ok, ShouldBeTrue)
+			ok, _ = e.Enforce(consts.OrgMember, "2", "/v1/api/org/2", "get")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("高级别角色拥有低级别角色的权限", func() {
+			ok, _ := e.Enforce(consts.OrgOwner, "1", "/v1/api/org/1/member", "get")
+			So(ok, ShouldBeTrue)
+			ok, _ = e.Enforce(consts.OrgOwner, "1", "/v1/api/org/1/member/200/role", "post")
+			So(ok, ShouldBeTrue)
+			ok, _ = e.Enforce(consts.OrgAdmin, "2", "/v1/api/org/2/transfer", "post")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("非成员没有权限", func() {
+			ok, _ := e.Enforce("", "1", "/v1/api/org/1", "get")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package authzer
+
+import (
+	"github.com/casbin/casbin/v2"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+)
+
+// casbin默认的角色管理最多查找10层, 包含用户到角色的一层, 超过的继承不生效
+const _MaxHierarchyLevel = 10
+
+// AddRoleParent 角色继承上级角色的全部权限, 与用户的角色共用 g
+// 域中的角色传入domain, 只在同一个域中继承
+func AddRoleParent(e *casbin.Enforcer, role, parent string, domain ...string) error {
+	if role == parent {
+		return errors.ErrRoleCycle
+	}
+	ancestors, err := GetRoleAncestors(e, parent, domain...)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == role {
+			return errors.ErrRoleCycle
+		}
+	}
+	up, err := depthUp(e, parent, domain...)
+	if err != nil {
+		return err
+	}
+	// 下级中的用户和角色无法区分, 按最下级的角色还会分配给用户计算
+	down := depthDown(e, role, domain...) + 1
+	if down+1+up > _MaxHierarchyLevel {
+		return errors.ErrRoleDepth
+	}
+	_, err = e.AddGroupingPolicy(grouping(role, parent, domain)...)
+	return err
+}
+
+// RemoveRoleParent 取消继承
+func RemoveRoleParent(e *casbin.Enforcer, role, parent string, domain ...string) error {
+	_, err := e.RemoveGroupingPolicy(grouping(role, parent, domain)...)
+	return err
+}
+
+// GetRoleParents 直接继承的上级角色
+func GetRoleParents(e *casbin.Enforcer, role string, domain ...string) ([]string, error) {
+	return e.GetRolesForUser(role, domain...)
+}
+
+// GetRoleAncestors 全部上级角色, 近的在前
+func GetRoleAncestors(e *casbin.Enforcer, role string, domain ...string) ([]string, error) {
+	var ancestors []string
+	seen := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		parents, err := e.GetRolesForUser(queue[0], domain...)
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+		for _, parent := range parents {
+			if !seen[parent] {
+				seen[parent] = true
+				ancestors = append(ancestors, parent)
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return ancestors, nil
+}
+
+// RemoveRole 移除角色的权限和继承关系, isRole用于区分下级中的角色和用户, 用户保留已分配的角色
+func RemoveRole(e *casbin.Enforcer, role string, isRole func(string) bool, domain ...string) error {
+	if _, err := e.RemoveFilteredPolicy(0, role); err != nil {
+		return err
+	}
+	if _, err := e.RemoveFilteredGroupingPolicy(0, role); err != nil {
+		return err
+	}
+	// 没有下级时返回错误, 忽略
+	children, _ := e.GetUsersForRole(role, domain...)
+	for _, child := range children {
+		if !isRole(child) {
+			continue
+		}
+		if _, err := e.RemoveGroupingPolicy(grouping(child, role, domain)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 向上最多的继承层数
+func depthUp(e *casbin.Enforcer, role string, domain ...string) (int, error) {
+	parents, err := e.GetRolesForUser(role, domain...)
+	if err != nil {
+		return 0, err
+	}
+	depth := 0
+	for _, parent := range parents {
+		d, err := depthUp(e, parent, domain...)
+		if err != nil {
+			return 0, err
+		}
+		if d+1 > depth {
+			depth = d + 1
+		}
+	}
+	return depth, nil
+}
+
+// 向下最多的层数, 包含拥有角色的用户和下级角色
+func depthDown(e *casbin.Enforcer, role string, domain ...string) int {
+	// 没有下级时返回错误, 忽略
+	children, _ := e.GetUsersForRole(role, domain...)
+	depth := 0
+	for _, child := range children {
+		if d := depthDown(e, child, domain...) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// 继承关系的参数, 域中的角色带上domain
+func grouping(role, parent string, domain []string) []interface{} {
+	params := []interface{}{role, parent}
+	for _, d := range domain {
+		params = append(params, d)
+	}
+	return params
+}
@@ -0,0 +1,87 @@
+package authzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRoleParent(t *testing.T) {
+	Convey("角色继承", t, func() {
+		e, err := newAuthzer(nil)
+		So(err, ShouldBeNil)
+		e.AddPolicy("1", "/v1/admin/user", "get")
+		e.AddPolicy("2", "/v1/admin/tenant", "get")
+		e.AddRoleForUser("100", "3")
+		So(AddRoleParent(e, "3", "2"), ShouldBeNil)
+		So(AddRoleParent(e, "2", "1"), ShouldBeNil)
+
+		Convey("继承上级角色的权限", func() {
+			ok, _ := e.Enforce("100", "/v1/admin/user", "get", Env{})
+			So(ok, ShouldBeTrue)
+			ok, _ = e.Enforce("100", "/v1/admin/tenant", "get", Env{})
+			So(ok, ShouldBeTrue)
+			ancestors, err := GetRoleAncestors(e, "3")
+			So(err, ShouldBeNil)
+			So(ancestors, ShouldResemble, []string{"2", "1"})
+		})
+
+		Convey("循环继承", func() {
+			So(AddRoleParent(e, "1", "3"), ShouldEqual, errors.ErrRoleCycle)
+			So(AddRoleParent(e, "1", "1"), ShouldEqual, errors.ErrRoleCycle)
+		})
+
+		Convey("继承层数", func() {
+			for i := 10; i < 16; i++ {
+				So(AddRoleParent(e, fmt.Sprint(i+1), fmt.Sprint(i)), ShouldBeNil)
+			}
+			// 用户 -> 16 -> ... -> 10 -> 3 -> 2 -> 1 共10层
+			So(AddRoleParent(e, "10", "3"), ShouldBeNil)
+			So(AddRoleParent(e, "1", "0"), ShouldEqual, errors.ErrRoleDepth)
+			So(AddRoleParent(e, "15", "2"), ShouldBeNil)
+		})
+
+		Convey("删除角色", func() {
+			isRole := func(s string) bool { return s != "100" }
+			So(RemoveRole(e, "2", isRole), ShouldBeNil)
+			ok, _ := e.Enforce("100", "/v1/admin/user", "get", Env{})
+			So(ok, ShouldBeFalse)
+			roles, _ := e.GetRolesForUser("100")
+			So(roles, ShouldResemble, []string{"3"})
+		})
+	})
+}
+
+func TestDomainRoleParent(t *testing.T) {
+	Convey("域中的角色继承", t, func() {
+		e, err := newDomainEnforcer(nil)
+		So(err, ShouldBeNil)
+		e.AddPolicy("1", "shop", "/order", "get")
+		e.AddRoleForUser("100", "2", "shop")
+		e.AddRoleForUser("100", "2", "blog")
+		So(AddRoleParent(e.Enforcer, "2", "1", "shop"), ShouldBeNil)
+
+		ok, _ := e.Enforce("100", "shop", "/order", "get", Env{})
+		So(ok, ShouldBeTrue)
+		ok, _ = e.Enforce("100", "blog", "/order", "get", Env{})
+		So(ok, ShouldBeFalse)
+		So(AddRoleParent(e.Enforcer, "1", "2", "shop"), ShouldEqual, errors.ErrRoleCycle)
+
+		So(RemoveRoleParent(e.Enforcer, "2", "1", "shop"), ShouldBeNil)
+		ok, _ = e.Enforce("100", "shop", "/order", "get", Env{})
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("合并用户在各个域中的角色", t, func() {
+		e, err := newDomainEnforcer(nil)
+		So(err, ShouldBeNil)
+		e.AddRoleForUser("100", "1", "shop")
+		e.AddRoleForUser("100", "2", "blog")
+		So(e.MergeRoles("100", "200"), ShouldBeNil)
+		So(e.GetRolesForUserInDomain("200", "shop"), ShouldResemble, []string{"1"})
+		So(e.GetRolesForUserInDomain("200", "blog"), ShouldResemble, []string{"2"})
+		So(e.GetRolesForUserInDomain("100", "shop"), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,227 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// 注册gif、png解码
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/ihuanglei/authenticator/pkg/errors"
+)
+
+// ContentType 处理后的头像格式
+const ContentType = "image/jpeg"
+
+// Ext 处理后的头像扩展名
+const Ext = ".jpg"
+
+// DefaultMaxSize 默认头像文件大小上限(KB)
+const DefaultMaxSize = 2048
+
+// DefaultSizes 默认生成的头像尺寸
+var DefaultSizes = []int{256, 128, 64}
+
+// 解码前限制图片像素数, 防止小文件解压出超大图片
+const maxPixels = 40000000
+
+const jpegQuality = 90
+
+// 支持的头像格式
+var _types = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Image 处理后的头像
+type Image struct {
+	Size int
+	Data []byte
+}
+
+// Process 校验头像格式, 按EXIF方向摆正后居中裁剪为正方形, 缩放为各尺寸并重新编码为JPEG
+// 重新编码后不保留EXIF等元数据, 透明背景填充为白色
+func Process(data []byte, sizes []int) ([]*Image, error) {
+	contentType := http.DetectContentType(data)
+	if !_types[contentType] {
+		return nil, errors.ErrAvatarType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, errors.ErrAvatarImage
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.ErrAvatarImage
+	}
+	img := cropSquare(src)
+	if contentType == "image/jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	images := make([]*Image, 0, len(sizes))
+	for _, size := range sizes {
+		if size <= 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(img, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		images = append(images, &Image{Size: size, Data: buf.Bytes()})
+	}
+	return images, nil
+}
+
+// 居中裁剪为正方形, 绘制在白色背景上
+func cropSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	size := b.Dx()
+	if b.Dy() < size {
+		size = b.Dy()
+	}
+	offset := image.Pt(b.Min.X+(b.Dx()-size)/2, b.Min.Y+(b.Dy()-size)/2)
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, offset, draw.Over)
+	return dst
+}
+
+// 区域平均缩放, 放大时为最近邻
+func resize(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0, sy1 := span(dy, size, h)
+		for dx := 0; dx < size; dx++ {
+			sx0, sx1 := span(dx, size, w)
+			var r, g, b, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					b += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// 目标像素对应的源像素范围, 至少包含一个像素
+func span(d, dst, src int) (int, int) {
+	s0 := d * src / dst
+	s1 := (d + 1) * src / dst
+	if s1 <= s0 {
+		s1 = s0 + 1
+	}
+	return s0, s1
+}
+
+// 按EXIF方向旋转或翻转, 返回摆正后的图片
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// 读取JPEG中EXIF的方向, 没有或无法解析时返回1
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// 从TIFF结构的IFD0中读取方向标签0x0112
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
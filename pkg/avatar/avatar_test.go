@@ -0,0 +1,155 @@
+package avatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/storage"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// 左半边红色右半边蓝色的图片
+func halfImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= w/2 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// 在JPEG的SOI之后插入带方向标签的EXIF段
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(segment) + 2
+	app1 := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, segment...)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, halfImage(300, 200), nil)
+	src := buf.Bytes()
+
+	Convey("crop and resize", t, func() {
+		images, err := Process(src, []int{128, 64, 0})
+		So(err, ShouldBeNil)
+		So(len(images), ShouldEqual, 2)
+		for _, img := range images {
+			config, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+			So(err, ShouldBeNil)
+			So(format, ShouldEqual, "jpeg")
+			So(config.Width, ShouldEqual, img.Size)
+			So(config.Height, ShouldEqual, img.Size)
+		}
+	})
+
+	Convey("exif is stripped", t, func() {
+		data := withOrientation(src, 6)
+		So(exifOrientation(data), ShouldEqual, 6)
+		images, err := Process(data, []int{32})
+		So(err, ShouldBeNil)
+		So(bytes.Contains(images[0].Data, []byte("Exif")), ShouldBeFalse)
+	})
+
+	Convey("transparent png on white", t, func() {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10)))
+		images, err := Process(buf.Bytes(), []int{4})
+		So(err, ShouldBeNil)
+		img, _ := jpeg.Decode(bytes.NewReader(images[0].Data))
+		r, g, b, _ := img.At(2, 2).RGBA()
+		So(r>>8, ShouldBeGreaterThan, 250)
+		So(g>>8, ShouldBeGreaterThan, 250)
+		So(b>>8, ShouldBeGreaterThan, 250)
+	})
+
+	Convey("unsupported type", t, func() {
+		_, err := Process([]byte("<svg></svg>"), []int{64})
+		So(err, ShouldEqual, errors.ErrAvatarType)
+	})
+
+	Convey("broken image", t, func() {
+		_, err := Process(src[:100], []int{64})
+		So(err, ShouldEqual, errors.ErrAvatarImage)
+	})
+}
+
+func TestOrient(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	img := halfImage(4, 4)
+
+	Convey("rotate 90 clockwise", t, func() {
+		dst := orient(img, 6)
+		So(dst.RGBAAt(0, 0), ShouldResemble, red)
+		So(dst.RGBAAt(3, 0), ShouldResemble, red)
+		So(dst.RGBAAt(0, 3), ShouldResemble, blue)
+	})
+
+	Convey("rotate 90 counterclockwise", t, func() {
+		dst := orient(img, 8)
+		So(dst.RGBAAt(0, 0), ShouldResemble, blue)
+		So(dst.RGBAAt(0, 3), ShouldResemble, red)
+	})
+
+	Convey("flip horizontal", t, func() {
+		dst := orient(img, 2)
+		So(dst.RGBAAt(0, 0), ShouldResemble, blue)
+		So(dst.RGBAAt(3, 0), ShouldResemble, red)
+	})
+
+	Convey("no orientation", t, func() {
+		So(orient(img, 1), ShouldEqual, img)
+		So(exifOrientation([]byte("not a jpeg")), ShouldEqual, 1)
+	})
+}
+
+func TestRemove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avatar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := storage.NewLocalStorage(dir, "/upload")
+
+	Convey("删除同一次上传的全部尺寸, 只删除该用户的", t, func() {
+		name := Name(1, time.Now())
+		var urls []string
+		for _, size := range []int{256, 64} {
+			url, err := store.Put(Key(name, size), []byte("jpeg"), ContentType)
+			So(err, ShouldBeNil)
+			urls = append(urls, url)
+		}
+		So(Remove(store, 2, urls[0], []int{256, 64}), ShouldBeNil)
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(Key(name, 64))))
+		So(err, ShouldBeNil)
+
+		So(Remove(store, 1, urls[0], []int{256, 64}), ShouldBeNil)
+		for _, size := range []int{256, 64} {
+			_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(Key(name, size))))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		}
+	})
+
+	Convey("其他地址忽略", t, func() {
+		So(Remove(store, 1, "https://example.com/a.jpg", nil), ShouldBeNil)
+	})
+}
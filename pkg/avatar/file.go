@@ -0,0 +1,50 @@
+package avatar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/storage"
+	"github.com/simplexwork/common"
+)
+
+// 上传头像的目录
+const dir = "avatar/"
+
+// Name 用户上传头像的文件名前缀, 各尺寸的文件名为 前缀_尺寸.jpg
+func Name(userID common.ID, t time.Time) string {
+	return fmt.Sprintf("%s%v/%v", dir, userID, t.UnixNano())
+}
+
+// Key 头像尺寸的文件名
+func Key(name string, size int) string {
+	return fmt.Sprintf("%s_%d%s", name, size, Ext)
+}
+
+// Remove 删除用户上传的头像及同一次上传的其他尺寸, 不是该用户上传到store的头像忽略
+// sizes为空时使用默认尺寸
+func Remove(store storage.Storage, userID common.ID, url string, sizes []int) error {
+	key, ok := store.Key(url)
+	if !ok || !strings.HasPrefix(key, fmt.Sprintf("%s%v/", dir, userID)) || !strings.HasSuffix(key, Ext) {
+		return nil
+	}
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return nil
+	}
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	name := key[:i]
+	keys := map[string]bool{key: true}
+	for _, size := range sizes {
+		keys[Key(name, size)] = true
+	}
+	for key := range keys {
+		if err := store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -16,6 +16,17 @@ type Config struct {
 		Port      int    `yaml:"port"`
 		JWTSecret string `yaml:"secret"`
 		Expire    int64  `yaml:"expire"`
+		// 可信代理, CIDR或IP
+		TrustedProxies []string `yaml:"trusted_proxies"`
+		// 注销宽限期(天)
+		DeleteGrace int64 `yaml:"delete_grace"`
+	}
+	Register struct {
+		Invite bool `yaml:"invite"`
+	}
+	Tenant struct {
+		Enable bool   `yaml:"enable"`
+		Header string `yaml:"header"`
 	}
 	Mysql struct {
 		Host         string `yaml:"host"`
@@ -30,6 +41,46 @@ type Config struct {
 		Sync         bool   `yaml:"sync"`
 		UseCache     bool   `yaml:"use_cache"`
 	}
+	Export struct {
+		Dir    string `yaml:"dir"`
+		Expire int64  `yaml:"expire"`
+	}
+	Avatar struct {
+		MaxSize int64 `yaml:"max_size"`
+		Sizes   []int `yaml:"sizes"`
+	}
+	Storage struct {
+		Type  string `yaml:"type"`
+		Local struct {
+			Dir string `yaml:"dir"`
+			URL string `yaml:"url"`
+		}
+		S3 struct {
+			Endpoint  string `yaml:"endpoint"`
+			Region    string `yaml:"region"`
+			Bucket    string `yaml:"bucket"`
+			AccessKey string `yaml:"access_key"`
+			SecretKey string `yaml:"secret_key"`
+			URL       string `yaml:"url"`
+			PathStyle bool   `yaml:"path_style"`
+		}
+	}
+	Moderation struct {
+		Enable  bool   `yaml:"enable"`
+		Webhook string `yaml:"webhook"`
+		Timeout int64  `yaml:"timeout"`
+	}
+	Admin struct {
+		Users []string `yaml:"users"`
+		Roles []string `yaml:"roles"`
+	}
+	Authz struct {
+		Cache   int64 `yaml:"cache"`
+		Clients []struct {
+			ID     string `yaml:"id"`
+			Secret string `yaml:"secret"`
+		} `yaml:"clients"`
+	}
 	Cache  string `yaml:"cache"`
 	Memory struct {
 		Size int `yaml:"size"`
@@ -6,12 +6,90 @@ const HeaderAuthorizationKey = "X-ACMS-Authorization"
 // HeaderAuthorizationAdminKey header管理后台鉴权key
 const HeaderAuthorizationAdminKey = "X-AACMS-Authorization"
 
+// HeaderTenantKey header默认租户key
+const HeaderTenantKey = "X-ACMS-Tenant"
+
+// DefaultTenant 默认租户, 未开启多租户或未识别到租户时使用
+const DefaultTenant = "default"
+
 // LoginErrorCount 登录错误次数
 const LoginErrorCount = 5
 
 // PageSize 每页默认长度
 const PageSize = 20
 
+// AddressLimit 每个用户地址数量上限
+const AddressLimit = 20
+
+// DeleteGrace 默认注销宽限期(天)
+const DeleteGrace = 15
+
+// ImportLimit 单次导入用户数量上限
+const ImportLimit = 5000
+
+// ImportBatchSize 导入用户每批数量, 每批一个事务
+const ImportBatchSize = 100
+
+// ExportBatchSize 管理员导出用户每批读取数量
+const ExportBatchSize = 500
+
+// OrgInviteExpire 组织邀请有效期(天)
+const OrgInviteExpire = 7
+
+// InviteBatchLimit 单次生成注册邀请码数量上限
+const InviteBatchLimit = 100
+
+// LegalTerms 协议类型, 服务条款
+const LegalTerms = "terms"
+
+// LegalPrivacy 协议类型, 隐私政策
+const LegalPrivacy = "privacy"
+
+// WordReserved 保留词, 与整个内容相同时禁止使用
+const WordReserved = "reserved"
+
+// WordSensitive 敏感词, 内容中包含时禁止使用
+const WordSensitive = "sensitive"
+
+// WordFieldName 保留词和敏感词适用的字段, 用户名
+const WordFieldName = "name"
+
+// WordFieldNickname 保留词和敏感词适用的字段, 昵称
+const WordFieldNickname = "nickname"
+
+// WordFieldAddress 保留词和敏感词适用的字段, 地址收货人
+const WordFieldAddress = "address"
+
+// WordBatchLimit 单次添加保留词和敏感词数量上限
+const WordBatchLimit = 500
+
+// ResourceRoute 资源来源, 启动时从已注册的路由同步
+const ResourceRoute = "route"
+
+// ResourceCustom 资源来源, 管理员自定义
+const ResourceCustom = "custom"
+
+// SuperAdminRole 内置的超级管理员角色, 拥有管理后台的全部权限
+const SuperAdminRole = "superadmin"
+
+// GrantActionGrant 用户角色变更, 授予
+const GrantActionGrant = "grant"
+
+// GrantActionRevoke 用户角色变更, 取消
+const GrantActionRevoke = "revoke"
+
+// GrantActionExpire 用户角色变更, 过期移除
+const GrantActionExpire = "expire"
+
+// GrantActionRenew 用户角色变更, 修改已有角色的有效期
+const GrantActionRenew = "renew"
+
+// ModerationFieldNickname 需要审核的字段, 昵称
+const ModerationFieldNickname = "nickname"
+
+// ModerationFieldAvatar 需要审核的字段, 头像
+const ModerationFieldAvatar = "avatar"
+
 // Mode 注册方式
 type Mode int
 
@@ -26,6 +104,26 @@ const (
 	Third Mode = 4
 )
 
+// Str 返回值
+func (m Mode) Str() string {
+	switch m {
+	case Name:
+		return "name"
+	case Email:
+		return "email"
+	case Mobile:
+		return "mobile"
+	case Third:
+		return "third"
+	}
+	return "unknown"
+}
+
+// MarshalText json格式返回
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.Str()), nil
+}
+
 // Status 数据逻辑状态
 type Status int
 
@@ -170,6 +268,120 @@ func NewGender(val string) Gender {
 	}
 }
 
+// Visibility 自定义属性可见范围
+type Visibility string
+
+const (
+	// VisibilityUser 用户可见可编辑
+	VisibilityUser Visibility = "user"
+	// VisibilityAdmin 仅管理员可见可编辑
+	VisibilityAdmin Visibility = "admin"
+	// VisibilityPublic 公开, 用户可编辑
+	VisibilityPublic Visibility = "public"
+)
+
+// Valid 是否有效
+func (v Visibility) Valid() bool {
+	return v == VisibilityUser || v == VisibilityAdmin || v == VisibilityPublic
+}
+
+// AttrType 自定义属性类型
+type AttrType string
+
+const (
+	// AttrString 字符串
+	AttrString AttrType = "string"
+	// AttrNumber 数字
+	AttrNumber AttrType = "number"
+	// AttrBool 布尔
+	AttrBool AttrType = "bool"
+	// AttrDate 日期 yyyy-MM-dd
+	AttrDate AttrType = "date"
+	// AttrEnum 枚举
+	AttrEnum AttrType = "enum"
+)
+
+// Valid 是否有效
+func (t AttrType) Valid() bool {
+	switch t {
+	case AttrString, AttrNumber, AttrBool, AttrDate, AttrEnum:
+		return true
+	}
+	return false
+}
+
+// OrgRole 组织成员角色
+type OrgRole string
+
+const (
+	// OrgOwner 所有者
+	OrgOwner OrgRole = "owner"
+	// OrgAdmin 管理员
+	OrgAdmin OrgRole = "admin"
+	// OrgMember 成员
+	OrgMember OrgRole = "member"
+)
+
+// Valid 是否有效
+func (r OrgRole) Valid() bool {
+	return r.Level() > 0
+}
+
+// Level 角色级别, 级别高的可以管理级别低的成员
+func (r OrgRole) Level() int {
+	switch r {
+	case OrgOwner:
+		return 3
+	case OrgAdmin:
+		return 2
+	case OrgMember:
+		return 1
+	}
+	return 0
+}
+
+// ModerationStatus 审核状态
+type ModerationStatus int
+
+const (
+	// ModerationPending 待审核
+	ModerationPending ModerationStatus = 0
+	// ModerationApproved 审核通过
+	ModerationApproved ModerationStatus = 1
+	// ModerationRejected 审核拒绝
+	ModerationRejected ModerationStatus = -1
+	// ModerationCanceled 被新的修改替代或撤回
+	ModerationCanceled ModerationStatus = -2
+)
+
+// Str 返回值
+func (m ModerationStatus) Str() string {
+	switch m {
+	case ModerationApproved:
+		return "approved"
+	case ModerationRejected:
+		return "rejected"
+	case ModerationCanceled:
+		return "canceled"
+	}
+	return "pending"
+}
+
+// MarshalText json格式返回
+func (m ModerationStatus) MarshalText() ([]byte, error) {
+	return []byte(m.Str()), nil
+}
+
+// NewModerationStatus 根据字符串返回审核状态, 无法识别时返回false
+func NewModerationStatus(val string) (ModerationStatus, bool) {
+	for _, m := range []ModerationStatus{ModerationPending, ModerationApproved, ModerationRejected, ModerationCanceled} {
+		if m.Str() == val {
+			return m, true
+		}
+	}
+	return ModerationPending, false
+}
+
 // Query 查询
 type Query struct {
 	Page  int `form:"page"`
@@ -3,7 +3,7 @@ package context
 import (
 	"net/http"
 	"runtime"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/ihuanglei/authenticator/pkg/config"
@@ -21,6 +21,8 @@ type SessionUser struct {
 	UserStrID string    `json:"user_id"`
 	Nickname  string    `json:"nickname"`
 	Avatar    string    `json:"avatar"`
+	// Tenant 令牌所属租户, 为空时为默认租户
+	Tenant string `json:"tenant"`
 }
 
 // JSONResult .
@@ -36,6 +38,7 @@ type Context struct {
 	*SessionUser
 	StartTime time.Time
 	IP        string
+	Tenant    string
 	Secret    string
 	Expire    int64
 }
@@ -50,6 +53,20 @@ func (c *Context) QueryID(name string) common.ID {
 	return common.Int64ToID(c.QueryInt64(name))
 }
 
+// FormValues 表单参数, 同名参数取第一个
+func (c *Context) FormValues() map[string]string {
+	values := map[string]string{}
+	if err := c.Req.ParseForm(); err != nil {
+		return values
+	}
+	for key, value := range c.Req.Form {
+		if len(value) > 0 {
+			values[key] = value[0]
+		}
+	}
+	return values
+}
+
 // NotFound .
 func (c *Context) NotFound() {
 	c.Context.JSON(http.StatusNotFound, &JSONResult{Code: http.StatusNotFound, Msg: "Not Found"})
@@ -130,11 +147,16 @@ func (c *Context) Redirect(url string) {
 
 // Contexter init
 func Contexter() macaron.Handler {
+	var once sync.Once
+	var proxies TrustedProxies
 	return func(config *config.Config, ctx *macaron.Context) {
+		once.Do(func() {
+			proxies = NewTrustedProxies(config.Server.TrustedProxies)
+		})
 		c := &Context{
 			Context:   ctx,
 			StartTime: time.Now(),
-			IP:        ip(ctx.Req.Request),
+			IP:        proxies.ClientIP(ctx.Req.Request),
 			Secret:    config.Server.JWTSecret,
 			Expire:    config.Server.Expire,
 		}
@@ -145,20 +167,3 @@ func Contexter() macaron.Handler {
 		ctx.Map(c)
 	}
 }
-
-func ip(r *http.Request) string {
-	ip := r.Header.Get("X-Forwarded-For")
-	if strings.Contains(ip, "127.0.0.1") || ip == "" {
-		ip = r.Header.Get("X-real-ip")
-	}
-	if ip == "" {
-		ip = r.RemoteAddr
-		if strings.Contains(ip, ":") {
-			ip = strings.Split(ip, ":")[0]
-		}
-	}
-	if ip == "" {
-		ip = "127.0.0.1"
-	}
-	return ip
-}
@@ -0,0 +1,141 @@
+package context
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ihuanglei/authenticator/pkg/logger"
+)
+
+// TrustedProxies 可信代理
+type TrustedProxies []*net.IPNet
+
+// NewTrustedProxies 解析可信代理配置, 支持CIDR和单个IP
+func NewTrustedProxies(values []string) TrustedProxies {
+	var proxies TrustedProxies
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				logger.Warnf("[WEB] invalid trusted proxy %s", value)
+				continue
+			}
+			if ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			logger.Warnf("[WEB] invalid trusted proxy %s", value)
+			continue
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies
+}
+
+// Contains 是否为可信代理
+func (proxies TrustedProxies) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range proxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP 获取客户端IP
+// 只有直接连接方是可信代理时才读取转发头, 转发链从右往左解析, 第一个不可信的地址即为客户端地址
+func (proxies TrustedProxies) ClientIP(r *http.Request) string {
+	remote := parseIP(r.RemoteAddr)
+	if remote == nil {
+		return "127.0.0.1"
+	}
+	if !proxies.Contains(remote) {
+		return remote.String()
+	}
+
+	chain := forwardedFor(r.Header.Values("Forwarded"))
+	if len(chain) == 0 {
+		chain = xForwardedFor(r.Header.Values("X-Forwarded-For"))
+	}
+	if len(chain) == 0 {
+		if ip := parseIP(r.Header.Get("X-Real-IP")); ip != nil {
+			return ip.String()
+		}
+		return remote.String()
+	}
+
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseIP(chain[i])
+		// 无法识别的地址(unknown或混淆标识), 无法继续向前追溯
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !proxies.Contains(ip) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// X-Forwarded-For: client, proxy1, proxy2
+func xForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, node := range strings.Split(value, ",") {
+			chain = append(chain, strings.TrimSpace(node))
+		}
+	}
+	return chain
+}
+
+// RFC 7239 Forwarded: for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=http
+func forwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			node := ""
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					node = strings.Trim(kv[1], `"`)
+				}
+			}
+			chain = append(chain, node)
+		}
+	}
+	return chain
+}
+
+// 解析地址, 兼容 ip / ip:port / [ipv6] / [ipv6]:port
+func parseIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	// 去掉IPv6 zone
+	if i := strings.LastIndex(value, "%"); i > 0 {
+		value = value[:i]
+	}
+	return net.ParseIP(value)
+}
@@ -0,0 +1,64 @@
+package context
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newRequest(remote string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remote
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := NewTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1", "::1"})
+
+	Convey("untrusted remote ignores forwarded headers", t, func() {
+		r := newRequest("203.0.113.9:5000", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"})
+		So(proxies.ClientIP(r), ShouldEqual, "203.0.113.9")
+	})
+
+	Convey("ipv6 remote address", t, func() {
+		r := newRequest("[2001:db8::1]:443", nil)
+		So(proxies.ClientIP(r), ShouldEqual, "2001:db8::1")
+	})
+
+	Convey("x-forwarded-for parsed right to left", t, func() {
+		r := newRequest("127.0.0.1:5000", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.7, 10.1.1.1"})
+		So(proxies.ClientIP(r), ShouldEqual, "198.51.100.7")
+	})
+
+	Convey("all hops trusted returns leftmost", t, func() {
+		r := newRequest("[::1]:5000", map[string]string{"X-Forwarded-For": "10.2.2.2, 10.1.1.1"})
+		So(proxies.ClientIP(r), ShouldEqual, "10.2.2.2")
+	})
+
+	Convey("forwarded header takes precedence", t, func() {
+		r := newRequest("10.0.0.1:80", map[string]string{
+			"Forwarded":       `for="[2001:db8:cafe::17]:4711";proto=https, for=10.3.3.3`,
+			"X-Forwarded-For": "6.6.6.6",
+		})
+		So(proxies.ClientIP(r), ShouldEqual, "2001:db8:cafe::17")
+	})
+
+	Convey("obfuscated forwarded node stops the walk", t, func() {
+		r := newRequest("10.0.0.1:80", map[string]string{"Forwarded": "for=_hidden, for=10.3.3.3"})
+		So(proxies.ClientIP(r), ShouldEqual, "10.3.3.3")
+	})
+
+	Convey("x-real-ip from trusted proxy", t, func() {
+		r := newRequest("10.0.0.1:80", map[string]string{"X-Real-IP": "198.51.100.8"})
+		So(proxies.ClientIP(r), ShouldEqual, "198.51.100.8")
+	})
+
+	Convey("no trusted proxies", t, func() {
+		r := newRequest("10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6"})
+		So(NewTrustedProxies(nil).ClientIP(r), ShouldEqual, "10.0.0.1")
+	})
+}
@@ -0,0 +1,81 @@
+package context
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+
+	"gopkg.in/macaron.v1"
+)
+
+// tenantPathPrefix 路径中识别租户的前缀, /t/{code}/v1/...
+const tenantPathPrefix = "/t/"
+
+// TenantFinder 根据租户代码或域名查找有效租户, 返回租户代码和令牌签名密钥
+type TenantFinder func(key string) (code, secret string, ok bool)
+
+// TenantResolver 租户识别
+type TenantResolver struct {
+	Header string
+	ByCode TenantFinder
+	ByHost TenantFinder
+}
+
+// Resolve 依次使用请求头、路径前缀、域名识别租户, 都未识别时为默认租户
+// 请求头或路径中指定的租户不存在时返回错误, 路径前缀识别后返回去掉前缀的路径
+func (t *TenantResolver) Resolve(r *http.Request) (code, secret, path string, err error) {
+	path = r.URL.Path
+	code = strings.TrimSpace(r.Header.Get(t.Header))
+	if code == "" && strings.HasPrefix(path, tenantPathPrefix) {
+		rest := path[len(tenantPathPrefix):]
+		if i := strings.Index(rest, "/"); i > 0 {
+			code, path = rest[:i], rest[i:]
+		}
+	}
+	if code == consts.DefaultTenant {
+		return code, "", path, nil
+	}
+	if code != "" {
+		code, secret, ok := t.ByCode(code)
+		if !ok {
+			return "", "", path, errors.ErrTenantNotFound
+		}
+		return code, secret, path, nil
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if code, secret, ok := t.ByHost(strings.ToLower(host)); ok {
+		return code, secret, path, nil
+	}
+	return consts.DefaultTenant, "", path, nil
+}
+
+// Tenanter 识别当前请求的租户, 租户配置了密钥时使用租户密钥签发和校验令牌
+func Tenanter(byCode, byHost TenantFinder) macaron.Handler {
+	return func(config *config.Config, ctx *Context) {
+		ctx.Tenant = consts.DefaultTenant
+		if !config.Tenant.Enable {
+			return
+		}
+		resolver := TenantResolver{Header: config.Tenant.Header, ByCode: byCode, ByHost: byHost}
+		if resolver.Header == "" {
+			resolver.Header = consts.HeaderTenantKey
+		}
+		code, secret, path, err := resolver.Resolve(ctx.Req.Request)
+		if err != nil {
+			ctx.BadRequestByError(err)
+			return
+		}
+		ctx.Req.URL.Path = path
+		ctx.Tenant = code
+		if secret != "" {
+			ctx.Secret = secret
+		}
+	}
+}
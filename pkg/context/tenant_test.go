@@ -0,0 +1,66 @@
+package context
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTenantResolve(t *testing.T) {
+	tenants := map[string]string{"brand-a": "secret-a", "brand-b": ""}
+	hosts := map[string]string{"a.example.com": "brand-a"}
+	resolver := &TenantResolver{
+		Header: "X-Tenant",
+		ByCode: func(key string) (string, string, bool) {
+			secret, ok := tenants[key]
+			return key, secret, ok
+		},
+		ByHost: func(key string) (string, string, bool) {
+			code, ok := hosts[key]
+			return code, tenants[code], ok
+		},
+	}
+	request := func(host, path string, headers map[string]string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://"+host+path, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	Convey("header takes precedence", t, func() {
+		code, secret, path, err := resolver.Resolve(request("a.example.com", "/t/brand-b/v1/api/login", map[string]string{"X-Tenant": "brand-b"}))
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, "brand-b")
+		So(secret, ShouldEqual, "")
+		So(path, ShouldEqual, "/t/brand-b/v1/api/login")
+	})
+
+	Convey("path prefix is stripped", t, func() {
+		code, secret, path, err := resolver.Resolve(request("localhost", "/t/brand-a/v1/api/login", nil))
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, "brand-a")
+		So(secret, ShouldEqual, "secret-a")
+		So(path, ShouldEqual, "/v1/api/login")
+	})
+
+	Convey("host with port", t, func() {
+		code, _, path, err := resolver.Resolve(request("A.example.com:8080", "/v1/api/login", nil))
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, "brand-a")
+		So(path, ShouldEqual, "/v1/api/login")
+	})
+
+	Convey("unknown tenant", t, func() {
+		_, _, _, err := resolver.Resolve(request("localhost", "/t/brand-x/v1/api/login", nil))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("fallback to default tenant", t, func() {
+		code, secret, _, err := resolver.Resolve(request("localhost", "/v1/api/login", nil))
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, "default")
+		So(secret, ShouldEqual, "")
+	})
+}
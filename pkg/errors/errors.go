@@ -41,6 +41,16 @@ var (
 	ErrUserAlreadyBind     = Error{10111, "用户已经绑定"}
 	ErrAddressNotFound     = Error{10112, "地址不存在"}
 	ErrDictNotFound        = Error{10113, "字典中数据不存在"}
+	ErrUserDeleting        = Error{10114, "用户已申请注销"}
+	ErrExportNotFound      = Error{10115, "导出文件不存在或已过期"}
+	ErrExportPending       = Error{10116, "导出文件正在生成"}
+	ErrThirdAlreadyBind    = Error{10117, "已绑定该类型的第三方账号"}
+	ErrThirdNotBind        = Error{10118, "未绑定该第三方账号"}
+	ErrLastLoginMethod     = Error{10119, "不能移除唯一的登录方式"}
+	ErrAddressLimit        = Error{10120, "地址数量已达上限"}
+	ErrImportLimit         = Error{10121, "导入数量超出上限"}
+	ErrImportFormat        = Error{10122, "导入文件格式错误"}
+	ErrMergeSelf           = Error{10123, "不能合并同一个账号"}
 
 	ErrArgument        = Error{10400, "参数错误"}
 	ErrPassword        = Error{10401, "密码长度必须为6-20位"}
@@ -55,6 +65,14 @@ var (
 	ErrThirdCode       = Error{10410, "无效的第三方认证令牌"}
 	ErrAvatar          = Error{10411, "头像地址不能为空"}
 	ErrNickname        = Error{10412, "昵称长度必须为1-15个字"}
+	ErrAddressName     = Error{10413, "收货人姓名长度必须为1-30个字"}
+	ErrAddressDetail   = Error{10414, "详细地址不能为空且不超过255个字"}
+	ErrZip             = Error{10415, "邮编格式错误"}
+	ErrGender          = Error{10416, "性别错误"}
+	ErrQQ              = Error{10417, "QQ号格式错误"}
+	ErrWeiXin          = Error{10418, "微信号格式错误"}
+	ErrRegion          = Error{10419, "省市区长度不能超过30个字"}
+	ErrPasswordHash    = Error{10420, "密码摘要格式错误"}
 
 	ErrWeiXinMPCode        = Error{10501, "微信小程序临时登录凭证错误"}
 	ErrWeiXinMPKey         = Error{10502, "调用微信小程序登录返回的key不存在或错误"}
@@ -63,4 +81,77 @@ var (
 
 	ErrRoleNotFound = Error{10600, "角色不存在"}
 	ErrRoleExist    = Error{10601, "角色已存在"}
+	ErrRoleCycle    = Error{10602, "不能继承自身或下级角色"}
+	ErrRoleDepth    = Error{10603, "角色继承层数过多"}
+	ErrRoleDomain   = Error{10604, "角色和资源必须属于同一个域"}
+	ErrDomain       = Error{10605, "域必须以小写字母开头, 只能包含小写字母、数字和-, 长度为2-32"}
+	ErrCondition    = Error{10606, "条件格式错误"}
+	ErrCondResource = Error{10607, "角色没有该资源"}
+	ErrSuperAdmin   = Error{10608, "只有超级管理员可以授予或取消超级管理员及继承它的角色"}
+
+	ErrAttributeNotFound = Error{10700, "自定义属性不存在"}
+	ErrAttributeExist    = Error{10701, "自定义属性已存在"}
+	ErrAttributeKey      = Error{10702, "属性键必须为2-30位小写字母、数字、下划线组合，以字母开头"}
+	ErrAttributeType     = Error{10703, "属性类型或可见范围错误"}
+	ErrAttributeValue    = Error{10704, "属性值格式错误"}
+	ErrAttributeReadOnly = Error{10705, "属性不允许修改"}
+	ErrAttributeClaim    = Error{10706, "仅管理员可见的属性不能放入令牌"}
+
+	ErrOrgNotFound       = Error{10800, "组织不存在"}
+	ErrOrgName           = Error{10801, "组织名称长度必须为1-30个字"}
+	ErrOrgNotMember      = Error{10802, "不是组织成员"}
+	ErrOrgMemberExist    = Error{10803, "已是组织成员"}
+	ErrOrgRole           = Error{10804, "组织角色错误"}
+	ErrOrgOwner          = Error{10805, "组织所有者不能退出或被移除, 请先转让组织"}
+	ErrOrgMemberRole     = Error{10806, "无权操作该成员"}
+	ErrOrgInviteNotFound = Error{10807, "邀请不存在或已过期"}
+
+	ErrTenantNotFound = Error{10900, "租户不存在"}
+	ErrTenantCode     = Error{10901, "租户代码必须为2-32位小写字母、数字、中划线组合，以字母开头"}
+	ErrTenantExist    = Error{10902, "租户代码已存在"}
+	ErrTenantName     = Error{10903, "租户名称长度必须为1-30个字"}
+	ErrTenantHost     = Error{10904, "域名已被其他租户使用"}
+	ErrTenantMismatch = Error{10905, "令牌不属于当前租户"}
+
+	ErrInviteRequired = Error{11000, "需要邀请码才能注册"}
+	ErrInviteNotFound = Error{11001, "邀请码无效、已过期或已用完"}
+	ErrInviteLimit    = Error{11002, "邀请码数量或使用次数错误"}
+
+	ErrRegisterClosed = Error{11100, "暂不开放注册"}
+	ErrRegisterMode   = Error{11101, "不支持该注册方式"}
+	ErrEmailDomain    = Error{11102, "不支持该邮箱域名"}
+
+	ErrLegalNotFound   = Error{11200, "协议不存在"}
+	ErrLegalType       = Error{11201, "协议类型错误"}
+	ErrLegalVersion    = Error{11202, "协议版本已存在"}
+	ErrLegalPublished  = Error{11203, "协议已发布, 不能修改或删除"}
+	ErrLegalMustAccept = Error{11204, "协议已更新, 请阅读并同意后继续"}
+
+	ErrAvatarSize  = Error{11300, "头像文件过大"}
+	ErrAvatarType  = Error{11301, "头像只支持jpg、png、gif格式"}
+	ErrAvatarImage = Error{11302, "无法识别的头像图片"}
+
+	ErrNameReserved         = Error{11400, "该用户名不可使用"}
+	ErrNameSensitive        = Error{11401, "用户名包含敏感词"}
+	ErrNicknameReserved     = Error{11402, "该昵称不可使用"}
+	ErrNicknameSensitive    = Error{11403, "昵称包含敏感词"}
+	ErrAddressNameReserved  = Error{11404, "该收货人姓名不可使用"}
+	ErrAddressNameSensitive = Error{11405, "收货人姓名包含敏感词"}
+	ErrWord                 = Error{11406, "词长度必须为1-50个字, 类型和适用字段必须正确"}
+	ErrWordNotFound         = Error{11407, "词不存在"}
+
+	ErrModerationNotFound = Error{11500, "审核记录不存在"}
+	ErrModerationReviewed = Error{11501, "该修改已审核或已撤回"}
+	ErrModerationRejected = Error{11502, "内容未通过审核"}
+	ErrModerationStatus   = Error{11503, "审核状态错误"}
+
+	ErrResourceNotFound = Error{11600, "资源不存在"}
+	ErrResourceExist    = Error{11601, "相同地址和方法的资源已存在"}
+	ErrResource         = Error{11602, "资源名称长度必须为1-30个字, 地址以/开头, 方法必须为get、post、put、patch、delete"}
+	ErrResourceRoute    = Error{11603, "从路由同步的资源不能修改, 只能删除已不存在的路由"}
+	ErrResourceInUse    = Error{11604, "资源已分配给角色, 不能修改地址或删除"}
+
+	ErrServiceAuth  = Error{11700, "服务凭证错误"}
+	ErrAuthzSubject = Error{11701, "用户编号或令牌必须填写一个, 且用户必须属于当前租户"}
+	ErrAuthzBatch   = Error{11702, "检查项数量必须为1-100, 地址和方法必须一一对应"}
 )
@@ -0,0 +1,253 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/ihuanglei/authenticator/pkg/errors"
+	"github.com/ihuanglei/authenticator/pkg/job"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/simplexwork/cache"
+	"github.com/simplexwork/common"
+)
+
+const (
+	// 导出任务
+	taskKey = "__export_task_%v"
+	// 用户当前导出任务
+	taskUserKey = "__export_user_%v"
+
+	// 默认导出目录
+	defaultDir = "data/export"
+	// 默认下载有效期(小时)
+	defaultExpire = 24
+)
+
+// Status 导出状态
+type Status string
+
+const (
+	// Pending 处理中
+	Pending Status = "pending"
+	// Done 完成
+	Done Status = "done"
+	// Failed 失败
+	Failed Status = "failed"
+)
+
+// Task 导出任务
+type Task struct {
+	Token      string          `json:"token"`
+	UserID     common.ID       `json:"-"`
+	Status     Status          `json:"status"`
+	URL        string          `json:"url"`
+	CreateTime common.DateTime `json:"create_time"`
+	ExpireTime common.DateTime `json:"expire_time"`
+}
+
+// Exporter 用户数据导出
+type Exporter struct {
+	dir      string
+	expire   time.Duration
+	cache    cache.Cache
+	enforcer *casbin.Enforcer
+}
+
+// NewExporter .
+func NewExporter(config *config.Config, cache cache.Cache, enforcer *casbin.Enforcer) *Exporter {
+	dir := config.Export.Dir
+	if common.IsEmpty(dir) {
+		dir = defaultDir
+	}
+	expire := config.Export.Expire
+	if expire <= 0 {
+		expire = defaultExpire
+	}
+	return &Exporter{
+		dir:      dir,
+		expire:   time.Hour * time.Duration(expire),
+		cache:    cache,
+		enforcer: enforcer,
+	}
+}
+
+// Start 创建导出任务, 同一用户在下载有效期内只保留一个任务
+func (e *Exporter) Start(userID common.ID) (*Task, error) {
+	if _, err := models.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+	if token, err := e.cache.GetString(fmt.Sprintf(taskUserKey, userID)); err == nil {
+		if task, err := e.Get(token); err == nil && task.Status != Failed {
+			return task, nil
+		}
+	}
+	now := time.Now()
+	token := common.RandomString(32)
+	task := (&cachedTask{
+		Token:      token,
+		UserID:     userID.Int64(),
+		Status:     Pending,
+		CreateTime: now.Unix(),
+		ExpireTime: now.Add(e.expire).Unix(),
+	}).task()
+	if err := e.save(task); err != nil {
+		return nil, err
+	}
+	if err := e.cache.Set(fmt.Sprintf(taskUserKey, userID), token, e.expire); err != nil {
+		return nil, err
+	}
+	job.Go(fmt.Sprintf("export user %v", userID), func() error {
+		err := e.build(task)
+		if err != nil {
+			task.Status = Failed
+		} else {
+			task.Status = Done
+		}
+		if saveErr := e.save(task); saveErr != nil {
+			return saveErr
+		}
+		return err
+	})
+	return task, nil
+}
+
+// Get 获取导出任务
+func (e *Exporter) Get(token string) (*Task, error) {
+	if common.IsEmpty(token) {
+		return nil, errors.ErrExportNotFound
+	}
+	data, err := e.cache.Get(fmt.Sprintf(taskKey, token))
+	if err != nil {
+		return nil, errors.ErrExportNotFound
+	}
+	var cached cachedTask
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	return cached.task(), nil
+}
+
+// File 导出文件路径
+func (e *Exporter) File(task *Task) string {
+	return filepath.Join(e.dir, fmt.Sprintf("%s.zip", task.Token))
+}
+
+// Clean 清除过期的导出文件
+func (e *Exporter) Clean() error {
+	files, err := ioutil.ReadDir(e.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() || time.Since(file.ModTime()) < e.expire {
+			continue
+		}
+		if err := os.Remove(filepath.Join(e.dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 缓存中的任务
+type cachedTask struct {
+	Token      string `json:"token"`
+	UserID     int64  `json:"user_id"`
+	Status     Status `json:"status"`
+	CreateTime int64  `json:"create_time"`
+	ExpireTime int64  `json:"expire_time"`
+}
+
+func (c *cachedTask) task() *Task {
+	return &Task{
+		Token:      c.Token,
+		UserID:     common.Int64ToID(c.UserID),
+		Status:     c.Status,
+		URL:        fmt.Sprintf("/v1/api/export/%s", c.Token),
+		CreateTime: common.DateTime(time.Unix(c.CreateTime, 0)),
+		ExpireTime: common.DateTime(time.Unix(c.ExpireTime, 0)),
+	}
+}
+
+func (e *Exporter) save(task *Task) error {
+	cached := cachedTask{
+		Token:      task.Token,
+		UserID:     task.UserID.Int64(),
+		Status:     task.Status,
+		CreateTime: time.Time(task.CreateTime).Unix(),
+		ExpireTime: time.Time(task.ExpireTime).Unix(),
+	}
+	return e.cache.Set(fmt.Sprintf(taskKey, task.Token), cached, time.Until(time.Time(task.ExpireTime)))
+}
+
+func (e *Exporter) build(task *Task) error {
+	exportDto, err := models.GetUserExport(task.UserID)
+	if err != nil {
+		return err
+	}
+	if e.enforcer != nil {
+		roles, err := e.enforcer.GetRolesForUser(task.UserID.Str())
+		if err != nil {
+			return err
+		}
+		exportDto.Roles = roles
+	}
+	if err := os.MkdirAll(e.dir, 0700); err != nil {
+		return err
+	}
+	file := e.File(task)
+	tmp := file + ".tmp"
+	if err := writeArchive(tmp, exportDto); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// 每部分数据单独一个json文件, all.json 为完整数据
+func writeArchive(file string, exportDto *st.UserExportDto) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	entries := []struct {
+		name string
+		data interface{}
+	}{
+		{"all.json", exportDto},
+		{"user.json", exportDto.User},
+		{"user_info.json", exportDto.UserInfo},
+		{"thirds.json", exportDto.Thirds},
+		{"addresses.json", exportDto.Addresses},
+		{"logins.json", exportDto.Logins},
+		{"roles.json", exportDto.Roles},
+	}
+	for _, entry := range entries {
+		zf, err := w.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(zf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entry.data); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
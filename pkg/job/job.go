@@ -0,0 +1,41 @@
+package job
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/logger"
+)
+
+// Every 定时执行任务, 启动时立即执行一次
+func Every(name string, interval time.Duration, fn func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			run(name, fn)
+			<-ticker.C
+		}
+	}()
+}
+
+// Go 异步执行一次任务
+func Go(name string, fn func() error) {
+	go run(name, fn)
+}
+
+func run(name string, fn func() error) {
+	defer func() {
+		if err := recover(); err != nil {
+			var buf [1024]byte
+			n := runtime.Stack(buf[:], false)
+			logger.Errorf("[JOB] %s panic: %v %s", name, err, string(buf[:n]))
+		}
+	}()
+	start := time.Now()
+	if err := fn(); err != nil {
+		logger.Errorf("[JOB] %s error: %v", name, err)
+		return
+	}
+	logger.Debugf("[JOB] %s completed in %v", name, time.Since(start))
+}
@@ -20,6 +20,7 @@ type UserLoginDto struct {
 // UserDto 用户
 type UserDto struct {
 	UserID     common.ID        `json:"user_id"`
+	Tenant     string           `json:"tenant"`
 	Name       string           `json:"name"`
 	Email      string           `json:"email"`
 	Mobile     string           `json:"mobile"`
@@ -27,6 +28,7 @@ type UserDto struct {
 	Error      int              `json:"error"`
 	Forbidden  consts.Forbidden `json:"forbidden"`
 	Activate   consts.Activate  `json:"activate"`
+	DeleteTime common.DateTime  `json:"delete_time"`
 	CreateTime common.DateTime  `json:"create_time"`
 }
 
@@ -43,14 +45,35 @@ type UserInfoDto struct {
 	County   string        `json:"county"`
 }
 
+// UserThirdDto 第三方绑定
+type UserThirdDto struct {
+	Type       string          `json:"type"`
+	OpenID     string          `json:"open_id"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// UserExportDto 用户数据导出
+type UserExportDto struct {
+	User      *UserDto        `json:"user"`
+	UserInfo  *UserInfoDto    `json:"user_info"`
+	RegIP     string          `json:"reg_ip"`
+	Thirds    []*UserThirdDto `json:"thirds"`
+	Addresses []*AddressDto   `json:"addresses"`
+	Logins    []*UserLoginDto `json:"logins"`
+	Roles     []string        `json:"roles"`
+}
+
 // RegisterDto 注册信息
 type RegisterDto struct {
-	Email     string
-	Mobile    string `json:"mobile"`
-	LoginName string
-	Password  string
-	Nickname  string `json:"nickname"`
-	IP        string
+	Tenant     string
+	InviteCode string
+	LegalIDs   []common.ID
+	Email      string
+	Mobile     string `json:"mobile"`
+	LoginName  string
+	Password   string
+	Nickname   string `json:"nickname"`
+	IP         string
 	// 三方注册的数据
 	TP       string `json:"tp"`
 	OpenID   string `json:"open_id"`
@@ -60,6 +83,205 @@ type RegisterDto struct {
 	City     string `json:"city"`
 }
 
+// UserExportRowDto 管理员导出用户, 每个用户一行
+type UserExportRowDto struct {
+	*UserDto
+	Mode          consts.Mode     `json:"mode"`
+	Nickname      string          `json:"nickname"`
+	Avatar        string          `json:"avatar"`
+	Gender        consts.Gender   `json:"gender"`
+	QQ            string          `json:"qq"`
+	WeiXin        string          `json:"weixin"`
+	Province      string          `json:"province"`
+	City          string          `json:"city"`
+	County        string          `json:"county"`
+	RegIP         string          `json:"reg_ip"`
+	LastLoginIP   string          `json:"last_login_ip"`
+	LastLoginTime common.DateTime `json:"last_login_time"`
+	Roles         []string        `json:"roles"`
+}
+
+// UserMergeDto 账号合并记录
+type UserMergeDto struct {
+	MergeID    common.ID       `json:"merge_id"`
+	SourceID   common.ID       `json:"source_id"`
+	TargetID   common.ID       `json:"target_id"`
+	OperatorID common.ID       `json:"operator_id"`
+	Fields     []string        `json:"fields"`
+	Thirds     []string        `json:"thirds"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// OrgDto 组织
+type OrgDto struct {
+	OrgID       common.ID       `json:"org_id"`
+	Tenant      string          `json:"tenant"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	OwnerID     common.ID       `json:"owner_id"`
+	CreateTime  common.DateTime `json:"create_time"`
+	// 当前用户在组织中的角色
+	Role consts.OrgRole `json:"role,omitempty"`
+}
+
+// OrgMemberDto 组织成员
+type OrgMemberDto struct {
+	UserID     common.ID       `json:"user_id"`
+	Nickname   string          `json:"nickname"`
+	Avatar     string          `json:"avatar"`
+	Role       consts.OrgRole  `json:"role"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// OrgInviteDto 组织邀请
+type OrgInviteDto struct {
+	InviteID   common.ID       `json:"invite_id"`
+	OrgID      common.ID       `json:"org_id"`
+	Code       string          `json:"code"`
+	Role       consts.OrgRole  `json:"role"`
+	InviterID  common.ID       `json:"inviter_id"`
+	ExpireTime common.DateTime `json:"expire_time"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// ImportUserDto 导入用户
+type ImportUserDto struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Mobile   string `json:"mobile"`
+	Password string `json:"password"`
+	// 已加密的密码 md5(password + salt)
+	PasswordHash string `json:"password_hash"`
+	Salt         string `json:"salt"`
+	Nickname     string `json:"nickname"`
+}
+
+// ImportResultDto 导入结果, 行号从1开始
+type ImportResultDto struct {
+	Row     int       `json:"row"`
+	Success bool      `json:"success"`
+	UserID  common.ID `json:"user_id,omitempty"`
+	Code    int       `json:"code,omitempty"`
+	Msg     string    `json:"msg,omitempty"`
+}
+
+// TenantDto 租户
+type TenantDto struct {
+	TenantID   common.ID       `json:"tenant_id"`
+	Code       string          `json:"code"`
+	Name       string          `json:"name"`
+	Hosts      []string        `json:"hosts"`
+	Secret     string          `json:"-"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// InviteDto 注册邀请码
+type InviteDto struct {
+	InviteID   common.ID       `json:"invite_id"`
+	Code       string          `json:"code"`
+	InviterID  common.ID       `json:"inviter_id"`
+	RoleID     common.ID       `json:"role_id"`
+	MaxUses    int             `json:"max_uses"`
+	Used       int             `json:"used"`
+	Remark     string          `json:"remark"`
+	ExpireTime common.DateTime `json:"expire_time"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// ReferralDto 邀请注册记录
+type ReferralDto struct {
+	InviteID   common.ID       `json:"invite_id"`
+	InviterID  common.ID       `json:"inviter_id"`
+	UserID     common.ID       `json:"user_id"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// ReferralStatDto 邀请人邀请注册人数
+type ReferralStatDto struct {
+	InviterID common.ID `json:"inviter_id"`
+	Count     int64     `json:"count"`
+}
+
+// RegisterSettingDto 注册设置, 保存在字典 register/setting 中, 值为JSON
+type RegisterSettingDto struct {
+	// Closed 关闭所有注册方式
+	Closed bool `json:"closed"`
+	// Disabled 禁用的注册方式: name, email, mobile, third, weixinmp
+	Disabled []string `json:"disabled"`
+	// AllowDomains 允许注册的邮箱域名, 为空时不限制
+	AllowDomains []string `json:"allow_domains"`
+	// BlockDomains 禁止注册的邮箱域名
+	BlockDomains []string `json:"block_domains"`
+	// BlockDisposable 禁止使用内置的一次性邮箱域名
+	BlockDisposable bool `json:"block_disposable"`
+}
+
+// LegalDto 协议文档
+type LegalDto struct {
+	LegalID     common.ID       `json:"legal_id"`
+	TP          string          `json:"tp"`
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	Content     string          `json:"content,omitempty"`
+	PublishTime common.DateTime `json:"publish_time"`
+	CreateTime  common.DateTime `json:"create_time"`
+}
+
+// LegalAcceptDto 用户同意协议记录
+type LegalAcceptDto struct {
+	LegalID    common.ID       `json:"legal_id"`
+	TP         string          `json:"tp"`
+	Version    string          `json:"version"`
+	IP         string          `json:"ip"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// WordDto 保留词和敏感词
+type WordDto struct {
+	WordID     common.ID       `json:"word_id"`
+	Word       string          `json:"word"`
+	TP         string          `json:"tp"`
+	Fields     []string        `json:"fields"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
+// ModerationDto 昵称和头像修改审核
+type ModerationDto struct {
+	ModerationID common.ID               `json:"moderation_id"`
+	UserID       common.ID               `json:"user_id"`
+	Field        string                  `json:"field"`
+	Value        string                  `json:"value"`
+	OldValue     string                  `json:"old_value"`
+	Status       consts.ModerationStatus `json:"status"`
+	Reason       string                  `json:"reason"`
+	ReviewerID   common.ID               `json:"reviewer_id"`
+	ReviewTime   common.DateTime         `json:"review_time"`
+	CreateTime   common.DateTime         `json:"create_time"`
+}
+
+// RoleGrantDto 用户角色授予记录, 过期时间为空时永不过期
+type RoleGrantDto struct {
+	Domain     string          `json:"domain"`
+	UserID     common.ID       `json:"user_id"`
+	RoleID     string          `json:"role_id"`
+	GranterID  common.ID       `json:"granter_id"`
+	ExpireTime common.DateTime `json:"expire_time"`
+	CreateTime common.DateTime `json:"create_time"`
+	// 已过期, 等待定时任务移除
+	Expired bool `json:"expired"`
+}
+
+// RoleGrantLogDto 用户角色变更记录
+type RoleGrantLogDto struct {
+	Domain     string          `json:"domain"`
+	UserID     common.ID       `json:"user_id"`
+	RoleID     string          `json:"role_id"`
+	Action     string          `json:"action"`
+	ExpireTime common.DateTime `json:"expire_time"`
+	OperatorID common.ID       `json:"operator_id"`
+	CreateTime common.DateTime `json:"create_time"`
+}
+
 // AddressDto 地址
 type AddressDto struct {
 	AddressID  common.ID       `json:"address_id"`
@@ -77,6 +299,7 @@ type AddressDto struct {
 
 // LoginDto 登录
 type LoginDto struct {
+	Tenant    string
 	LoginName string
 	Password  string
 	Mobile    string
@@ -102,18 +325,60 @@ type DictDto struct {
 // ResourceDto 资源
 type ResourceDto struct {
 	ID string `json:"id"`
+	// 域
+	Domain string `json:"domain"`
 	// 名称
 	Name string `json:"name"`
 	// 资源
 	URL string `json:"url"`
 	// 方法
 	Method string `json:"method"`
+	// 来源 [route|custom]
+	Source string `json:"source"`
+	// 路由已不存在
+	Stale bool `json:"stale"`
+}
+
+// AuthzResultDto 权限检查结果
+type AuthzResultDto struct {
+	// 资源
+	Obj string `json:"obj"`
+	// 方法
+	Act string `json:"act"`
+	// 是否允许
+	Allow bool `json:"allow"`
+}
+
+// AttributeDto 自定义属性
+type AttributeDto struct {
+	// 编号
+	AttrID common.ID `json:"attr_id"`
+	// 属性键
+	Key string `json:"key"`
+	// 名称
+	Name string `json:"name"`
+	// 类型
+	Type consts.AttrType `json:"type"`
+	// 校验规则
+	Rule string `json:"rule"`
+	// 可见范围
+	Visibility consts.Visibility `json:"visibility"`
+	// 是否放入令牌
+	Claim bool `json:"claim"`
+}
+
+// UserDetailDto 用户详情及自定义属性
+type UserDetailDto struct {
+	*UserInfoDto
+	Attributes map[string]string `json:"attributes"`
 }
 
 // RoleDto 角色
 type RoleDto struct {
 	// 编号
 	RoleID common.ID `json:"role_id"`
+	// 域
+	Domain string `json:"domain"`
 	// 名称
 	Name string `json:"name"`
 }
@@ -127,3 +392,34 @@ type RoleResourceDto struct {
 	// 方法
 	Method string `json:"method"`
 }
+
+// PolicyConditionDto 角色资源的附加条件
+type PolicyConditionDto struct {
+	RoleID common.ID `json:"role_id"`
+	Domain string    `json:"domain"`
+	URL    string    `json:"url"`
+	Method string    `json:"method"`
+	// 每天允许的时间段, 例如 09:00-18:00, 结束早于开始时跨越零点
+	Hours string `json:"hours"`
+	// 允许的星期, 逗号分隔, 0为周日
+	Weekdays string `json:"weekdays"`
+	// 允许的IP或网段, 逗号分隔
+	IPRanges string `json:"ip_ranges"`
+	// 只允许访问自己的数据
+	Owner      bool            `json:"owner"`
+	UpdateTime common.DateTime `json:"update_time"`
+}
+
+// InheritedResourceDto 继承自上级角色的资源
+type InheritedResourceDto struct {
+	// 上级角色编号
+	RoleID common.ID `json:"role_id"`
+	// 上级角色名称
+	RoleName string `json:"role_name"`
+	// 名称
+	Name string `json:"name"`
+	// 资源
+	URL string `json:"url"`
+	// 方法
+	Method string `json:"method"`
+}
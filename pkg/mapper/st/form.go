@@ -47,25 +47,171 @@ type DictForm struct {
 	TP    string `form:"tp"`
 }
 
+// CreateUserForm 管理员创建用户表单
+type CreateUserForm struct {
+	FormError
+	Name     string `form:"name"`
+	Email    string `form:"email"`
+	Mobile   string `form:"mobile"`
+	Password string `form:"password"`
+	Nickname string `form:"nickname"`
+}
+
+// MergeUserForm 合并账号表单
+type MergeUserForm struct {
+	FormError
+	Token string `form:"token" binding:"Required"`
+}
+
+// OrgForm 组织表单
+type OrgForm struct {
+	FormError
+	Name        string `form:"name" binding:"Required"`
+	Description string `form:"description"`
+}
+
+// OrgMemberForm 组织成员表单
+type OrgMemberForm struct {
+	FormError
+	UserID string `form:"user_id"`
+	Role   string `form:"role"`
+}
+
+// OrgInviteForm 组织邀请表单
+type OrgInviteForm struct {
+	FormError
+	Role string `form:"role" binding:"Required"`
+}
+
+// AttributeForm 自定义属性表单
+type AttributeForm struct {
+	FormError
+	Key        string `form:"key"`
+	Name       string `form:"name" binding:"Required"`
+	Type       string `form:"type" binding:"Required"`
+	Rule       string `form:"rule"`
+	Visibility string `form:"visibility" binding:"Required"`
+	Claim      bool   `form:"claim"`
+}
+
+// TenantForm 租户表单
+type TenantForm struct {
+	FormError
+	Code   string `form:"code"`
+	Name   string `form:"name" binding:"Required"`
+	Hosts  string `form:"hosts"`
+	Secret string `form:"secret"`
+}
+
+// InviteForm 注册邀请码表单
+type InviteForm struct {
+	FormError
+	Count      int    `form:"count"`
+	MaxUses    int    `form:"max_uses"`
+	ExpireDays int    `form:"expire_days"`
+	RoleID     string `form:"role_id"`
+	InviterID  string `form:"inviter_id"`
+	Remark     string `form:"remark"`
+}
+
+// LegalForm 协议文档表单
+type LegalForm struct {
+	FormError
+	TP      string `form:"tp"`
+	Version string `form:"version" binding:"Required"`
+	Title   string `form:"title" binding:"Required"`
+	Content string `form:"content" binding:"Required"`
+}
+
+// LegalAcceptForm 同意协议表单
+type LegalAcceptForm struct {
+	FormError
+	LegalIDs string `form:"legal_ids" binding:"Required"`
+}
+
+// WordForm 保留词和敏感词表单, 新增时可换行分隔多个词
+type WordForm struct {
+	FormError
+	Word   string `form:"word" binding:"Required"`
+	TP     string `form:"tp" binding:"Required"`
+	Fields string `form:"fields" binding:"Required"`
+}
+
+// ResourceForm 自定义资源表单, 域只在创建时使用
+type ResourceForm struct {
+	FormError
+	Domain string `form:"domain"`
+	Name   string `form:"name" binding:"Required"`
+	URL    string `form:"url" binding:"Required"`
+	Method string `form:"method" binding:"Required"`
+}
+
+// PolicyConditionForm 角色资源条件表单, 条件都为空时移除
+type PolicyConditionForm struct {
+	FormError
+	URL      string `form:"url" binding:"Required"`
+	Method   string `form:"method" binding:"Required"`
+	Hours    string `form:"hours"`
+	Weekdays string `form:"weekdays"`
+	IPRanges string `form:"ip_ranges"`
+	Owner    bool   `form:"owner"`
+}
+
+// AuthzCheckForm 权限检查表单, 用户编号和令牌填写一个
+type AuthzCheckForm struct {
+	FormError
+	Domain  string `form:"domain"`
+	UserID  string `form:"user_id"`
+	Token   string `form:"token"`
+	Obj     string `form:"obj" binding:"Required"`
+	Act     string `form:"act" binding:"Required"`
+	IP      string `form:"ip"`
+	OwnerID string `form:"owner_id"`
+}
+
+// AuthzBatchForm 批量权限检查表单, obj和act按顺序一一对应
+type AuthzBatchForm struct {
+	FormError
+	Domain  string   `form:"domain"`
+	UserID  string   `form:"user_id"`
+	Token   string   `form:"token"`
+	Obj     []string `form:"obj"`
+	Act     []string `form:"act"`
+	IP      string   `form:"ip"`
+	OwnerID string   `form:"owner_id"`
+}
+
+// ModerationRejectForm 审核拒绝表单
+type ModerationRejectForm struct {
+	FormError
+	Reason string `form:"reason"`
+}
+
 // RegisterNameForm 用户名注册表单
 type RegisterNameForm struct {
 	FormError
-	LoginName string `form:"login_name" binding:"Required"`
-	Password  string `form:"password" binding:"Required;Password"`
+	LoginName  string `form:"login_name" binding:"Required"`
+	Password   string `form:"password" binding:"Required;Password"`
+	InviteCode string `form:"invite_code"`
+	LegalIDs   string `form:"legal_ids"`
 }
 
 // RegisterWithThirdForm 三方登录表单
 type RegisterWithThirdForm struct {
 	FormError
-	Code string `form:"code" binding:"Required"`
+	Code       string `form:"code" binding:"Required"`
+	InviteCode string `form:"invite_code"`
+	LegalIDs   string `form:"legal_ids"`
 }
 
-// WeiXinMPForm 微信小程序表单
+// WeiXinMPForm 微信小程序表单, 注册时可填写邀请码
 type WeiXinMPForm struct {
 	FormError
 	WeiXinMPKey         string `form:"key" binding:"Required"`
 	WeiXinEncryptedData string `form:"encrypted_data" binding:"Required"`
 	WeiXinIV            string `form:"iv" binding:"Required"`
+	InviteCode          string `form:"invite_code"`
+	LegalIDs            string `form:"legal_ids"`
 }
 
 // LoginForm 用户名邮箱手机号和密码登录表单
@@ -98,6 +244,18 @@ type LoginWithWeiXinMPCodeForm struct {
 	OnlySession  bool `form:"only_session"`
 }
 
+// BindThirdForm 绑定第三方表单
+type BindThirdForm struct {
+	FormError
+	Code string `form:"code" binding:"Required"`
+}
+
+// BindWeiXinMPForm 绑定微信小程序表单
+type BindWeiXinMPForm struct {
+	FormError
+	WeiXinMPCode string `form:"code" binding:"Required"`
+}
+
 // UpdatePasswordWithOldPasswordForm 验证码修改密码表单
 type UpdatePasswordWithOldPasswordForm struct {
 	FormError
@@ -112,6 +270,39 @@ type UpdatePasswordWithCodeForm struct {
 	Code     string `form:"code" binding:"Required;Size(6)"`
 }
 
+// DeleteUserForm 注销账号表单, 密码和验证码任选其一
+type DeleteUserForm struct {
+	FormError
+	Password string `form:"password"`
+	Code     string `form:"code"`
+}
+
+// UpdateProfileForm 修改用户信息表单, 只更新提交的字段
+type UpdateProfileForm struct {
+	FormError
+	Nickname string `form:"nickname"`
+	Avatar   string `form:"avatar"`
+	Gender   string `form:"gender"`
+	QQ       string `form:"qq"`
+	WeiXin   string `form:"weixin"`
+	Province string `form:"province"`
+	City     string `form:"city"`
+	County   string `form:"county"`
+}
+
+// AddressForm 地址表单
+type AddressForm struct {
+	FormError
+	Name     string `form:"name"`
+	Mobile   string `form:"mobile" binding:"Required;Mobile"`
+	Province string `form:"province"`
+	City     string `form:"city"`
+	County   string `form:"county"`
+	Address  string `form:"address"`
+	Zip      string `form:"zip"`
+	Def      bool   `form:"def"`
+}
+
 // ************ 手机号相关表单
 
 // MobileForm 手机号表单
@@ -123,8 +314,10 @@ type MobileForm struct {
 // RegisterMobileForm 手机注册表单
 type RegisterMobileForm struct {
 	MobileForm
-	Password string `form:"password" binding:"Required;Password"`
-	Code     string `form:"code" binding:"Required;Size(6)"`
+	Password   string `form:"password" binding:"Required;Password"`
+	Code       string `form:"code" binding:"Required;Size(6)"`
+	InviteCode string `form:"invite_code"`
+	LegalIDs   string `form:"legal_ids"`
 }
 
 // LoginWithMobileAndCodeForm 手机号验证码登录表单
@@ -156,7 +349,9 @@ type EmailForm struct {
 // RegisterEmailForm 邮箱注册表单
 type RegisterEmailForm struct {
 	EmailForm
-	Password string `form:"password" binding:"Required;Password"`
+	Password   string `form:"password" binding:"Required;Password"`
+	InviteCode string `form:"invite_code"`
+	LegalIDs   string `form:"legal_ids"`
 }
 
 // ResetPasswordWithEmailCodeForm 忘记密码邮件验证码修改密码表单
@@ -7,6 +7,7 @@ import (
 // UserQuery 用户搜索
 type UserQuery struct {
 	consts.Query
+	Tenant     string   `form:"-"`
 	Keyword    string   `form:"keyword"`
 	Name       string   `form:"name"`
 	Email      string   `form:"email"`
@@ -19,16 +20,59 @@ type UserQuery struct {
 // ResourceQuery 资源搜索
 type ResourceQuery struct {
 	consts.Query
-	Name string `form:"name"`
+	Domain string `form:"domain"`
+	Name   string `form:"name"`
+	Source string `form:"source"`
+	Stale  bool   `form:"stale"`
 }
 
 // RoleQuery 角色搜索
 type RoleQuery struct {
 	consts.Query
-	Name string `form:"name"`
+	Domain string `form:"domain"`
+	Name   string `form:"name"`
 }
 
 // EmptyQuery 搜索
 type EmptyQuery struct {
 	consts.Query
 }
+
+// InviteQuery 邀请码和邀请记录搜索
+type InviteQuery struct {
+	consts.Query
+	Tenant    string `form:"-"`
+	InviterID string `form:"inviter_id"`
+	InviteID  string `form:"invite_id"`
+}
+
+// LegalQuery 协议文档搜索
+type LegalQuery struct {
+	consts.Query
+	Tenant string `form:"-"`
+	TP     string `form:"tp"`
+}
+
+// WordQuery 保留词和敏感词搜索
+type WordQuery struct {
+	consts.Query
+	Word  string `form:"word"`
+	TP    string `form:"tp"`
+	Field string `form:"field"`
+}
+
+// ModerationQuery 审核记录搜索
+type ModerationQuery struct {
+	consts.Query
+	Tenant string `form:"-"`
+	Status string `form:"status"`
+	Field  string `form:"field"`
+	UserID string `form:"user_id"`
+}
+
+// OrgQuery 组织搜索
+type OrgQuery struct {
+	consts.Query
+	Tenant string `form:"-"`
+	Name   string `form:"name"`
+}
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"runtime"
+
+	"github.com/ihuanglei/authenticator/models"
+	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/simplexwork/common"
+)
+
+const (
+	// 通知类邮箱配置
+	cateEmail = "email"
+	tpEmail   = "notice"
+
+	// 短信配置
+	cateSMS = "sms"
+	tpSMS   = "identify"
+
+	// 模板类型
+	cateTmpl = "tmpl"
+)
+
+// BuildMailMessage 使用租户的通知邮箱配置和邮件模板创建邮件
+func BuildMailMessage(tenant, tmpl string) (*MailMessage, error) {
+	// FIXME: 是否要缓存当前邮件数据?
+	dictDto, err := models.GetOneDict(tenant, cateEmail, tpEmail)
+	if err != nil {
+		return nil, err
+	}
+	var ev *MailMessage
+	if err := common.FromJSON([]byte(dictDto.Value), &ev); err != nil {
+		return nil, err
+	}
+	dictDto1, err := models.GetOneDict(tenant, cateTmpl, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.FromJSON([]byte(dictDto1.Value), &ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// BuildSMSMessage 使用租户的短信配置和短信模板创建短信
+func BuildSMSMessage(tenant, tmpl string) (*SMSMessage, error) {
+	// FIXME: 是否要缓存当前短信数据?
+	dictDto, err := models.GetOneDict(tenant, cateSMS, tpSMS)
+	if err != nil {
+		return nil, err
+	}
+	var ev *SMSMessage
+	if err := common.FromJSON([]byte(dictDto.Value), &ev); err != nil {
+		return nil, err
+	}
+	dictDto1, err := models.GetOneDict(tenant, cateTmpl, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	ev.Body = dictDto1.Value
+	return ev, nil
+}
+
+// Recover 发送消息失败时记录调用栈, 在发送消息的协程中 defer 调用
+func Recover() {
+	if err := recover(); err != nil {
+		var buf [1024]byte
+		n := runtime.Stack(buf[:], false)
+		logger.Error(string(buf[:n]))
+	}
+}
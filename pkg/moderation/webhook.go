@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	"github.com/simplexwork/common"
+)
+
+// DefaultTimeout 默认自动审核接口超时时间
+const DefaultTimeout = time.Second * 5
+
+// 自动审核接口返回的结果
+const (
+	resultApprove = "approve"
+	resultReject  = "reject"
+	resultReview  = "review"
+)
+
+// 响应内容上限
+const maxResponse = 64 << 10
+
+type request struct {
+	UserID common.ID `json:"user_id"`
+	Field  string    `json:"field"`
+	Value  string    `json:"value"`
+}
+
+type response struct {
+	Result string `json:"result"`
+	Reason string `json:"reason"`
+}
+
+// Webhook 调用外部接口审核昵称和头像
+// 以JSON格式POST {"user_id","field","value"}, 接口返回 {"result":"approve|reject|review","reason":""}
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook .
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Check 自动审核, review 或无法识别的结果转人工审核
+func (w *Webhook) Check(userID common.ID, field, value string) (consts.ModerationStatus, string, error) {
+	body, err := json.Marshal(&request{UserID: userID, Field: field, Value: value})
+	if err != nil {
+		return consts.ModerationPending, "", err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return consts.ModerationPending, "", fmt.Errorf("moderation webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return consts.ModerationPending, "", fmt.Errorf("moderation webhook: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return consts.ModerationPending, "", fmt.Errorf("moderation webhook: %s %s", resp.Status, data)
+	}
+	var ret response
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return consts.ModerationPending, "", fmt.Errorf("moderation webhook: %v", err)
+	}
+	switch ret.Result {
+	case resultApprove:
+		return consts.ModerationApproved, "", nil
+	case resultReject:
+		return consts.ModerationRejected, ret.Reason, nil
+	case resultReview:
+		return consts.ModerationPending, "", nil
+	}
+	return consts.ModerationPending, "", fmt.Errorf("moderation webhook: unknown result %q", ret.Result)
+}
@@ -0,0 +1,59 @@
+package moderation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ihuanglei/authenticator/pkg/consts"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["user_id"] != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case strings.Contains(req["value"], "bad"):
+			w.Write([]byte(`{"result":"reject","reason":"广告"}`))
+		case strings.Contains(req["value"], "maybe"):
+			w.Write([]byte(`{"result":"review"}`))
+		case strings.Contains(req["value"], "down"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"result":"approve"}`))
+		}
+	}))
+	defer server.Close()
+	webhook := NewWebhook(server.URL, 0)
+
+	Convey("approve", t, func() {
+		status, _, err := webhook.Check(1, consts.ModerationFieldNickname, "tom")
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, consts.ModerationApproved)
+	})
+
+	Convey("reject with reason", t, func() {
+		status, reason, err := webhook.Check(1, consts.ModerationFieldNickname, "bad tom")
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, consts.ModerationRejected)
+		So(reason, ShouldEqual, "广告")
+	})
+
+	Convey("manual review", t, func() {
+		status, _, err := webhook.Check(1, consts.ModerationFieldAvatar, "maybe.jpg")
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, consts.ModerationPending)
+	})
+
+	Convey("unavailable", t, func() {
+		status, _, err := webhook.Check(1, consts.ModerationFieldNickname, "down")
+		So(err, ShouldNotBeNil)
+		So(status, ShouldEqual, consts.ModerationPending)
+	})
+}
@@ -0,0 +1,106 @@
+//go:build ignore
+// +build ignore
+
+// 从控制器的接口文档注释中提取 @Summary 和 @Router, 生成 summaries.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const root = "../../controller"
+
+var (
+	basePathRe = regexp.MustCompile(`@BasePath\s+(\S+)`)
+	summaryRe  = regexp.MustCompile(`^@Summary\s+(.+)$`)
+	routerRe   = regexp.MustCompile(`^@Router\s+(\S+)\s+\[(\w+)\]`)
+)
+
+func main() {
+	doc, err := ioutil.ReadFile(filepath.Join(root, "api_doc.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	basePath := ""
+	if m := basePathRe.FindSubmatch(doc); m != nil {
+		basePath = strings.TrimRight(string(m[1]), "/")
+	}
+
+	summaries := map[string]string{}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			var summary, method, pattern string
+			for _, c := range fn.Doc.List {
+				line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+				if m := summaryRe.FindStringSubmatch(line); m != nil {
+					summary = strings.TrimSpace(m[1])
+				} else if m := routerRe.FindStringSubmatch(line); m != nil {
+					pattern, method = basePath+m[1], m[2]
+				}
+			}
+			if summary != "" && pattern != "" {
+				summaries[key(method, pattern)] = summary
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(summaries))
+	for k := range summaries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by go generate; DO NOT EDIT.\n\n")
+	buf.WriteString("package routes\n\n")
+	buf.WriteString("// 接口文档中各路由的@Summary\n")
+	buf.WriteString("var _Summaries = map[string]string{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "\t%q: %q,\n", k, summaries[k])
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("summaries.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// 与 routes.Key 相同的规则
+func key(method, pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") {
+			segments[i] = ":"
+		}
+	}
+	return strings.ToLower(method) + " " + strings.Join(segments, "/")
+}
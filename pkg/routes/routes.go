@@ -0,0 +1,98 @@
+package routes
+
+//go:generate go run gen.go
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/macaron.v1"
+)
+
+// 作为资源的请求方法, OPTIONS、HEAD等不作为资源
+var _Methods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// 手动登记的路由名称, 优先于接口文档中的@Summary
+var _Registry = struct {
+	sync.RWMutex
+	names map[string]string
+}{names: map[string]string{}}
+
+// Route 已注册的路由
+type Route struct {
+	// Method 小写的请求方法
+	Method string
+	// Pattern 路由规则, 例如 /v1/admin/user/:userID
+	Pattern string
+	// Name 名称
+	Name string
+}
+
+// List 列出已注册的路由, 按规则和方法排序
+// macaron没有提供遍历路由的方法, 通过反射读取内部的路由表, 只在启动时调用
+func List(m *macaron.Macaron) []*Route {
+	var routes []*Route
+	table := reflect.ValueOf(m.Router).Elem().FieldByName("routeMap").Elem().FieldByName("routes")
+	for _, method := range table.MapKeys() {
+		if !_Methods[method.String()] {
+			continue
+		}
+		for _, key := range table.MapIndex(method).MapKeys() {
+			// 分组下注册的 / 带有结尾的斜杠, 与请求路径和接口文档保持一致
+			pattern := key.String()
+			if len(pattern) > 1 {
+				pattern = strings.TrimSuffix(pattern, "/")
+			}
+			routes = append(routes, &Route{
+				Method:  strings.ToLower(method.String()),
+				Pattern: pattern,
+				Name:    Name(method.String(), pattern),
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern == routes[j].Pattern {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Pattern < routes[j].Pattern
+	})
+	return routes
+}
+
+// Register 登记路由名称, 用于没有接口文档或需要覆盖文档名称的路由
+func Register(method, pattern, name string) {
+	_Registry.Lock()
+	defer _Registry.Unlock()
+	_Registry.names[Key(method, pattern)] = name
+}
+
+// Name 路由名称, 先查找手动登记的名称, 再查找接口文档中的@Summary, 都没有时返回空
+func Name(method, pattern string) string {
+	key := Key(method, pattern)
+	_Registry.RLock()
+	name, ok := _Registry.names[key]
+	_Registry.RUnlock()
+	if ok {
+		return name
+	}
+	return _Summaries[key]
+}
+
+// Key 路由的唯一标识, 路径参数统一替换为 :, 兼容 :userID 和接口文档的 {id} 两种写法
+func Key(method, pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") {
+			segments[i] = ":"
+		}
+	}
+	return strings.ToLower(method) + " " + strings.Join(segments, "/")
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+func TestList(t *testing.T) {
+	Convey("list registered routes", t, func() {
+		m := macaron.New()
+		handler := func() {}
+		m.Group("/v1/admin", func() {
+			m.Group("/word", func() {
+				m.Get("/", handler)
+				m.Post("/:wordID/update", handler)
+			})
+			m.Get("/custom", handler)
+		})
+		m.Options("/*", handler)
+		Register("get", "/v1/admin/custom", "自定义")
+
+		routes := List(m)
+		So(len(routes), ShouldEqual, 3)
+		So(*routes[0], ShouldResemble, Route{Method: "get", Pattern: "/v1/admin/custom", Name: "自定义"})
+		So(*routes[1], ShouldResemble, Route{Method: "get", Pattern: "/v1/admin/word", Name: "保留词和敏感词列表"})
+		So(routes[2].Pattern, ShouldEqual, "/v1/admin/word/:wordID/update")
+		So(routes[2].Name, ShouldEqual, "更新保留词或敏感词")
+	})
+
+	Convey("route key", t, func() {
+		So(Key("POST", "/v1/admin/user/:userID/role"), ShouldEqual, Key("post", "/v1/admin/user/{id}/role"))
+		So(Key("get", "/v1/admin/user"), ShouldNotEqual, Key("post", "/v1/admin/user"))
+	})
+}
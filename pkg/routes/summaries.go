@@ -0,0 +1,148 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package routes
+
+// 接口文档中各路由的@Summary
+var _Summaries = map[string]string{
+	"get /v1/admin/attribute":                            "自定义属性列表",
+	"get /v1/admin/authority/resource":                   "资源列表",
+	"get /v1/admin/authority/role":                       "角色列表",
+	"get /v1/admin/authority/role/:/condition":           "角色资源的条件",
+	"get /v1/admin/authority/role/:/parents":             "上级角色",
+	"get /v1/admin/authority/role/:/resource":            "角色资源",
+	"get /v1/admin/dict":                                 "根据类型查询字典",
+	"get /v1/admin/dict/one":                             "按类型和业务类型获取唯一字典",
+	"get /v1/admin/invite":                               "邀请码列表",
+	"get /v1/admin/invite/referral":                      "邀请注册记录",
+	"get /v1/admin/invite/referral/stat":                 "邀请人排行",
+	"get /v1/admin/legal":                                "协议列表",
+	"get /v1/admin/legal/:":                              "协议详情",
+	"get /v1/admin/moderation":                           "审核列表",
+	"get /v1/admin/org":                                  "组织列表",
+	"get /v1/admin/org/:":                                "组织信息",
+	"get /v1/admin/org/:/member":                         "组织成员",
+	"get /v1/admin/tenant":                               "租户列表",
+	"get /v1/admin/tenant/:":                             "租户详情",
+	"get /v1/admin/user":                                 "管理员获取用户列表",
+	"get /v1/admin/user/:":                               "管理员获取用户信息",
+	"get /v1/admin/user/:/address":                       "管理员获取用户地址",
+	"get /v1/admin/user/:/legal":                         "用户同意协议记录",
+	"get /v1/admin/user/:/login":                         "管理员获取用户登录历史",
+	"get /v1/admin/user/:/merge":                         "账号合并记录",
+	"get /v1/admin/user/:/role":                          "获取用户角色",
+	"get /v1/admin/user/:/role/log":                      "用户角色变更记录",
+	"get /v1/admin/user/export":                          "管理员导出用户, 搜索条件同用户列表",
+	"get /v1/admin/word":                                 "保留词和敏感词列表",
+	"get /v1/api/export/:":                               "下载导出数据(下载地址有时效)",
+	"get /v1/api/legal":                                  "当前生效的协议",
+	"get /v1/api/login/th/:":                             "第三方QQ，微信，微博登录地址",
+	"get /v1/api/org":                                    "我加入的组织",
+	"get /v1/api/org/:":                                  "组织信息",
+	"get /v1/api/org/:/invite":                           "未使用的组织邀请, 需要管理员",
+	"get /v1/api/org/:/member":                           "组织成员",
+	"get /v1/api/profile":                                "用户信息",
+	"get /v1/api/profile/address":                        "地址列表",
+	"get /v1/api/profile/legal":                          "需要同意的协议",
+	"get /v1/api/profile/third":                          "已绑定的第三方账号",
+	"get /v1/api/profile/third/:":                        "第三方QQ，微信，微博，Github绑定地址",
+	"get /v1/api/reg/activate":                           "邮箱注册激活用户",
+	"patch /v1/api/profile":                              "修改用户信息(只更新提交的字段, 昵称或头像变化时返回新令牌)",
+	"post /v1/admin/attribute/:/delete":                  "删除自定义属性, 同时删除所有用户的属性值",
+	"post /v1/admin/attribute/:/update":                  "更新自定义属性, 属性键不可修改",
+	"post /v1/admin/attribute/create":                    "创建自定义属性",
+	"post /v1/admin/authority/resource/:/delete":         "删除资源",
+	"post /v1/admin/authority/resource/:/update":         "更新自定义资源",
+	"post /v1/admin/authority/resource/create":           "创建自定义资源",
+	"post /v1/admin/authority/role/:/condition":          "设置角色资源的条件",
+	"post /v1/admin/authority/role/:/delete":             "删除角色",
+	"post /v1/admin/authority/role/:/parents":            "继承上级角色",
+	"post /v1/admin/authority/role/:/parents/:/delete":   "取消继承上级角色",
+	"post /v1/admin/authority/role/:/update":             "更新角色",
+	"post /v1/admin/authority/role/create":               "创建角色",
+	"post /v1/admin/dict/:/del":                          "删除字典",
+	"post /v1/admin/dict/:/update":                       "修改字典",
+	"post /v1/admin/dict/create":                         "创建字典",
+	"post /v1/admin/dict/one/update":                     "按类型和业务类型修改唯一字典",
+	"post /v1/admin/invite/:/revoke":                     "撤销邀请码",
+	"post /v1/admin/invite/create":                       "生成邀请码",
+	"post /v1/admin/legal/:/delete":                      "删除协议",
+	"post /v1/admin/legal/:/publish":                     "发布协议",
+	"post /v1/admin/legal/:/update":                      "更新协议",
+	"post /v1/admin/legal/create":                        "创建协议",
+	"post /v1/admin/moderation/:/approve":                "审核通过",
+	"post /v1/admin/moderation/:/reject":                 "审核拒绝",
+	"post /v1/admin/org/:/delete":                        "删除组织",
+	"post /v1/admin/org/:/member":                        "添加组织成员",
+	"post /v1/admin/org/:/member/:/remove":               "移除组织成员, 所有者需先转让组织",
+	"post /v1/admin/org/:/member/:/role":                 "设置成员角色",
+	"post /v1/admin/org/:/transfer":                      "转让组织, 原所有者成为管理员",
+	"post /v1/admin/tenant/:/delete":                     "删除租户",
+	"post /v1/admin/tenant/:/update":                     "更新租户",
+	"post /v1/admin/tenant/create":                       "创建租户",
+	"post /v1/admin/user/:/activate":                     "激活用户",
+	"post /v1/admin/user/:/attribute":                    "设置用户自定义属性, 参数名为属性键, 空值删除",
+	"post /v1/admin/user/:/export":                       "导出用户数据(异步生成, 返回下载地址)",
+	"post /v1/admin/user/:/forbidden/:":                  "禁止/恢复用户",
+	"post /v1/admin/user/:/merge":                        "将source_id账号合并到当前账号, 被合并的账号将被删除",
+	"post /v1/admin/user/:/password":                     "修改密码",
+	"post /v1/admin/user/:/reset":                        "重置错误登录",
+	"post /v1/admin/user/:/role":                         "添加用户角色",
+	"post /v1/admin/user/create":                         "创建用户, 用户名、邮箱、手机号至少填写一项, 无密码时随机生成",
+	"post /v1/admin/user/import":                         "批量导入用户",
+	"post /v1/admin/word/:/delete":                       "删除保留词或敏感词",
+	"post /v1/admin/word/:/update":                       "更新保留词或敏感词",
+	"post /v1/admin/word/create":                         "添加保留词或敏感词",
+	"post /v1/api/code/bind/email":                       "绑定或更新邮箱验证码(用户已登录)",
+	"post /v1/api/code/bind/mobile":                      "绑定或更新手机号验证码(用户已登录)",
+	"post /v1/api/code/delete":                           "注销账号验证码(用户已登录)",
+	"post /v1/api/code/forgot/email":                     "忘记密码重置验证码",
+	"post /v1/api/code/login":                            "手机登录验证码",
+	"post /v1/api/code/password":                         "更新密码验证码(用户已登录)",
+	"post /v1/api/code/reg":                              "手机注册验证码",
+	"post /v1/api/forgot/reset/email":                    "忘记密码重置",
+	"post /v1/api/login":                                 "手机号\\邮箱\\用户名和密码登录",
+	"post /v1/api/login/mobile":                          "手机号和验证码登录",
+	"post /v1/api/login/th/:":                            "第三方QQ，微信，微博使用code登录",
+	"post /v1/api/login/th/weixinmp/:":                   "微信小程序登录",
+	"post /v1/api/org/:/delete":                          "删除组织, 需要所有者",
+	"post /v1/api/org/:/invite":                          "创建组织邀请, 需要管理员, 返回邀请码",
+	"post /v1/api/org/:/invite/:/revoke":                 "撤销组织邀请, 需要管理员",
+	"post /v1/api/org/:/leave":                           "退出组织, 所有者需先转让组织",
+	"post /v1/api/org/:/member/:/remove":                 "移除成员, 需要管理员, 只能移除角色低于自己的成员",
+	"post /v1/api/org/:/member/:/role":                   "设置成员角色, 需要所有者",
+	"post /v1/api/org/:/transfer":                        "转让组织, 需要所有者, 转让后原所有者成为管理员",
+	"post /v1/api/org/:/update":                          "更新组织, 需要管理员",
+	"post /v1/api/org/create":                            "创建组织, 创建者为所有者",
+	"post /v1/api/org/invite/accept":                     "接受组织邀请",
+	"post /v1/api/profile/address/:/default":             "设为默认地址",
+	"post /v1/api/profile/address/:/delete":              "删除地址",
+	"post /v1/api/profile/address/:/update":              "更新地址",
+	"post /v1/api/profile/address/create":                "新增地址",
+	"post /v1/api/profile/attribute":                     "设置自定义属性, 参数名为属性键, 空值删除, 仅管理员可见的属性不可设置",
+	"post /v1/api/profile/delete":                        "注销账号(宽限期内登录即取消)",
+	"post /v1/api/profile/delete/cancel":                 "取消注销账号",
+	"post /v1/api/profile/export":                        "导出个人数据(异步生成, 返回下载地址)",
+	"post /v1/api/profile/legal/accept":                  "同意协议",
+	"post /v1/api/profile/merge":                         "将另一个账号合并到当前账号",
+	"post /v1/api/profile/third/:":                       "第三方QQ，微信，微博，Github使用code绑定",
+	"post /v1/api/profile/third/:/unbind":                "解绑第三方(不能解绑唯一的登录方式)",
+	"post /v1/api/profile/third/weixinmp/:":              "绑定微信小程序",
+	"post /v1/api/profile/update/avatar":                 "修改头像",
+	"post /v1/api/profile/update/avatar/upload":          "上传头像",
+	"post /v1/api/profile/update/bind/email":             "根据邮箱验证码绑定或更新邮箱",
+	"post /v1/api/profile/update/bind/mobile":            "根据手机验证码绑定或更新手机号",
+	"post /v1/api/profile/update/bind/mobile/weixinmp/:": "微信小程序更新手机号",
+	"post /v1/api/profile/update/gender":                 "修改性别",
+	"post /v1/api/profile/update/nickname":               "修改昵称",
+	"post /v1/api/profile/update/password/mobile":        "根据手机验证码修改密码",
+	"post /v1/api/profile/update/password/old":           "根据原密码修改密码",
+	"post /v1/api/reg/activate/resend":                   "重发邮件激活码",
+	"post /v1/api/reg/email":                             "邮箱和密码注册",
+	"post /v1/api/reg/mobile":                            "手机号和密码注册",
+	"post /v1/api/reg/name":                              "用户名和密码注册",
+	"post /v1/api/reg/third":                             "第三方QQ，微信，微博使用code注册",
+	"post /v1/api/reg/weixinmp/mobile/:":                 "微信小程序注册(通过手机号)",
+	"post /v1/api/reg/weixinmp/userinfo/:":               "微信小程序注册(通过用户信息)",
+	"post /v1/authz/check":                               "权限检查",
+	"post /v1/authz/check/batch":                         "批量权限检查",
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ Storage = (*LocalStorage)(nil)
+
+// LocalStorage 本地文件存储
+type LocalStorage struct {
+	dir string
+	url string
+}
+
+// NewLocalStorage dir为保存目录, url为访问地址前缀
+func NewLocalStorage(dir, url string) *LocalStorage {
+	return &LocalStorage{dir: dir, url: strings.TrimRight(url, "/")}
+}
+
+// Dir 保存目录
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// Prefix 访问地址的路径, 用于挂载静态文件
+func (s *LocalStorage) Prefix() string {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return LocalPrefix
+	}
+	return strings.TrimRight(u.Path, "/")
+}
+
+// Put 保存文件
+func (s *LocalStorage) Put(key string, data []byte, contentType string) (string, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return "", err
+	}
+	return s.url + "/" + key, nil
+}
+
+// Delete 删除文件, 文件不存在时忽略
+func (s *LocalStorage) Delete(key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Key 访问地址对应的文件名
+func (s *LocalStorage) Key(url string) (string, bool) {
+	if !strings.HasPrefix(url, s.url+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(url, s.url+"/"), true
+}
+
+// 文件路径, 不允许超出保存目录
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", os.ErrInvalid
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
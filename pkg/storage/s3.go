@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/simplexwork/common"
+)
+
+var _ Storage = (*S3Storage)(nil)
+
+const (
+	defaultRegion = "us-east-1"
+	s3Service     = "s3"
+	amzDateFormat = "20060102T150405Z"
+)
+
+// S3Options S3兼容的对象存储配置
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// URL 访问地址前缀, 为空时使用Endpoint
+	URL string
+	// PathStyle 使用路径方式访问bucket
+	PathStyle bool
+}
+
+// S3Storage S3兼容的对象存储, 使用AWS签名V4
+type S3Storage struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage .
+func NewS3Storage(opts S3Options) (*S3Storage, error) {
+	if common.IsEmpty(opts.Bucket) || common.IsEmpty(opts.AccessKey) || common.IsEmpty(opts.SecretKey) {
+		return nil, fmt.Errorf("s3 storage: bucket, access_key and secret_key are required")
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 storage: invalid endpoint %q", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = defaultRegion
+	}
+	opts.URL = strings.TrimRight(opts.URL, "/")
+	return &S3Storage{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Second * 30},
+		now:      time.Now,
+	}, nil
+}
+
+// Put 上传文件
+func (s *S3Storage) Put(key string, data []byte, contentType string) (string, error) {
+	req, err := s.newRequest(http.MethodPut, key, data)
+	if err != nil {
+		return "", err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if err := s.do(req, data); err != nil {
+		return "", err
+	}
+	if s.opts.URL != "" {
+		return s.opts.URL + "/" + uriEncode(key), nil
+	}
+	return req.URL.String(), nil
+}
+
+// Delete 删除文件
+func (s *S3Storage) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+// Key 访问地址对应的文件名
+func (s *S3Storage) Key(rawURL string) (string, bool) {
+	prefix := s.opts.URL
+	if prefix == "" {
+		u := *s.endpoint
+		u.Path = ""
+		if s.opts.PathStyle {
+			u.Path = "/" + s.opts.Bucket
+		} else {
+			u.Host = s.opts.Bucket + "." + u.Host
+		}
+		prefix = u.String()
+	}
+	if !strings.HasPrefix(rawURL, prefix+"/") {
+		return "", false
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(rawURL, prefix+"/"))
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+func (s *S3Storage) newRequest(method, key string, data []byte) (*http.Request, error) {
+	u := *s.endpoint
+	path := "/" + key
+	if s.opts.PathStyle {
+		path = "/" + s.opts.Bucket + path
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = uriEncode(path)
+	return http.NewRequest(method, u.String(), bytes.NewReader(data))
+}
+
+func (s *S3Storage) do(req *http.Request, data []byte) error {
+	s.sign(req, data, s.now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 storage: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("s3 storage: %s %s: %s %s", req.Method, req.URL.Path, resp.Status, body)
+	}
+	return nil
+}
+
+// 签名请求, 参与签名的请求头为host、content-type和x-amz-*
+func (s *S3Storage) sign(req *http.Request, data []byte, t time.Time) {
+	amzDate := t.Format(amzDateFormat)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(data)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if k == "content-type" || strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, s.opts.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.opts.SecretKey, date, s.opts.Region, s3Service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature))
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// 按签名V4的要求编码路径, 只保留非保留字符和路径分隔符
+func uriEncode(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/ihuanglei/authenticator/pkg/config"
+	"github.com/simplexwork/common"
+)
+
+const (
+	// Local 本地存储
+	Local = "local"
+	// S3 S3兼容的对象存储
+	S3 = "s3"
+
+	// 本地存储默认目录
+	defaultDir = "data/upload"
+	// LocalPrefix 本地存储文件的访问路径
+	LocalPrefix = "/upload"
+)
+
+// Storage 上传文件存储
+type Storage interface {
+	// Put 保存文件, 返回访问地址
+	Put(key string, data []byte, contentType string) (string, error)
+	// Delete 删除文件
+	Delete(key string) error
+	// Key 访问地址对应的文件名, 不是该存储的地址时返回false
+	Key(url string) (string, bool)
+}
+
+// New 根据配置创建存储, 默认使用本地存储
+func New(config *config.Config) (Storage, error) {
+	switch config.Storage.Type {
+	case "", Local:
+		dir := config.Storage.Local.Dir
+		if common.IsEmpty(dir) {
+			dir = defaultDir
+		}
+		url := config.Storage.Local.URL
+		if common.IsEmpty(url) {
+			url = LocalPrefix
+		}
+		return NewLocalStorage(dir, url), nil
+	case S3:
+		s3 := config.Storage.S3
+		return NewS3Storage(S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			URL:       s3.URL,
+			PathStyle: s3.PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage type: %s", config.Storage.Type)
+}
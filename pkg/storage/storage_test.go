@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// 模拟S3服务, 校验签名头和内容摘要后保存对象
+type s3StandIn struct {
+	sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = body
+		s.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	Convey("aws signature v4 signing key", t, func() {
+		key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+		So(hex.EncodeToString(key), ShouldEqual, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d")
+	})
+}
+
+func TestS3Storage(t *testing.T) {
+	standIn := &s3StandIn{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	s, err := NewS3Storage(S3Options{Endpoint: server.URL, Bucket: "avatar", AccessKey: "AK", SecretKey: "SK", PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("put and delete object", t, func() {
+		url, err := s.Put("1/a_256.jpg", []byte("jpeg"), "image/jpeg")
+		So(err, ShouldBeNil)
+		So(url, ShouldEqual, server.URL+"/avatar/1/a_256.jpg")
+		So(string(standIn.objects["/avatar/1/a_256.jpg"]), ShouldEqual, "jpeg")
+		So(standIn.types["/avatar/1/a_256.jpg"], ShouldEqual, "image/jpeg")
+
+		key, ok := s.Key(url)
+		So(ok, ShouldBeTrue)
+		So(key, ShouldEqual, "1/a_256.jpg")
+		_, ok = s.Key("https://other.example.com/avatar/1/a_256.jpg")
+		So(ok, ShouldBeFalse)
+
+		So(s.Delete("1/a_256.jpg"), ShouldBeNil)
+		So(standIn.objects, ShouldNotContainKey, "/avatar/1/a_256.jpg")
+	})
+
+	Convey("custom url prefix", t, func() {
+		s.opts.URL = "https://cdn.example.com"
+		defer func() { s.opts.URL = "" }()
+		url, err := s.Put("1/b_64.jpg", []byte("jpeg"), "image/jpeg")
+		So(err, ShouldBeNil)
+		So(url, ShouldEqual, "https://cdn.example.com/1/b_64.jpg")
+	})
+
+	Convey("rejected request", t, func() {
+		bad, _ := NewS3Storage(S3Options{Endpoint: server.URL, Bucket: "avatar", AccessKey: "XX", SecretKey: "SK", PathStyle: true})
+		_, err := bad.Put("1/c.jpg", []byte("jpeg"), "image/jpeg")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := NewLocalStorage(dir, "/upload/")
+
+	Convey("put and delete file", t, func() {
+		url, err := s.Put("avatar/1/a.jpg", []byte("jpeg"), "image/jpeg")
+		So(err, ShouldBeNil)
+		So(url, ShouldEqual, "/upload/avatar/1/a.jpg")
+		data, err := ioutil.ReadFile(filepath.Join(dir, "avatar", "1", "a.jpg"))
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEqual, "jpeg")
+		key, ok := s.Key(url)
+		So(ok, ShouldBeTrue)
+		So(key, ShouldEqual, "avatar/1/a.jpg")
+		So(s.Delete("avatar/1/a.jpg"), ShouldBeNil)
+		So(s.Delete("avatar/1/a.jpg"), ShouldBeNil)
+	})
+
+	Convey("mount prefix of url", t, func() {
+		So(s.Prefix(), ShouldEqual, "/upload")
+		So(NewLocalStorage(dir, "https://cdn.example.com/files/").Prefix(), ShouldEqual, "/files")
+	})
+
+	Convey("key outside dir", t, func() {
+		_, err := s.Put("../a.jpg", []byte("jpeg"), "image/jpeg")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -4,14 +4,23 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/casbin/casbin/v2"
 	"github.com/ihuanglei/authenticator/controller/admin"
 	"github.com/ihuanglei/authenticator/controller/api"
+	"github.com/ihuanglei/authenticator/models"
 	"github.com/ihuanglei/authenticator/pkg/authzer"
 	"github.com/ihuanglei/authenticator/pkg/config"
 	"github.com/ihuanglei/authenticator/pkg/consts"
 	"github.com/ihuanglei/authenticator/pkg/context"
+	"github.com/ihuanglei/authenticator/pkg/export"
+	"github.com/ihuanglei/authenticator/pkg/job"
 	"github.com/ihuanglei/authenticator/pkg/logger"
+	"github.com/ihuanglei/authenticator/pkg/mapper/st"
+	"github.com/ihuanglei/authenticator/pkg/moderation"
+	"github.com/ihuanglei/authenticator/pkg/routes"
+	"github.com/ihuanglei/authenticator/pkg/storage"
 
 	"github.com/simplexwork/cache"
 	"github.com/simplexwork/common"
@@ -47,10 +56,52 @@ func Run(config *config.Config) {
 	m.Use(logger.MacaronLogger())
 	m.Use(macaron.Renderer())
 	m.Use(context.Contexter())
+	m.Use(context.Tenanter(findTenant(models.LookupTenant), findTenant(models.LookupTenantByHost)))
+
+	// 策略的时间、IP和所有者条件, 启动时加载, 由定时任务同步其他实例的修改
+	conditions := authzer.NewConditions()
+	if err := conditions.Reload(); err != nil {
+		logger.Fatalln(err)
+	}
+	enforcer := authzer.NewAuthzer(conditions)
+	if err := authzer.SetupSuperAdmin(enforcer, config.Admin.Users, config.Admin.Roles); err != nil {
+		logger.Fatalln(err)
+	}
+	orgEnforcer := authzer.NewOrgAuthzer()
+	domainEnforcer := authzer.NewDomainAuthzer(conditions)
+	// 有效期角色, 过期的在检查权限时忽略, 由定时任务移除
+	grants := authzer.NewGrants()
+	for _, e := range []*casbin.Enforcer{enforcer, domainEnforcer.Enforcer} {
+		if err := grants.Enable(e); err != nil {
+			logger.Fatalln(err)
+		}
+	}
+	exporter := export.NewExporter(config, cache, enforcer)
+	store, err := storage.New(config)
+	if err != nil {
+		logger.Fatalln(err)
+	}
+	if local, ok := store.(*storage.LocalStorage); ok {
+		m.Use(macaron.Static(local.Dir(), macaron.StaticOptions{Prefix: local.Prefix(), SkipLogging: true}))
+	}
+
+	// 昵称和头像审核, 配置了自动审核接口时先自动审核
+	var checker models.ModerationChecker
+	if config.Moderation.Webhook != "" {
+		checker = moderation.NewWebhook(config.Moderation.Webhook, time.Duration(config.Moderation.Timeout)*time.Second)
+	}
+	models.SetModeration(config.Moderation.Enable, checker)
 
 	// 注入
 	m.Map(cache)
-	m.Map(authzer.NewAuthzer())
+	m.Map(enforcer)
+	m.Map(orgEnforcer)
+	m.Map(domainEnforcer)
+	m.Map(grants)
+	m.Map(conditions)
+	m.Map(authzer.NewChecker(enforcer, domainEnforcer, grants, conditions, time.Duration(config.Authz.Cache)*time.Second))
+	m.Map(exporter)
+	m.MapTo(store, (*storage.Storage)(nil))
 	m.Map(config)
 
 	m.NotFound(func(ctx *context.Context) {
@@ -62,13 +113,49 @@ func Run(config *config.Config) {
 
 	// 解决跨域访问
 	m.Options("/*", func(ctx *context.Context) {
-		ctx.Resp.Header().Set("Access-Control-Allow-Headers", fmt.Sprintf("%s,%s", consts.HeaderAuthorizationKey, consts.HeaderAuthorizationAdminKey))
-		ctx.Resp.Header().Set("Access-Control-Allow-Methods", "POST,GET")
+		tenantHeader := config.Tenant.Header
+		if tenantHeader == "" {
+			tenantHeader = consts.HeaderTenantKey
+		}
+		ctx.Resp.Header().Set("Access-Control-Allow-Headers", fmt.Sprintf("%s,%s,%s", consts.HeaderAuthorizationKey, consts.HeaderAuthorizationAdminKey, tenantHeader))
+		ctx.Resp.Header().Set("Access-Control-Allow-Methods", "POST,GET,PATCH")
 	})
 
 	api.Router(m)
 	admin.Router(m)
 
+	// 已注册的路由同步到资源表
+	var resources []*st.ResourceDto
+	for _, route := range routes.List(m) {
+		resources = append(resources, &st.ResourceDto{Name: route.Name, URL: route.Pattern, Method: route.Method})
+	}
+	if added, stale, err := models.SyncResources(resources); err != nil {
+		logger.Error(err)
+	} else if added > 0 || stale > 0 {
+		logger.Infof("Sync resources: %d added, %d stale", added, stale)
+	}
+
+	// 定时任务
+	job.Every("purge deleted users", time.Hour, func() error {
+		err := models.PurgeDeletedUsers(func(userID common.ID) error {
+			return authzer.RemoveUser(enforcer, domainEnforcer, userID.Str())
+		})
+		if err != nil {
+			return err
+		}
+		return grants.Reload()
+	})
+	job.Every("clean export files", time.Hour, exporter.Clean)
+	job.Every("reload tenants", time.Minute, models.ReloadTenants)
+	job.Every("reload words", time.Minute, models.ReloadWords)
+	job.Every("sweep role grants", time.Minute, func() error {
+		return grants.Sweep(enforcer, domainEnforcer)
+	})
+	job.Every("reload policy conditions", time.Minute, conditions.Reload)
+	job.Every("finish user merges", time.Minute, func() error {
+		return grants.FinishMerges(enforcer, domainEnforcer)
+	})
+
 	// IP PORT
 	host := config.Server.Host
 	if len(host) == 0 {
@@ -87,3 +174,14 @@ func Run(config *config.Config) {
 	logger.Infof("[WEB] Listening on %s", addr)
 	logger.Fatalln(http.ListenAndServe(addr, m))
 }
+
+// 租户查询适配租户识别中间件
+func findTenant(lookup func(string) (*st.TenantDto, bool)) context.TenantFinder {
+	return func(key string) (string, string, bool) {
+		tenantDto, ok := lookup(key)
+		if !ok {
+			return "", "", false
+		}
+		return tenantDto.Code, tenantDto.Secret, true
+	}
+}
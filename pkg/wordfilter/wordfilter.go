@@ -0,0 +1,108 @@
+package wordfilter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Matcher 多模式匹配, 基于Aho-Corasick自动机, 匹配时忽略大小写、空白和标点
+type Matcher struct {
+	nodes []node
+	words []string
+}
+
+type node struct {
+	next map[rune]int
+	fail int
+	// 以该节点结尾的词, 包括后缀链接上的词, -1为没有
+	out int
+}
+
+// New 创建匹配器, 空词忽略
+func New(words []string) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}, out: -1}}}
+	for _, word := range words {
+		m.add(word)
+	}
+	m.build()
+	return m
+}
+
+// Len 词数量
+func (m *Matcher) Len() int {
+	return len(m.words)
+}
+
+// Find 返回文本中第一个匹配的词
+func (m *Matcher) Find(text string) (string, bool) {
+	state := 0
+	for _, r := range Normalize(text) {
+		for state != 0 && m.nodes[state].next[r] == 0 {
+			state = m.nodes[state].fail
+		}
+		state = m.nodes[state].next[r]
+		if out := m.nodes[state].out; out >= 0 {
+			return m.words[out], true
+		}
+	}
+	return "", false
+}
+
+// Normalize 转为小写并去掉空白和标点
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+func (m *Matcher) add(word string) {
+	key := Normalize(word)
+	if key == "" {
+		return
+	}
+	state := 0
+	for _, r := range key {
+		next, ok := m.nodes[state].next[r]
+		if !ok {
+			m.nodes = append(m.nodes, node{next: map[rune]int{}, out: -1})
+			next = len(m.nodes) - 1
+			m.nodes[state].next[r] = next
+		}
+		state = next
+	}
+	if m.nodes[state].out < 0 {
+		m.nodes[state].out = len(m.words)
+		m.words = append(m.words, word)
+	}
+}
+
+// 广度优先设置失败指针, 节点没有词时继承失败指针上的词
+func (m *Matcher) build() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[state].next {
+			fail := m.nodes[state].fail
+			for fail != 0 && m.nodes[fail].next[r] == 0 {
+				fail = m.nodes[fail].fail
+			}
+			if f, ok := m.nodes[fail].next[r]; ok && f != child {
+				fail = f
+			} else {
+				fail = 0
+			}
+			m.nodes[child].fail = fail
+			if m.nodes[child].out < 0 {
+				m.nodes[child].out = m.nodes[fail].out
+			}
+			queue = append(queue, child)
+		}
+	}
+}
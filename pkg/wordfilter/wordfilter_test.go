@@ -0,0 +1,40 @@
+package wordfilter
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMatcher(t *testing.T) {
+	m := New([]string{"he", "she", "his", "hers", "管理员", "Admin", ""})
+
+	Convey("empty word is ignored", t, func() {
+		So(m.Len(), ShouldEqual, 6)
+	})
+
+	Convey("overlapping words", t, func() {
+		word, ok := m.Find("ushers")
+		So(ok, ShouldBeTrue)
+		So(word, ShouldEqual, "she")
+		word, ok = m.Find("ahishers")
+		So(ok, ShouldBeTrue)
+		So(word, ShouldEqual, "his")
+	})
+
+	Convey("ignore case, space and punctuation", t, func() {
+		word, ok := m.Find("super A-d m.i_n")
+		So(ok, ShouldBeTrue)
+		So(word, ShouldEqual, "Admin")
+		word, ok = m.Find("我是 管 理 员!")
+		So(ok, ShouldBeTrue)
+		So(word, ShouldEqual, "管理员")
+	})
+
+	Convey("no match", t, func() {
+		_, ok := m.Find("xyz 管理")
+		So(ok, ShouldBeFalse)
+		_, ok = New(nil).Find("anything")
+		So(ok, ShouldBeFalse)
+	})
+}